@@ -1,5 +1,9 @@
 package api
 
+import "github.com/solo-io/valet/pkg/render"
+
 type ValetGlobalConfig struct {
 	Env map[string]string `json:"env"`
+	// Values provided to every workflow, with the lowest precedence
+	Values render.Values `json:"values,omitempty"`
 }
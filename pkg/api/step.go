@@ -6,7 +6,10 @@ import (
 	"github.com/solo-io/valet/pkg/client/helm"
 	"github.com/solo-io/valet/pkg/client/kube"
 	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/diagnostics"
+	"github.com/solo-io/valet/pkg/log"
 	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/report"
 )
 
 type Step interface {
@@ -15,13 +18,180 @@ type Step interface {
 	GetDocs(ctx *WorkflowContext, values render.Values, flags render.Flags) (string, error)
 }
 
+// Steps can implement ValidatingStep to check for problems, such as missing files or invalid fields, without
+// running anything. The workflow already checks the valet tags of the step's fields.
+type ValidatingStep interface {
+	Validate(ctx *WorkflowContext, values render.Values) error
+}
+
+// Steps that change the cluster can implement DryRunStep to show what they would do, without changing anything.
+// During a dry run, steps that don't implement it are skipped.
+type DryRunStep interface {
+	DryRun(ctx *WorkflowContext, values render.Values) error
+}
+
+// Steps that apply or delete manifests can implement ManifestStep, so the rendered manifests of a failed step are
+// included in its diagnostics. They should also record the namespaces of their objects with TrackManifests.
+type ManifestStep interface {
+	GetManifests(ctx *WorkflowContext, values render.Values) (string, error)
+}
+
 type WorkflowContext struct {
 	Ctx context.Context
-	//Logger
-	//SharedState
+	// Optional, defaults to log.Default(). Use GetLogger to access.
+	Logger log.Logger
+	// Values captured from the outputs of earlier steps, which are available to later steps
+	SharedState render.Values
+	// Outputs published by the step that is currently running, see SetOutput
+	Outputs      map[string]string
 	Runner       cmd.Runner
 	FileStore    render.FileStore
 	HelmClient   helm.Client
 	KubeClient   kube.Client
 	AwsDnsClient aws.DnsClient
+
+	// Optional, the directory (or url) that relative paths in steps are resolved against. This is set to the
+	// directory of an included workflow while it runs; otherwise, paths are relative to the working directory.
+	Dir string
+
+	// The kube context and kubeconfig that steps should target. If empty, the current context
+	// and default kubeconfig are used. These are usually set with a useCluster step.
+	KubeContext string
+	Kubeconfig  string
+
+	// Optional, records the result of each step that is run
+	Report *report.Report
+	// If true, steps only show what they would do (see DryRunStep)
+	DryRun bool
+	// Optional, if set the workflow waits for the presenter before each step, and lets them retry, skip or abort
+	// when a step fails
+	Prompter cmd.Prompter
+	// If true, the docs for each step are shown when prompting the presenter
+	ShowDocs bool
+	// If true, apply, applyTemplate, createSecret, delete and patch steps use the kube client (with server-side apply)
+	// rather than kubectl, so kubectl doesn't need to be installed
+	NativeApply bool
+	// Optional, the sensitive values (i.e. secret values and the entries of created secrets) to hide in commands,
+	// logs, errors and reports. Use GetRedactor to access.
+	Redactor *render.Redactor
+	// Optional, if set the namespaces and helm releases that steps touch are recorded, and when a step fails, the
+	// pods, events, container logs and helm releases of those namespaces are collected into the bundle
+	Diagnostics *diagnostics.Bundle
+}
+
+// Returns the context of the workflow, or context.Background() if none was provided. It is done when the step times
+// out, so steps that wait should stop when it is done.
+func (c *WorkflowContext) GetContext() context.Context {
+	if c == nil || c.Ctx == nil {
+		return context.Background()
+	}
+	return c.Ctx
+}
+
+// Returns the logger for the workflow, or a default logger if none was provided.
+func (c *WorkflowContext) GetLogger() log.Logger {
+	if c == nil || c.Logger == nil {
+		return log.Default()
+	}
+	return c.Logger
+}
+
+// Returns the redactor for the workflow, or an empty redactor if none was provided.
+func (c *WorkflowContext) GetRedactor() *render.Redactor {
+	if c == nil || c.Redactor == nil {
+		return render.NewRedactor()
+	}
+	return c.Redactor
+}
+
+// Publish an output from the running step (i.e. the body of a curl response). A workflow step can capture
+// outputs into values for later steps.
+func (c *WorkflowContext) SetOutput(name, value string) {
+	if c.Outputs == nil {
+		c.Outputs = make(map[string]string)
+	}
+	c.Outputs[name] = value
+}
+
+// Record the namespaces of the objects in manifests that a step renders to apply or delete, so they are included in the
+// diagnostics if a step fails. Does nothing without a diagnostics bundle. Manifests that can't be parsed are left for
+// kubectl or the kube client to report.
+func (c *WorkflowContext) TrackManifests(manifests string) {
+	if c == nil || c.Diagnostics == nil {
+		return
+	}
+	objects, err := kube.ParseManifests(manifests)
+	if err != nil {
+		return
+	}
+	for _, object := range objects {
+		if object.GetKind() == "Namespace" {
+			c.Diagnostics.AddNamespace(object.GetName())
+		} else {
+			c.Diagnostics.AddNamespace(object.GetNamespace())
+		}
+	}
+}
+
+// Resolve a relative path in a step against the directory of the workflow. Use this for paths that are passed to
+// commands; paths loaded with the FileStore are already resolved.
+func (c *WorkflowContext) ResolvePath(path string) string {
+	if c == nil {
+		return path
+	}
+	return render.ResolvePath(c.Dir, path)
+}
+
+// Set the logger of the workflow, and scope the runner and clients that support it to the same logger, so their output
+// (i.e. of commands) is written with it too. Returns a function that restores the previous logger, runner and clients.
+func (c *WorkflowContext) ScopeLogger(logger log.Logger) (restore func()) {
+	previous := *c
+	c.Logger = logger
+	if runner, ok := c.Runner.(cmd.LoggingRunner); ok {
+		c.Runner = runner.WithLogger(logger)
+	}
+	if fileStore, ok := c.FileStore.(render.LoggingFileStore); ok {
+		c.FileStore = fileStore.WithLogger(logger)
+	}
+	if kubeClient, ok := c.KubeClient.(kube.LoggingClient); ok {
+		c.KubeClient = kubeClient.WithLogger(logger)
+	}
+	if helmClient, ok := c.HelmClient.(helm.LoggingClient); ok {
+		c.HelmClient = helmClient.WithLogger(logger)
+	}
+	return func() {
+		c.Logger, c.Runner, c.FileStore = previous.Logger, previous.Runner, previous.FileStore
+		c.KubeClient, c.HelmClient = previous.KubeClient, previous.HelmClient
+	}
+}
+
+// Point the workflow and its kube and helm clients at a kube context, without changing the current context of the
+// user's kubeconfig. Empty values fall back to the default kubeconfig and the current context.
+func (c *WorkflowContext) UseContext(kubeconfig, kubeContext string) {
+	c.Kubeconfig = kubeconfig
+	c.KubeContext = kubeContext
+	if c.KubeClient != nil {
+		c.KubeClient.UseContext(kubeconfig, kubeContext)
+	}
+	if c.HelmClient != nil {
+		c.HelmClient.UseContext(kubeconfig, kubeContext)
+	}
+}
+
+// Returns a kubectl command that targets the kube context of the workflow.
+func (c *WorkflowContext) Kubectl() *cmd.Kubectl {
+	kubectl := cmd.New().Kubectl()
+	if c == nil {
+		return kubectl
+	}
+	if c.Kubeconfig != "" {
+		kubectl = kubectl.Kubeconfig(c.Kubeconfig)
+	}
+	if c.KubeContext != "" {
+		kubectl = kubectl.Context(c.KubeContext)
+	}
+	if c.Redactor != nil {
+		kubectl = kubectl.WithRedactor(c.Redactor)
+	}
+	return kubectl
 }
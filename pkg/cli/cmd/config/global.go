@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+
 	"github.com/solo-io/go-utils/cliutils"
 	"github.com/solo-io/valet/pkg/cli/options"
 	"github.com/solo-io/valet/pkg/render"
@@ -22,7 +23,7 @@ func Config(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.C
 				}
 				globalConfigPath = defaultPath
 			}
-			fileStore := render.NewFileStore()
+			fileStore := render.NewFileStore().WithLogger(opts.Top.Logger)
 			if exists, err := fileStore.Exists(globalConfigPath); err != nil || !exists {
 				return err
 			}
@@ -30,8 +31,8 @@ func Config(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.C
 			if err != nil {
 				return err
 			}
-			fmt.Printf("%s\n", contents)
-			return nil
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%s", contents)
+			return err
 		},
 	}
 
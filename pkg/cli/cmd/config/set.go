@@ -1,7 +1,6 @@
 package config
 
 import (
-	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/workflow"
 	"strings"
@@ -38,7 +37,7 @@ func setConfig(opts *options.Options, args []string) error {
 		}
 		globalConfigPath = defaultPath
 	}
-	fileStore := render.NewFileStore()
+	fileStore := render.NewFileStore().WithLogger(opts.Top.Logger)
 
 	config, err := workflow.LoadGlobalConfig(globalConfigPath, fileStore)
 	if err != nil {
@@ -61,6 +60,6 @@ func setConfig(opts *options.Options, args []string) error {
 	if err != nil {
 		return err
 	}
-	cmd.Stdout().Println("Successfully updated config")
+	opts.Top.Logger.Infof("Successfully updated config")
 	return nil
 }
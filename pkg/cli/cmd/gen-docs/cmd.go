@@ -26,7 +26,7 @@ func GenDocs(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.
 }
 
 func genDocs(opts *options.Options) error {
-	ctx := workflow.DefaultContext(opts.Top.Ctx)
+	ctx := workflow.DefaultContextWithLogger(opts.Top.Ctx, opts.Top.Logger)
 	if opts.GenDocs.Template == "" {
 		return errors.Errorf("Must provide input docs template")
 	}
@@ -5,7 +5,10 @@ import (
 	"github.com/solo-io/valet/pkg/cli/cmd/config"
 	gen_docs "github.com/solo-io/valet/pkg/cli/cmd/gen-docs"
 	"github.com/solo-io/valet/pkg/cli/cmd/run"
+	"github.com/solo-io/valet/pkg/cli/cmd/validate"
+	"github.com/solo-io/valet/pkg/cli/cmd/values"
 	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/log"
 
 	"github.com/solo-io/go-utils/cliutils"
 
@@ -36,8 +39,23 @@ func ValetCli(version string) *cobra.Command {
 	optionsFunc := func(app *cobra.Command) {
 		app.SuggestionsMinimumDistance = 1
 		app.PersistentFlags().StringVarP(&opts.Config.GlobalConfigPath, "global-config-path", "", "", "alternate location for global config (default $HOME/.valet/global.yaml)")
+		app.PersistentFlags().StringVar(&opts.Top.LogLevel, "log-level", log.InfoLevel.String(), "minimum level of log messages to print (debug, info, warn or error)")
+		app.PersistentFlags().BoolVar(&opts.Top.LogJson, "log-json", false, "print log messages as json")
+		app.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+			level, err := log.ParseLevel(opts.Top.LogLevel)
+			if err != nil {
+				return err
+			}
+			opts.Top.Logger = log.New(log.Options{
+				Level: level,
+				Json:  opts.Top.LogJson,
+			})
+			return nil
+		}
 		app.AddCommand(
 			run.Run(opts),
+			validate.Validate(opts),
+			values.Values(opts),
 			config.Config(opts),
 			gen_docs.GenDocs(opts),
 		)
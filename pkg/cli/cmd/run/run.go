@@ -1,13 +1,28 @@
 package run
 
 import (
+	"os"
+
 	errors "github.com/rotisserie/eris"
 	"github.com/solo-io/go-utils/cliutils"
+	"github.com/solo-io/valet/pkg/api"
 	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/diagnostics"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/report"
 	"github.com/solo-io/valet/pkg/workflow"
 	"github.com/spf13/cobra"
 )
 
+var (
+	ConflictingSetupFlagsError  = errors.Errorf("Cannot provide both --skip-setup and --setup-only")
+	ConflictingResumeFlagsError = errors.Errorf("Cannot provide both --resume and --from")
+	NoCheckpointError           = func(file string) error {
+		return errors.Errorf("No checkpoint found for workflow %s, it can't be resumed", file)
+	}
+)
+
 func Run(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.Command {
 	runCmd := &cobra.Command{
 		Use:   "run",
@@ -20,6 +35,20 @@ func Run(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.Comm
 	cliutils.ApplyOptions(runCmd, optionsFunc)
 	runCmd.PersistentFlags().StringVarP(&opts.Run.File, "file", "f", "", "path to file containing config to ensure")
 	runCmd.PersistentFlags().StringToStringVarP(&opts.Run.Values, "values", "v", make(map[string]string), "values to provide to workflow")
+	runCmd.PersistentFlags().StringSliceVar(&opts.Run.ValuesFiles, "values-file", nil, "path (or url) of a yaml file of values to provide to workflow; later files override earlier ones")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.DryRun, "dry-run", false, "show the values and manifests of each step without changing the cluster")
+	runCmd.PersistentFlags().BoolVarP(&opts.Run.Interactive, "interactive", "i", false, "wait for the presenter before each step, and let them retry, skip or abort when a step fails")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.ShowDocs, "show-docs", false, "show the docs for each step in interactive mode")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.SkipSetup, "skip-setup", false, "skip the setup steps and only run the workflow steps")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.SetupOnly, "setup-only", false, "only run the setup steps of the workflow")
+	runCmd.PersistentFlags().StringVar(&opts.Run.From, "from", "", "id of the first step to run (or its position, i.e. steps[3])")
+	runCmd.PersistentFlags().StringVar(&opts.Run.Until, "until", "", "id of the last step to run (or its position, i.e. steps[3])")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.Checkpoint, "checkpoint", false, "save a checkpoint after setup and after each step that succeeds, so a failed run can be continued with --resume")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.Resume, "resume", false, "continue after the last step that succeeded in a previous run with --checkpoint, skipping setup")
+	runCmd.PersistentFlags().StringVar(&opts.Run.Report, "report", "", "path to write a report of the result of each step")
+	runCmd.PersistentFlags().BoolVar(&opts.Run.NativeApply, "native-apply", false, "apply, delete and patch resources with server-side apply in process, rather than with kubectl")
+	runCmd.PersistentFlags().StringVar(&opts.Run.Diagnostics, "diagnostics", "", "directory (or .tar.gz file) to collect pods, events, logs and helm releases into when a step fails")
+	runCmd.PersistentFlags().StringVar(&opts.Run.ReportFormat, "report-format", report.JsonFormat, "format of the report (json or junit)")
 	return runCmd
 }
 
@@ -27,11 +56,162 @@ func run(opts *options.Options) error {
 	if opts.Run.File == "" {
 		return errors.Errorf("Must provide file containing yaml workflow")
 	}
-	ctx := workflow.DefaultContext(opts.Top.Ctx)
+	if opts.Run.SkipSetup && opts.Run.SetupOnly {
+		return ConflictingSetupFlagsError
+	}
+	if opts.Run.Resume && opts.Run.From != "" {
+		return ConflictingResumeFlagsError
+	}
+	if opts.Run.Report != "" && opts.Run.ReportFormat != report.JsonFormat && opts.Run.ReportFormat != report.JunitFormat {
+		return report.UnknownReportFormatError(opts.Run.ReportFormat)
+	}
+	ctx := workflow.DefaultContextWithLogger(opts.Top.Ctx, opts.Top.Logger)
+	ctx.DryRun = opts.Run.DryRun
+	ctx.NativeApply = opts.Run.NativeApply
+	if opts.Run.Diagnostics != "" {
+		ctx.Diagnostics = diagnostics.NewBundle(opts.Run.Diagnostics)
+		// A tarball is written once, after every step (and every combination of a matrix) has run
+		defer func() {
+			if err := ctx.Diagnostics.Close(); err != nil {
+				ctx.GetLogger().Errorf("Error saving diagnostics: %s", err.Error())
+			}
+		}()
+	}
+	if opts.Run.Interactive {
+		ctx.Prompter = cmd.NewPrompter(ctx.GetLogger(), os.Stdin)
+		ctx.ShowDocs = opts.Run.ShowDocs
+	}
 	toRun := workflow.Workflow{}
 	if err := ctx.FileStore.LoadYaml(opts.Run.File, &toRun); err != nil {
 		return err
 	}
-	toRun.Values = toRun.Values.MergeValues(opts.Run.Values)
+	globalConfig, err := workflow.LoadGlobalConfigOrDefault(opts.Config.GlobalConfigPath, ctx.FileStore)
+	if err != nil {
+		return err
+	}
+	sources, err := toRun.GetValueSources(ctx.FileStore, globalConfig, opts.Run.ValuesFiles, render.FromStrings(opts.Run.Values))
+	if err != nil {
+		return err
+	}
+	toRun.Values = sources.Merge()
+	toRun.From = opts.Run.From
+	toRun.Until = opts.Run.Until
+	if opts.Run.Checkpoint || opts.Run.Resume {
+		checkpointFile, err := workflow.GetDefaultCheckpointPath(opts.Run.File)
+		if err != nil && opts.Run.Resume {
+			return err
+		} else if err != nil {
+			ctx.GetLogger().Warnf("Unable to save checkpoints: %s", err.Error())
+		}
+		toRun.CheckpointFile = checkpointFile
+	}
+	if len(toRun.Matrix) > 0 {
+		return runMatrix(opts, ctx, &toRun)
+	}
+	if opts.Run.Resume {
+		checkpoint, err := workflow.LoadCheckpoint(toRun.CheckpointFile, ctx.FileStore)
+		if err != nil {
+			return err
+		} else if checkpoint == nil {
+			return NoCheckpointError(opts.Run.File)
+		}
+		if err := toRun.ResumeFrom(ctx, checkpoint); err != nil {
+			return err
+		}
+	}
+	if opts.Run.Report != "" {
+		ctx.Report = report.New(opts.Run.File)
+	}
+	// Errors can include the values of secrets, i.e. in a rendered manifest
+	err = ctx.GetRedactor().RedactError(runWorkflow(opts, ctx, &toRun))
+	if ctx.Report != nil {
+		ctx.Report.Finish(err)
+		if saveErr := saveReport(ctx, opts.Run.Report, opts.Run.ReportFormat); saveErr != nil {
+			ctx.GetLogger().Errorf("Error saving report: %s", saveErr.Error())
+		}
+	}
+	return err
+}
+
+// Run the workflow once for each combination of its matrix, with a report for each. Every combination runs, even
+// if an earlier one failed.
+func runMatrix(opts *options.Options, ctx *api.WorkflowContext, toRun *workflow.Workflow) error {
+	var reports report.Reports
+	var errs workflow.MatrixErrors
+	logger := ctx.GetLogger()
+	// A useCluster step in one combination shouldn't change the cluster that the next one starts with
+	kubeconfig, kubeContext := ctx.Kubeconfig, ctx.KubeContext
+	for _, combination := range toRun.GetCombinations() {
+		name := workflow.FormatCombination(combination)
+		combinationRun, err := toRun.ForCombination(combination)
+		if err != nil {
+			return err
+		}
+		ctx.Logger = logger.With("combination", name)
+		ctx.SharedState = nil
+		ctx.UseContext(kubeconfig, kubeContext)
+		// Each combination is resumed from its own checkpoint. Combinations without one start from the first step.
+		if opts.Run.Resume {
+			checkpoint, err := workflow.LoadCheckpoint(combinationRun.CheckpointFile, ctx.FileStore)
+			if err != nil {
+				return err
+			}
+			if checkpoint != nil && combinationRun.Finished(checkpoint) {
+				ctx.GetLogger().Infof("Skipping combination, the last step %s already succeeded", checkpoint.LastStep)
+				continue
+			} else if checkpoint != nil {
+				if err := combinationRun.ResumeFrom(ctx, checkpoint); err != nil {
+					return err
+				}
+			}
+		}
+		if opts.Run.Report != "" {
+			ctx.Report = report.New(opts.Run.File)
+			ctx.Report.Values = combination
+			reports = append(reports, ctx.Report)
+		}
+		err = ctx.GetRedactor().RedactError(runWorkflow(opts, ctx, combinationRun))
+		ctx.Report.Finish(err)
+		if err != nil {
+			errs = append(errs, workflow.CombinationFailedError(name, err))
+		}
+	}
+	ctx.Logger = logger
+	if opts.Run.Report != "" {
+		if saveErr := saveReports(ctx, reports, opts.Run.Report, opts.Run.ReportFormat); saveErr != nil {
+			ctx.GetLogger().Errorf("Error saving report: %s", saveErr.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func runWorkflow(opts *options.Options, ctx *api.WorkflowContext, toRun *workflow.Workflow) error {
+	if !opts.Run.SkipSetup {
+		if err := toRun.SetupOrCleanup(ctx); err != nil {
+			return err
+		}
+	}
+	if opts.Run.SetupOnly {
+		return nil
+	}
 	return toRun.Run(ctx)
 }
+
+func saveReport(ctx *api.WorkflowContext, path, format string) error {
+	contents, err := ctx.Report.Marshal(format)
+	if err != nil {
+		return err
+	}
+	return ctx.FileStore.Save(path, ctx.GetRedactor().Redact(contents))
+}
+
+func saveReports(ctx *api.WorkflowContext, reports report.Reports, path, format string) error {
+	contents, err := reports.Marshal(format)
+	if err != nil {
+		return err
+	}
+	return ctx.FileStore.Save(path, ctx.GetRedactor().Redact(contents))
+}
@@ -0,0 +1,174 @@
+package run_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/cli/cmd/run"
+	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/log"
+)
+
+var _ = Describe("run", func() {
+
+	var (
+		dir          string
+		workflowFile string
+		outputFile   string
+		home         string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "valet-run-")
+		Expect(err).To(BeNil())
+		// The global config is read from (and checkpoints are written to) the home directory
+		home = os.Getenv("HOME")
+		Expect(os.Setenv("HOME", dir)).To(BeNil())
+		outputFile = filepath.Join(dir, "output.txt")
+		workflowFile = filepath.Join(dir, "workflow.yaml")
+		workflow := fmt.Sprintf(`
+setup:
+- bash:
+    inline: echo setup >> %[1]s
+steps:
+- bash:
+    inline: echo steps >> %[1]s
+cleanup:
+- bash:
+    inline: echo cleanup >> %[1]s
+`, outputFile)
+		Expect(ioutil.WriteFile(workflowFile, []byte(workflow), 0644)).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.Setenv("HOME", home)
+		os.RemoveAll(dir)
+	})
+
+	runWithArgs := func(args ...string) error {
+		opts := &options.Options{
+			Top: options.Top{
+				Ctx:    context.Background(),
+				Logger: log.New(log.Options{Out: GinkgoWriter, Err: GinkgoWriter}),
+			},
+		}
+		runCmd := run.Run(opts)
+		runCmd.SetArgs(append([]string{"-f", workflowFile}, args...))
+		runCmd.SetOutput(GinkgoWriter)
+		return runCmd.Execute()
+	}
+
+	readOutput := func() string {
+		contents, err := ioutil.ReadFile(outputFile)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		Expect(err).To(BeNil())
+		return string(contents)
+	}
+
+	It("runs setup, then the steps and cleanup", func() {
+		Expect(runWithArgs()).To(BeNil())
+		Expect(readOutput()).To(Equal("setup\nsteps\ncleanup\n"))
+	})
+
+	It("skips setup with --skip-setup", func() {
+		Expect(runWithArgs("--skip-setup")).To(BeNil())
+		Expect(readOutput()).To(Equal("steps\ncleanup\n"))
+	})
+
+	It("only runs setup with --setup-only", func() {
+		Expect(runWithArgs("--setup-only")).To(BeNil())
+		Expect(readOutput()).To(Equal("setup\n"))
+	})
+
+	It("errors when both --skip-setup and --setup-only are provided", func() {
+		err := runWithArgs("--skip-setup", "--setup-only")
+		Expect(err).To(Equal(run.ConflictingSetupFlagsError))
+		Expect(readOutput()).To(BeEmpty())
+	})
+
+	It("runs cleanup when setup fails", func() {
+		workflow := fmt.Sprintf(`
+setup:
+- bash:
+    inline: echo setup >> %[1]s && exit 1
+steps:
+- bash:
+    inline: echo steps >> %[1]s
+cleanup:
+- bash:
+    inline: echo cleanup >> %[1]s
+`, outputFile)
+		Expect(ioutil.WriteFile(workflowFile, []byte(workflow), 0644)).To(BeNil())
+		Expect(runWithArgs()).NotTo(BeNil())
+		Expect(readOutput()).To(Equal("setup\ncleanup\n"))
+	})
+
+	It("only saves a checkpoint with --checkpoint", func() {
+		checkpointDir := filepath.Join(dir, ".valet", "checkpoints")
+		Expect(runWithArgs()).To(BeNil())
+		Expect(checkpointDir).NotTo(BeADirectory())
+		Expect(runWithArgs("--checkpoint")).To(BeNil())
+		files, err := ioutil.ReadDir(checkpointDir)
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("resumes each combination of a matrix from its own checkpoint", func() {
+		workflow := fmt.Sprintf(`
+matrix:
+  Replicas: [1, 3]
+steps:
+- bash:
+    inline: echo steps >> %[1]s
+`, outputFile)
+		Expect(ioutil.WriteFile(workflowFile, []byte(workflow), 0644)).To(BeNil())
+		Expect(runWithArgs("--checkpoint")).To(BeNil())
+		Expect(readOutput()).To(Equal("steps\nsteps\n"))
+		files, err := ioutil.ReadDir(filepath.Join(dir, ".valet", "checkpoints"))
+		Expect(err).To(BeNil())
+		Expect(files).To(HaveLen(2))
+		// Both combinations already succeeded, so there is nothing to resume
+		Expect(runWithArgs("--resume")).To(BeNil())
+		Expect(readOutput()).To(Equal("steps\nsteps\n"))
+	})
+
+	It("skips setup when resuming, since it succeeded before the checkpoint", func() {
+		fixed := filepath.Join(dir, "fixed")
+		workflow := fmt.Sprintf(`
+setup:
+- bash:
+    inline: echo setup >> %[1]s
+steps:
+- bash:
+    inline: test -f %[2]s && echo first >> %[1]s
+- bash:
+    inline: echo second >> %[1]s
+`, outputFile, fixed)
+		Expect(ioutil.WriteFile(workflowFile, []byte(workflow), 0644)).To(BeNil())
+		// The first step fails, so the checkpoint is the one saved after setup
+		Expect(runWithArgs("--checkpoint")).NotTo(BeNil())
+		Expect(readOutput()).To(Equal("setup\n"))
+		Expect(ioutil.WriteFile(fixed, nil, 0644)).To(BeNil())
+		Expect(runWithArgs("--checkpoint", "--resume")).To(BeNil())
+		Expect(readOutput()).To(Equal("setup\nfirst\nsecond\n"))
+	})
+
+	It("errors when resuming without a checkpoint", func() {
+		Expect(runWithArgs("--resume")).To(MatchError(run.NoCheckpointError(workflowFile).Error()))
+	})
+
+	It("warns, rather than failing, when a checkpoint can't be saved", func() {
+		Expect(os.MkdirAll(filepath.Join(dir, ".valet"), os.ModePerm)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(dir, ".valet", "checkpoints"), []byte("not a directory"), 0644)).To(BeNil())
+		Expect(runWithArgs("--checkpoint")).To(BeNil())
+		Expect(readOutput()).To(Equal("setup\nsteps\ncleanup\n"))
+	})
+})
@@ -0,0 +1,57 @@
+package validate
+
+import (
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/go-utils/cliutils"
+	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+func Validate(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.Command {
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "check a valet workflow for problems without running it",
+		RunE: func(_ *cobra.Command, args []string) error {
+			return validate(opts)
+		},
+	}
+
+	cliutils.ApplyOptions(validateCmd, optionsFunc)
+	validateCmd.PersistentFlags().StringVarP(&opts.Validate.File, "file", "f", "", "path to file containing workflow to validate")
+	validateCmd.PersistentFlags().StringToStringVarP(&opts.Validate.Values, "values", "v", make(map[string]string), "values that will be provided to the workflow")
+	validateCmd.PersistentFlags().StringSliceVar(&opts.Validate.ValuesFiles, "values-file", nil, "path (or url) of a yaml file of values that will be provided to the workflow; later files override earlier ones")
+	return validateCmd
+}
+
+func validate(opts *options.Options) error {
+	if opts.Validate.File == "" {
+		return errors.Errorf("Must provide file containing yaml workflow")
+	}
+	ctx := workflow.DefaultContextWithLogger(opts.Top.Ctx, opts.Top.Logger)
+	toValidate := workflow.Workflow{}
+	if err := ctx.FileStore.LoadYaml(opts.Validate.File, &toValidate); err != nil {
+		return err
+	}
+	globalConfig, err := workflow.LoadGlobalConfigOrDefault(opts.Config.GlobalConfigPath, ctx.FileStore)
+	if err != nil {
+		return err
+	}
+	sources, err := toValidate.GetValueSources(ctx.FileStore, globalConfig, opts.Validate.ValuesFiles, render.FromStrings(opts.Validate.Values))
+	if err != nil {
+		return err
+	}
+	toValidate.Values = sources.Merge()
+	err = toValidate.Validate(ctx)
+	if validationErrs, ok := err.(workflow.ValidationErrors); ok {
+		for _, validationErr := range validationErrs {
+			ctx.GetLogger().Errorf("%s", validationErr.Error())
+		}
+		return ctx.GetRedactor().RedactError(err)
+	} else if err != nil {
+		return ctx.GetRedactor().RedactError(err)
+	}
+	ctx.GetLogger().Infof("Workflow is valid")
+	return nil
+}
@@ -0,0 +1,102 @@
+package values
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/go-utils/cliutils"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+func Values(opts *options.Options, optionsFunc ...cliutils.OptionsFunc) *cobra.Command {
+	valuesCmd := &cobra.Command{
+		Use:   "values",
+		Short: "print the values a valet workflow would run with, and the source of each value",
+		RunE: func(command *cobra.Command, args []string) error {
+			return values(opts, command.OutOrStdout())
+		},
+	}
+
+	cliutils.ApplyOptions(valuesCmd, optionsFunc)
+	valuesCmd.PersistentFlags().StringVarP(&opts.Values.File, "file", "f", "", "path to file containing workflow")
+	valuesCmd.PersistentFlags().StringToStringVarP(&opts.Values.Values, "values", "v", make(map[string]string), "values that will be provided to the workflow")
+	valuesCmd.PersistentFlags().StringSliceVar(&opts.Values.ValuesFiles, "values-file", nil, "path (or url) of a yaml file of values that will be provided to the workflow; later files override earlier ones")
+	valuesCmd.PersistentFlags().StringVar(&opts.Values.Step, "step", "", "id of a step (or its position, i.e. steps[3]) whose values to include")
+	valuesCmd.PersistentFlags().BoolVar(&opts.Values.Resolve, "resolve", false, "resolve value prefixes (i.e. env: or cmd:) instead of printing the values as written")
+	valuesCmd.PersistentFlags().BoolVar(&opts.Values.ShowSecrets, "show-secrets", false, "print resolved secrets (i.e. vault: or secret: values) instead of redacting them")
+	return valuesCmd
+}
+
+func values(opts *options.Options, out io.Writer) error {
+	if opts.Values.File == "" {
+		return errors.Errorf("Must provide file containing yaml workflow")
+	}
+	ctx := workflow.DefaultContextWithLogger(opts.Top.Ctx, opts.Top.Logger)
+	toPrint := workflow.Workflow{}
+	if err := ctx.FileStore.LoadYaml(opts.Values.File, &toPrint); err != nil {
+		return err
+	}
+	globalConfig, err := workflow.LoadGlobalConfigOrDefault(opts.Config.GlobalConfigPath, ctx.FileStore)
+	if err != nil {
+		return err
+	}
+	sources, err := toPrint.GetValueSources(ctx.FileStore, globalConfig, opts.Values.ValuesFiles, render.FromStrings(opts.Values.Values))
+	if err != nil {
+		return err
+	}
+	if opts.Values.Step != "" {
+		step, err := toPrint.GetStep(opts.Values.Step)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, workflow.ValueSource{Name: workflow.StepSource(opts.Values.Step), Values: step.Values})
+	}
+	return printValues(ctx, out, sources, opts.Values.Resolve, opts.Values.ShowSecrets)
+}
+
+// Print each value with the source it came from, sorted by name. Resolved secrets are redacted unless showSecrets
+// is true.
+func printValues(ctx *api.WorkflowContext, out io.Writer, sources workflow.ValueSources, resolve, showSecrets bool) error {
+	merged := sources.Merge()
+	origins := sources.Origins()
+	var keys []string
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tVALUE\tSOURCE\n")
+	for _, key := range keys {
+		value := merged[key]
+		if resolve {
+			resolved, err := merged.Get(key, ctx.Runner)
+			if err != nil {
+				return err
+			}
+			value = resolved
+		}
+		formatted, err := render.FormatValue(value)
+		if err != nil {
+			return err
+		}
+		if resolve && !showSecrets {
+			if render.IsSecretRef(merged[key], ctx.Runner) {
+				formatted = cmd.Redacted
+			} else {
+				// Other values, such as templates, may contain a secret too
+				formatted = ctx.GetRedactor().Redact(formatted)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, formatted, origins[key])
+	}
+	return w.Flush()
+}
@@ -0,0 +1,20 @@
+package values_test
+
+import (
+	"testing"
+
+	"github.com/solo-io/go-utils/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestValues(t *testing.T) {
+	RegisterFailHandler(Fail)
+	testutils.RegisterPreFailHandler(
+		func() {
+			testutils.PrintTrimmedStack()
+		})
+	testutils.RegisterCommonFailHandlers()
+	RunSpecs(t, "Values Suite")
+}
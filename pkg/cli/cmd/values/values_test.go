@@ -0,0 +1,93 @@
+package values_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/cli/cmd/values"
+	"github.com/solo-io/valet/pkg/cli/options"
+	"github.com/solo-io/valet/pkg/log"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ = Describe("values", func() {
+
+	var (
+		dir          string
+		workflowFile string
+		server       *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "valet-values-")
+		Expect(err).To(BeNil())
+		workflowFile = filepath.Join(dir, "workflow.yaml")
+		workflow := `
+values:
+  License: vault:secret/data/gloo#license
+  Namespace: gloo-system
+steps:
+- bash:
+    inline: echo steps
+`
+		Expect(ioutil.WriteFile(workflowFile, []byte(workflow), 0644)).To(BeNil())
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data":{"data":{"license":"my-license-key"},"metadata":{}}}`)
+		}))
+		Expect(os.Setenv(render.VaultAddressEnv, server.URL)).To(BeNil())
+		Expect(os.Setenv(render.VaultTokenEnv, "root")).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.Unsetenv(render.VaultAddressEnv)
+		os.Unsetenv(render.VaultTokenEnv)
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	valuesWithArgs := func(args ...string) string {
+		opts := &options.Options{
+			Top: options.Top{
+				Ctx:    context.Background(),
+				Logger: log.New(log.Options{Out: GinkgoWriter, Err: GinkgoWriter}),
+			},
+			Config: options.Config{
+				GlobalConfigPath: filepath.Join(dir, "global.yaml"),
+			},
+		}
+		out := &bytes.Buffer{}
+		valuesCmd := values.Values(opts)
+		valuesCmd.SetArgs(append([]string{"-f", workflowFile}, args...))
+		valuesCmd.SetOut(out)
+		valuesCmd.SetErr(GinkgoWriter)
+		Expect(valuesCmd.Execute()).To(BeNil())
+		return out.String()
+	}
+
+	It("prints the values as written", func() {
+		out := valuesWithArgs()
+		Expect(out).To(ContainSubstring("vault:secret/data/gloo#license"))
+		Expect(out).To(ContainSubstring("gloo-system"))
+	})
+
+	It("redacts resolved secrets", func() {
+		out := valuesWithArgs("--resolve")
+		Expect(out).NotTo(ContainSubstring("my-license-key"))
+		Expect(out).To(MatchRegexp(`License\s+REDACTED`))
+		Expect(out).To(ContainSubstring("gloo-system"))
+	})
+
+	It("prints resolved secrets with --show-secrets", func() {
+		out := valuesWithArgs("--resolve", "--show-secrets")
+		Expect(out).To(MatchRegexp(`License\s+my-license-key`))
+	})
+})
@@ -2,24 +2,79 @@ package options
 
 import (
 	"context"
+
+	"github.com/solo-io/valet/pkg/log"
 )
 
 type Options struct {
-	Top    Top
-	Run    Run
-	Config Config
+	Top      Top
+	Run      Run
+	Validate Validate
+	Values   Values
+	Config   Config
 
 	GenDocs GenDocs
 }
 
 type Top struct {
 	Ctx context.Context
+	// Minimum level of log messages to print (debug, info, warn or error)
+	LogLevel string
+	// Print log messages as json
+	LogJson bool
+	// Created from the log options before a command runs
+	Logger log.Logger
 }
 
 type Run struct {
 	File   string
 	Values map[string]string
-	Debug  bool
+	// Paths (or urls) of values files, in increasing order of precedence
+	ValuesFiles []string
+	// Show what each step would do, without changing the cluster
+	DryRun bool
+	// Wait for the presenter before each step, and let them retry, skip or abort when a step fails
+	Interactive bool
+	// Show the docs for each step in interactive mode
+	ShowDocs bool
+	// Skip the setup steps and only run the main steps of the workflow
+	SkipSetup bool
+	// Only run the setup steps of the workflow
+	SetupOnly bool
+	// Optional, the id of the first step to run
+	From string
+	// Optional, the id of the last step to run
+	Until string
+	// Save a checkpoint after each step that succeeds, so a failed run can be continued with Resume
+	Checkpoint bool
+	// Continue after the last step that succeeded in the previous run (implies Checkpoint)
+	Resume bool
+	// Optional, path to write a report of the run to
+	Report string
+	// Format of the report (json or junit)
+	ReportFormat string
+	// Apply, delete and patch with the kube client instead of kubectl
+	NativeApply bool
+	// Optional, directory (or .tar.gz file) to collect diagnostics into when a step fails
+	Diagnostics string
+}
+
+type Validate struct {
+	File        string
+	Values      map[string]string
+	ValuesFiles []string
+}
+
+type Values struct {
+	File        string
+	Values      map[string]string
+	ValuesFiles []string
+	// Optional, the id of a step whose values are included
+	Step string
+	// Resolve value prefixes (i.e. env:) instead of printing the values as written
+	Resolve bool
+	// Print resolved secrets (i.e. vault: values) instead of redacting them
+	ShowSecrets bool
 }
 
 type GenDocs struct {
@@ -2,7 +2,7 @@ package artifact
 
 import (
 	"context"
-	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
 	"io"
 	"net/http"
 	"os"
@@ -21,18 +21,28 @@ var (
 	CouldNotFindAssetError = errors.Errorf("Could not find asset")
 )
 
+// Create a downloader that logs with log.Default(), see WithLogger
 func NewGithubDownloader(client *github.Client, repoName, tag string) *githubArtifactDownloader {
 	return &githubArtifactDownloader{
 		client:   client,
 		repoName: repoName,
 		tag:      tag,
+		logger:   log.Default(),
 	}
 }
 
+// Returns a copy of the downloader that logs with the provided logger
+func (d *githubArtifactDownloader) WithLogger(logger log.Logger) *githubArtifactDownloader {
+	scoped := *d
+	scoped.logger = logger
+	return &scoped
+}
+
 type githubArtifactDownloader struct {
 	client   *github.Client
 	repoName string
 	tag      string
+	logger   log.Logger
 }
 
 func (d *githubArtifactDownloader) Download(ctx context.Context, remotePath, localPath string) error {
@@ -50,7 +60,7 @@ func (d *githubArtifactDownloader) Download(ctx context.Context, remotePath, loc
 func (d *githubArtifactDownloader) getRelease(ctx context.Context, client *github.Client) (*github.RepositoryRelease, error) {
 	release, _, err := client.Repositories.GetReleaseByTag(ctx, "solo-io", d.repoName, d.tag)
 	if err != nil {
-		cmd.Stderr().Println("Could not get release %s:%s", d.repoName, d.tag)
+		d.logger.Errorf("Could not get release %s:%s", d.repoName, d.tag)
 		return nil, err
 	}
 	return release, nil
@@ -62,23 +72,23 @@ func (d *githubArtifactDownloader) getAsset(ctx context.Context, release *github
 			return &asset, nil
 		}
 	}
-	cmd.Stderr().Println("Could not find asset %s:%s %s", d.repoName, d.tag, remotePath)
+	d.logger.Errorf("Could not find asset %s:%s %s", d.repoName, d.tag, remotePath)
 	return nil, CouldNotFindAssetError
 }
 
-func chmod(ctx context.Context, filepath string) error {
+func chmod(logger log.Logger, filepath string) error {
 	err := os.Chmod(filepath, os.ModePerm)
 	if err != nil {
-		cmd.Stderr().Println("Error changing file permissions: %s", err.Error())
+		logger.Errorf("Error changing file permissions: %s", err.Error())
 	}
 	return err
 }
 
 func (d *githubArtifactDownloader) downloadAsset(ctx context.Context, asset *github.ReleaseAsset, filepath string) error {
-	cmd.Stdout().Println("Downloading asset %s to %s", asset.GetName(), filepath)
+	d.logger.Infof("Downloading asset %s to %s", asset.GetName(), filepath)
 	rc, redirectUrl, err := d.client.Repositories.DownloadReleaseAsset(ctx, "solo-io", d.repoName, asset.GetID())
 	if err != nil {
-		cmd.Stderr().Println("Could not download asset: %s", err.Error())
+		d.logger.Errorf("Could not download asset: %s", err.Error())
 		return err
 	}
 	if rc != nil {
@@ -87,10 +97,10 @@ func (d *githubArtifactDownloader) downloadAsset(ctx context.Context, asset *git
 		err = downloadFile(filepath, redirectUrl)
 	}
 	if err != nil {
-		cmd.Stderr().Println("Could not download asset: %s", err.Error())
+		d.logger.Errorf("Could not download asset: %s", err.Error())
 		return err
 	}
-	return chmod(ctx, filepath)
+	return chmod(d.logger, filepath)
 }
 
 func copyReader(filepath string, rc io.ReadCloser) error {
@@ -128,20 +138,31 @@ func downloadFile(filepath, url string) error {
 	return err
 }
 
+// Create a downloader that logs with log.Default(), see WithLogger
 func NewUrlDownloader() *urlArtifactDownloader {
-	return &urlArtifactDownloader{}
+	return &urlArtifactDownloader{
+		logger: log.Default(),
+	}
+}
+
+// Returns a copy of the downloader that logs with the provided logger
+func (d *urlArtifactDownloader) WithLogger(logger log.Logger) *urlArtifactDownloader {
+	return &urlArtifactDownloader{
+		logger: logger,
+	}
 }
 
 type urlArtifactDownloader struct {
+	logger log.Logger
 }
 
 var _ Downloader = new(urlArtifactDownloader)
 
 func (d *urlArtifactDownloader) Download(ctx context.Context, remotePath, localPath string) error {
-	cmd.Stdout().Println("Downloading file %s to %s", remotePath, localPath)
+	d.logger.Infof("Downloading file %s to %s", remotePath, localPath)
 	err := downloadFile(localPath, remotePath)
 	if err != nil {
 		return err
 	}
-	return chmod(ctx, localPath)
+	return chmod(d.logger, localPath)
 }
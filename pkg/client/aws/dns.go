@@ -2,7 +2,7 @@ package aws
 
 import (
 	"context"
-	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -22,6 +22,7 @@ type DnsClient interface {
 	CreateMapping(ctx context.Context, hostedZoneName, domain, ip string) error
 }
 
+// Create a dns client that logs with log.Default(), see WithLogger
 func NewAwsDnsClient() (*awsDnsClient, error) {
 	config := aws.NewConfig()
 	awsSession, err := session.NewSession(config)
@@ -30,16 +31,25 @@ func NewAwsDnsClient() (*awsDnsClient, error) {
 	}
 	svc := route53.New(awsSession)
 	return &awsDnsClient{
-		svc: svc,
+		svc:    svc,
+		logger: log.Default(),
 	}, nil
 }
 
+// Returns a copy of the client that logs with the provided logger
+func (c *awsDnsClient) WithLogger(logger log.Logger) *awsDnsClient {
+	scoped := *c
+	scoped.logger = logger
+	return &scoped
+}
+
 type awsDnsClient struct {
-	svc *route53.Route53
+	svc    *route53.Route53
+	logger log.Logger
 }
 
 func (c *awsDnsClient) getHostedZone(name string) (*route53.HostedZone, error) {
-	cmd.Stdout().Println("Getting hosted zone id")
+	c.logger.Infof("Getting hosted zone id")
 	listHostedZonesInput := route53.ListHostedZonesInput{}
 	output, err := c.svc.ListHostedZones(&listHostedZonesInput)
 	if err != nil {
@@ -86,7 +96,7 @@ func (c *awsDnsClient) CreateMapping(ctx context.Context, hostedZoneName, domain
 		HostedZoneId: hostedZone.Id,
 		ChangeBatch:  changeBatch,
 	}
-	cmd.Stdout().Println("Creating DNS mapping for %s to %s", domain, ip)
+	c.logger.Infof("Creating DNS mapping for %s to %s", domain, ip)
 	_, err = c.svc.ChangeResourceRecordSets(&input)
 	return err
 }
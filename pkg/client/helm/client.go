@@ -1,9 +1,14 @@
 package helm
 
 import (
+	"sync"
+
 	errors "github.com/rotisserie/eris"
 	"github.com/solo-io/go-utils/installutils/helminstall"
+	"github.com/solo-io/go-utils/kubeutils"
+	"github.com/solo-io/valet/pkg/log"
 	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/kubernetes"
 )
 
 //go:generate mockgen -destination ./mocks/helm_client_mock.go github.com/solo-io/valet/pkg/client/helm Client
@@ -11,23 +16,82 @@ import (
 type Client interface {
 	Install(config *helminstall.InstallerConfig) error
 	GetRelease(releaseName, releaseNamespace string) (*release.Release, error)
+	// Target the provided kubeconfig and kube context in subsequent calls. Empty values fall back
+	// to the default kubeconfig and the current context.
+	UseContext(kubeconfig, kubeContext string)
+}
+
+// Clients can implement LoggingClient, so their output can be scoped, i.e. to the step that is running.
+type LoggingClient interface {
+	WithLogger(logger log.Logger) Client
 }
 
+// Create a default helm client, which writes the output of installs to log.Default() (see WithLogger)
 func NewClient() *helmClient {
-	return &helmClient{}
+	return &helmClient{
+		target: &target{},
+		logger: log.Default(),
+	}
 }
 
+var _ LoggingClient = new(helmClient)
+
 type helmClient struct {
+	// Shared with the copies of the client from WithLogger, so they all target the same cluster
+	*target
+	logger log.Logger
+}
+
+// The cluster that a client targets
+type target struct {
+	lock        sync.RWMutex
+	kubeconfig  string
+	kubeContext string
+}
+
+// Returns a copy of the client that writes to the provided logger, and targets the same cluster.
+func (h *helmClient) WithLogger(logger log.Logger) Client {
+	return &helmClient{
+		target: h.target,
+		logger: logger,
+	}
 }
 
+func (h *helmClient) UseContext(kubeconfig, kubeContext string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.kubeconfig = kubeconfig
+	h.kubeContext = kubeContext
+}
+
+func (h *helmClient) getContext() (kubeconfig, kubeContext string) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.kubeconfig, h.kubeContext
+}
+
+// Install the chart into the cluster the client targets, unless the config sets its own kubeconfig or kube context.
 func (h *helmClient) Install(config *helminstall.InstallerConfig) error {
-	inst := helminstall.MustInstaller()
+	if config.KubeConfig == "" && config.KubeContext == "" {
+		config.KubeConfig, config.KubeContext = h.getContext()
+	}
+	// The namespace is created with this client, so it must target the same cluster as the release
+	cfg, err := kubeutils.GetConfigWithContext("", config.KubeConfig, config.KubeContext)
+	if err != nil {
+		return err
+	}
+	kube, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	inst := helminstall.NewInstaller(helminstall.DefaultHelmClient(), kube.CoreV1().Namespaces(), log.NewWriter(h.logger))
 	return inst.Install(config)
 }
 
-func (h *helmClient) GetRelease(releaseName, releaseNamespace string)  (*release.Release, error) {
+func (h *helmClient) GetRelease(releaseName, releaseNamespace string) (*release.Release, error) {
 	client := helminstall.DefaultHelmClient()
-	releaseLister, err := client.ReleaseList("", "", releaseNamespace)
+	kubeconfig, kubeContext := h.getContext()
+	releaseLister, err := client.ReleaseList(kubeconfig, kubeContext, releaseNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -41,4 +105,4 @@ func (h *helmClient) GetRelease(releaseName, releaseNamespace string)  (*release
 		}
 	}
 	return nil, errors.Errorf("Release %s not found in namespace %s", releaseName, releaseNamespace)
-}
\ No newline at end of file
+}
@@ -62,3 +62,15 @@ func (mr *MockClientMockRecorder) Install(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Install", reflect.TypeOf((*MockClient)(nil).Install), arg0)
 }
+
+// UseContext mocks base method
+func (m *MockClient) UseContext(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UseContext", arg0, arg1)
+}
+
+// UseContext indicates an expected call of UseContext
+func (mr *MockClientMockRecorder) UseContext(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseContext", reflect.TypeOf((*MockClient)(nil).UseContext), arg0, arg1)
+}
@@ -0,0 +1,402 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	errors "github.com/rotisserie/eris"
+	kubeerrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	// The field manager that owns the fields set by server-side apply
+	FieldManager = "valet"
+
+	ObjectCreated    = "created"
+	ObjectConfigured = "configured"
+	ObjectUnchanged  = "unchanged"
+	ObjectDeleted    = "deleted"
+
+	JsonPatchType      = "json"
+	MergePatchType     = "merge"
+	StrategicPatchType = "strategic"
+)
+
+var (
+	InvalidManifestError = func(err error) error {
+		return errors.Wrapf(err, "Invalid manifest")
+	}
+	UnknownKindError = func(kind string, err error) error {
+		return errors.Wrapf(err, "Unknown kind %s", kind)
+	}
+	ApplyObjectError = func(object string, err error) error {
+		return errors.Wrapf(err, "Error applying %s", object)
+	}
+	ApplyConflictError = func(object string, err error) error {
+		return errors.Wrapf(err, "Error applying %s, other managers own some of its fields (set force to take ownership of them)", object)
+	}
+	DeleteObjectError = func(object string, err error) error {
+		return errors.Wrapf(err, "Error deleting %s", object)
+	}
+	PatchObjectError = func(object string, err error) error {
+		return errors.Wrapf(err, "Error patching %s", object)
+	}
+	UnknownPatchTypeError = func(patchType string) error {
+		return errors.Errorf("Unknown patch type %s, must be one of [%s, %s, %s]", patchType, JsonPatchType, MergePatchType, StrategicPatchType)
+	}
+
+	patchTypes = map[string]types.PatchType{
+		JsonPatchType:      types.JSONPatchType,
+		MergePatchType:     types.MergePatchType,
+		StrategicPatchType: types.StrategicMergePatchType,
+		// kubectl uses a strategic merge patch by default
+		"": types.StrategicMergePatchType,
+	}
+)
+
+// The result of applying, deleting or patching one object, i.e. "Deployment default/petclinic configured".
+type ObjectResult struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// One of created, configured, unchanged or deleted
+	Result string
+}
+
+func (o ObjectResult) String() string {
+	return fmt.Sprintf("%s %s", o.object(), o.Result)
+}
+
+func (o ObjectResult) object() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s", o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+type ObjectResults []ObjectResult
+
+// The dynamic clients that the kube client uses to work with objects of any kind in process.
+type resourceClients struct {
+	dynamic dynamic.Interface
+	mapper  meta.RESTMapper
+	// The namespace of namespaced objects that don't set one
+	defaultNamespace string
+	// Optional, forgets the kinds that the mapper discovered, so kinds added since (i.e. by a crd) can be found
+	resetMapper func()
+}
+
+func newResourceClients(dynamicClient dynamic.Interface, mapper meta.RESTMapper, defaultNamespace string) resourceClients {
+	if defaultNamespace == "" {
+		defaultNamespace = "default"
+	}
+	return resourceClients{
+		dynamic:          dynamicClient,
+		mapper:           mapper,
+		defaultNamespace: defaultNamespace,
+	}
+}
+
+// Applies, deletes and patches objects in process, with a dynamic client and a RESTMapper, rather than with kubectl.
+// Objects are applied with server-side apply, so the cluster must support it (kube 1.16+).
+type Applier struct {
+	resourceClients
+}
+
+func NewApplier(dynamicClient dynamic.Interface, mapper meta.RESTMapper, defaultNamespace string) *Applier {
+	return &Applier{
+		resourceClients: newResourceClients(dynamicClient, mapper, defaultNamespace),
+	}
+}
+
+// Returns the dynamic client and RESTMapper for the kubeconfig and kube context that the client targets, with the
+// default namespace of the context. They are created once and shared by the copies of the client, until UseContext
+// targets another cluster. The mapper caches what it discovers, so each kind is only looked up once.
+func (k *kubeClient) resourceClients() (resourceClients, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.resources != nil {
+		return *k.resources, nil
+	}
+	clientConfig := k.newClientConfig()
+	restCfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return resourceClients{}, errors.Wrapf(err, "getting kube rest config")
+	}
+	dynamicClient, err := dynamic.NewForConfig(restCfg)
+	if err != nil {
+		return resourceClients{}, errors.Wrapf(err, "starting dynamic client")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restCfg)
+	if err != nil {
+		return resourceClients{}, errors.Wrapf(err, "starting discovery client")
+	}
+	cachedDiscovery := memory.NewMemCacheClient(discoveryClient)
+	discoveryMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery)
+	mapper := restmapper.NewShortcutExpander(discoveryMapper, cachedDiscovery)
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return resourceClients{}, err
+	}
+	resources := newResourceClients(dynamicClient, mapper, namespace)
+	resources.resetMapper = discoveryMapper.Reset
+	k.resources = &resources
+	return resources, nil
+}
+
+func (k *kubeClient) applier() (*Applier, error) {
+	resources, err := k.resourceClients()
+	if err != nil {
+		return nil, err
+	}
+	return &Applier{resourceClients: resources}, nil
+}
+
+func (k *kubeClient) ApplyManifests(manifests string, force bool) (ObjectResults, error) {
+	applier, err := k.applier()
+	if err != nil {
+		return nil, err
+	}
+	return applier.Apply(manifests, force)
+}
+
+func (k *kubeClient) DeleteManifests(manifests string) (ObjectResults, error) {
+	applier, err := k.applier()
+	if err != nil {
+		return nil, err
+	}
+	return applier.Delete(manifests)
+}
+
+func (k *kubeClient) PatchObject(kubeType, namespace, name, patchType, patch string) (*ObjectResult, error) {
+	applier, err := k.applier()
+	if err != nil {
+		return nil, err
+	}
+	return applier.Patch(kubeType, namespace, name, patchType, patch)
+}
+
+// Apply each object in the manifests with server-side apply, in order. Stops at the first object that fails, and
+// returns the results of the objects applied before it. Unless force is true, applying a field that another manager
+// (i.e. a controller or helm) owns fails with a conflict, rather than taking ownership of the field.
+func (a *Applier) Apply(manifests string, force bool) (ObjectResults, error) {
+	objects, err := ParseManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	var results ObjectResults
+	for _, object := range objects {
+		result, err := a.applyObject(object, force)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+func (a *Applier) applyObject(object *unstructured.Unstructured, force bool) (*ObjectResult, error) {
+	client, result, err := a.resourceFor(object)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.Get(object.GetName(), v12.GetOptions{})
+	if kubeerrs.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return nil, ApplyObjectError(result.object(), err)
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := client.Patch(object.GetName(), types.ApplyPatchType, data, v12.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if kubeerrs.IsConflict(err) {
+		return nil, ApplyConflictError(result.object(), err)
+	} else if err != nil {
+		return nil, ApplyObjectError(result.object(), err)
+	}
+	switch {
+	case existing == nil:
+		result.Result = ObjectCreated
+	case changed(existing, applied):
+		result.Result = ObjectConfigured
+	default:
+		result.Result = ObjectUnchanged
+	}
+	return result, nil
+}
+
+// Returns true if an apply or patch changed the object. Every change updates the resource version, but the
+// contents are compared too, in case the server doesn't track resource versions.
+func changed(before, after *unstructured.Unstructured) bool {
+	if before.GetResourceVersion() != after.GetResourceVersion() {
+		return true
+	}
+	return !reflect.DeepEqual(before.Object, after.Object)
+}
+
+// Delete each object in the manifests, in reverse order so objects are deleted before the namespaces and custom
+// resource definitions they depend on. Like kubectl, an object that doesn't exist is an error.
+func (a *Applier) Delete(manifests string) (ObjectResults, error) {
+	objects, err := ParseManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	var results ObjectResults
+	propagation := v12.DeletePropagationBackground
+	for i := len(objects) - 1; i >= 0; i-- {
+		client, result, err := a.resourceFor(objects[i])
+		if err != nil {
+			return results, err
+		}
+		if err := client.Delete(objects[i].GetName(), &v12.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+			return results, DeleteObjectError(result.object(), err)
+		}
+		result.Result = ObjectDeleted
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// Patch an object, where kubeType is a resource name as it would be passed to kubectl (i.e. "deployment" or
+// "deploy"). The patch can be json or yaml.
+func (a *Applier) Patch(kubeType, namespace, name, patchType, patch string) (*ObjectResult, error) {
+	pt, ok := patchTypes[patchType]
+	if !ok {
+		return nil, UnknownPatchTypeError(patchType)
+	}
+	gvk, err := a.kindFor(kubeType)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{}
+	object.SetGroupVersionKind(gvk)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	client, result, err := a.resourceFor(object)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.YAMLToJSON([]byte(patch))
+	if err != nil {
+		return nil, PatchObjectError(result.object(), err)
+	}
+	existing, err := client.Get(name, v12.GetOptions{})
+	if err != nil {
+		return nil, PatchObjectError(result.object(), err)
+	}
+	patched, err := client.Patch(name, pt, data, v12.PatchOptions{FieldManager: FieldManager})
+	if err != nil {
+		return nil, PatchObjectError(result.object(), err)
+	}
+	result.Result = ObjectUnchanged
+	if changed(existing, patched) {
+		result.Result = ObjectConfigured
+	}
+	return result, nil
+}
+
+// Forgets what the mapper discovered if err is because a kind wasn't found, and returns true if the lookup should be
+// retried. The mapper is shared across steps, so a kind may have been added (i.e. by a crd) since it was discovered.
+func (r *resourceClients) resetIfNoMatch(err error) bool {
+	if r.resetMapper == nil || !meta.IsNoMatchError(err) {
+		return false
+	}
+	r.resetMapper()
+	return true
+}
+
+// Returns the kind for a resource name as it would be passed to kubectl (i.e. "deployment" or "deploy").
+func (r *resourceClients) kindFor(kubeType string) (schema.GroupVersionKind, error) {
+	resource := schema.GroupVersionResource{Resource: strings.ToLower(kubeType)}
+	gvr, err := r.mapper.ResourceFor(resource)
+	if r.resetIfNoMatch(err) {
+		gvr, err = r.mapper.ResourceFor(resource)
+	}
+	if err != nil {
+		return schema.GroupVersionKind{}, UnknownKindError(kubeType, err)
+	}
+	gvk, err := r.mapper.KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionKind{}, UnknownKindError(kubeType, err)
+	}
+	return gvk, nil
+}
+
+// Returns the client for the resource of the object, and a result that identifies the object. The object is
+// defaulted to the default namespace if its kind is namespaced and it doesn't set one.
+func (r *resourceClients) resourceFor(object *unstructured.Unstructured) (dynamic.ResourceInterface, *ObjectResult, error) {
+	gvk := object.GroupVersionKind()
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if r.resetIfNoMatch(err) {
+		mapping, err = r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, nil, UnknownKindError(gvk.Kind, err)
+	}
+	result := &ObjectResult{
+		Kind: gvk.Kind,
+		Name: object.GetName(),
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return r.dynamic.Resource(mapping.Resource), result, nil
+	}
+	if object.GetNamespace() == "" {
+		object.SetNamespace(r.defaultNamespace)
+	}
+	result.Namespace = object.GetNamespace()
+	return r.dynamic.Resource(mapping.Resource).Namespace(object.GetNamespace()), result, nil
+}
+
+// Parse a stream of yaml (or json) documents into objects. Empty documents are skipped, and the items of lists are
+// returned as separate objects.
+func ParseManifests(manifests string) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader([]byte(manifests)), 4096)
+	var objects []*unstructured.Unstructured
+	for {
+		var raw map[string]interface{}
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, InvalidManifestError(err)
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		object := &unstructured.Unstructured{Object: raw}
+		if strings.HasSuffix(object.GetKind(), "List") && object.IsList() {
+			list, err := object.ToList()
+			if err != nil {
+				return nil, InvalidManifestError(err)
+			}
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			continue
+		}
+		if object.GetKind() == "" || object.GetName() == "" {
+			return nil, InvalidManifestError(errors.Errorf("objects must have a kind and a name"))
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
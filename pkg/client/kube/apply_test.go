@@ -0,0 +1,159 @@
+package kube_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/client/kube"
+	kubeerrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("applier", func() {
+
+	const (
+		manifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: petclinic
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: petclinic
+  namespace: petclinic
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  debug: "true"
+`
+	)
+
+	var (
+		deployments = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+		configMaps  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+		client  *fake.FakeDynamicClient
+		applier *kube.Applier
+	)
+
+	BeforeEach(func() {
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+		// The fake client doesn't support server-side apply, so applies replace the object
+		scheme := runtime.NewScheme()
+		client = fake.NewSimpleDynamicClient(scheme)
+		tracker := k8stesting.NewObjectTracker(scheme, unstructured.UnstructuredJSONScheme)
+		client.ReactionChain = nil
+		client.AddReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patch := action.(k8stesting.PatchAction)
+			if patch.GetPatchType() != types.ApplyPatchType {
+				return false, nil, nil
+			}
+			applied := &unstructured.Unstructured{}
+			if err := json.Unmarshal(patch.GetPatch(), &applied.Object); err != nil {
+				return true, nil, err
+			}
+			_, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+			if kubeerrs.IsNotFound(err) {
+				return true, applied, tracker.Create(patch.GetResource(), applied, patch.GetNamespace())
+			}
+			return true, applied, tracker.Update(patch.GetResource(), applied, patch.GetNamespace())
+		})
+		client.AddReactor("*", "*", k8stesting.ObjectReaction(tracker))
+		applier = kube.NewApplier(client, mapper, "gloo-system")
+	})
+
+	It("applies each object and reports whether it was created, configured or unchanged", func() {
+		results, err := applier.Apply(manifests, false)
+		Expect(err).To(BeNil())
+		Expect(results).To(Equal(kube.ObjectResults{
+			{Kind: "Namespace", Name: "petclinic", Result: kube.ObjectCreated},
+			{Kind: "Deployment", Namespace: "petclinic", Name: "petclinic", Result: kube.ObjectCreated},
+			{Kind: "ConfigMap", Namespace: "gloo-system", Name: "settings", Result: kube.ObjectCreated},
+		}))
+		Expect(results[1].String()).To(Equal("Deployment petclinic/petclinic created"))
+
+		results, err = applier.Apply(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}, "data": {"debug": "false"}}`, false)
+		Expect(err).To(BeNil())
+		Expect(results[0].Result).To(Equal(kube.ObjectConfigured))
+		configMap, err := client.Resource(configMaps).Namespace("gloo-system").Get("settings", v1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(configMap.Object["data"]).To(Equal(map[string]interface{}{"debug": "false"}))
+
+		results, err = applier.Apply(manifests, false)
+		Expect(err).To(BeNil())
+		Expect(results[0].Result).To(Equal(kube.ObjectUnchanged))
+		Expect(results[1].Result).To(Equal(kube.ObjectUnchanged))
+		Expect(results[2].Result).To(Equal(kube.ObjectConfigured))
+	})
+
+	It("surfaces conflicts with other field managers unless forced", func() {
+		client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, kubeerrs.NewConflict(configMaps.GroupResource(), "settings", errors.Errorf("conflict with \"helm\""))
+		})
+		_, err := applier.Apply(manifests, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("set force to take ownership"))
+	})
+
+	It("patches an object by its resource name", func() {
+		_, err := applier.Apply(manifests, false)
+		Expect(err).To(BeNil())
+		result, err := applier.Patch("deployment", "petclinic", "petclinic", kube.MergePatchType, "spec:\n  replicas: 2\n")
+		Expect(err).To(BeNil())
+		Expect(result.String()).To(Equal("Deployment petclinic/petclinic configured"))
+		deployment, err := client.Resource(deployments).Namespace("petclinic").Get("petclinic", v1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(deployment.Object["spec"]).To(HaveKeyWithValue("replicas", BeNumerically("==", 2)))
+
+		result, err = applier.Patch("deployments", "petclinic", "petclinic", kube.MergePatchType, `{"spec": {"replicas": 2}}`)
+		Expect(err).To(BeNil())
+		Expect(result.Result).To(Equal(kube.ObjectUnchanged))
+
+		_, err = applier.Patch("deployment", "petclinic", "petclinic", "replace", "{}")
+		Expect(err).To(MatchError(kube.UnknownPatchTypeError("replace").Error()))
+	})
+
+	It("deletes objects in reverse order", func() {
+		_, err := applier.Apply(manifests, false)
+		Expect(err).To(BeNil())
+		results, err := applier.Delete(manifests)
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].Kind).To(Equal("ConfigMap"))
+		Expect(results[2].String()).To(Equal("Namespace petclinic deleted"))
+		_, err = client.Resource(deployments).Namespace("petclinic").Get("petclinic", v1.GetOptions{})
+		Expect(kubeerrs.IsNotFound(err)).To(BeTrue())
+
+		_, err = applier.Delete(manifests)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns typed errors for unknown kinds and invalid manifests", func() {
+		_, err := applier.Apply("apiVersion: gloo.solo.io/v1\nkind: Upstream\nmetadata:\n  name: petclinic\n", false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown kind Upstream"))
+		_, err = applier.Apply("apiVersion: v1\nkind: ConfigMap\n", false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid manifest"))
+	})
+})
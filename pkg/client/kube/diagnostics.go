@@ -0,0 +1,80 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The suffix of the key of the logs of the previous instance of a container that restarted
+	PreviousLogsSuffix = ".previous"
+)
+
+// The state of a pod, with the last lines of the logs of each of its containers.
+type PodDiagnostics struct {
+	Pod v1.Pod
+	// Logs by container name. Containers that restarted also have the logs of their previous instance, under the
+	// container name with PreviousLogsSuffix. If the logs can't be read, the error is recorded instead.
+	Logs map[string]string
+}
+
+// The pods and events of a namespace, used to debug a workflow that failed.
+type NamespaceDiagnostics struct {
+	Namespace string
+	Pods      []PodDiagnostics
+	// Events ordered by the time they were last seen
+	Events []v1.Event
+}
+
+func (k *kubeClient) GetNamespaceDiagnostics(namespace string, logLines int64) (*NamespaceDiagnostics, error) {
+	kubeClient, err := k.kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	return CollectNamespaceDiagnostics(kubeClient, containerLogs(kubeClient), namespace, logLines)
+}
+
+// Collect the pods and events of a namespace, and the last lines of the logs of each container of the pods.
+func CollectNamespaceDiagnostics(kube kubernetes.Interface, logs ContainerLogsFunc, namespace string, logLines int64) (*NamespaceDiagnostics, error) {
+	pods, err := kube.CoreV1().Pods(namespace).List(v12.ListOptions{})
+	if err != nil {
+		return nil, ListObjectsError("pods", err)
+	}
+	events, err := kube.CoreV1().Events(namespace).List(v12.ListOptions{})
+	if err != nil {
+		return nil, ListObjectsError("events", err)
+	}
+	diagnostics := &NamespaceDiagnostics{
+		Namespace: namespace,
+		Events:    events.Items,
+	}
+	sort.SliceStable(diagnostics.Events, func(i, j int) bool {
+		return diagnostics.Events[i].LastTimestamp.Before(&diagnostics.Events[j].LastTimestamp)
+	})
+	for _, pod := range pods.Items {
+		podDiagnostics := PodDiagnostics{
+			Pod:  pod,
+			Logs: make(map[string]string),
+		}
+		for _, status := range containerStatuses(&pod) {
+			podDiagnostics.Logs[status.Name] = getLogs(logs, &pod, status.Name, false, logLines)
+			if status.RestartCount > 0 {
+				podDiagnostics.Logs[status.Name+PreviousLogsSuffix] = getLogs(logs, &pod, status.Name, true, logLines)
+			}
+		}
+		diagnostics.Pods = append(diagnostics.Pods, podDiagnostics)
+	}
+	return diagnostics, nil
+}
+
+func getLogs(logs ContainerLogsFunc, pod *v1.Pod, container string, previous bool, lines int64) string {
+	contents, err := logs(pod.Namespace, pod.Name, container, previous, lines)
+	if err != nil {
+		return fmt.Sprintf("Unable to get logs: %v", err)
+	}
+	return contents
+}
@@ -0,0 +1,56 @@
+package kube_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/client/kube"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("namespace diagnostics", func() {
+
+	It("collects pods, events and container logs", func() {
+		now := time.Now()
+		pod := &v1.Pod{
+			ObjectMeta: v12.ObjectMeta{Name: "petclinic-1", Namespace: "petclinic"},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "petclinic", RestartCount: 2},
+					{Name: "envoy"},
+				},
+			},
+		}
+		newEvent := func(name string, lastSeen time.Time) *v1.Event {
+			return &v1.Event{
+				ObjectMeta:    v12.ObjectMeta{Name: name, Namespace: "petclinic"},
+				LastTimestamp: v12.NewTime(lastSeen),
+			}
+		}
+		client := fake.NewSimpleClientset(pod, newEvent("later", now), newEvent("earlier", now.Add(-time.Minute)))
+		logs := func(namespace, pod, container string, previous bool, lines int64) (string, error) {
+			Expect(lines).To(BeNumerically("==", 100))
+			if container == "envoy" {
+				return "", fmt.Errorf("container not started")
+			}
+			return fmt.Sprintf("%s/%s/%s previous=%t", namespace, pod, container, previous), nil
+		}
+
+		diagnostics, err := kube.CollectNamespaceDiagnostics(client, logs, "petclinic", 100)
+		Expect(err).To(BeNil())
+		Expect(diagnostics.Namespace).To(Equal("petclinic"))
+		Expect(diagnostics.Events).To(HaveLen(2))
+		Expect(diagnostics.Events[0].Name).To(Equal("earlier"))
+		Expect(diagnostics.Pods).To(HaveLen(1))
+		Expect(diagnostics.Pods[0].Pod.Name).To(Equal("petclinic-1"))
+		Expect(diagnostics.Pods[0].Logs).To(Equal(map[string]string{
+			"petclinic":          "petclinic/petclinic-1/petclinic previous=false",
+			"petclinic.previous": "petclinic/petclinic-1/petclinic previous=true",
+			"envoy":              "Unable to get logs: container not started",
+		}))
+	})
+})
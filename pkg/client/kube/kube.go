@@ -3,18 +3,18 @@ package kube
 import (
 	"bytes"
 	"fmt"
-	"github.com/solo-io/go-utils/kubeutils"
 	kubeerrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"os"
 	"os/exec"
 	"strings"
-	"time"
+	"sync"
 
 	errors "github.com/rotisserie/eris"
-	"github.com/solo-io/go-utils/testutils/kube"
-	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
 	v1 "k8s.io/api/core/v1"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -29,31 +29,121 @@ const (
 type Client interface {
 	// Wait until all of the pods in the provided namespace are ready (or completed successfully)
 	WaitUntilPodsRunning(namespace string) error
+	// Wait until the selected pods are ready (or completed successfully), with a timeout. If they aren't, the events
+	// and last container logs of the pods that aren't ready are logged.
+	WaitForPods(options WaitForPodsOptions) error
 	// Get the address of the service, trying to account for different service types (i.e. LoadBalancer) and
 	// Kubernetes flavors (i.e. Minikube)
 	GetIngressAddress(name, namespace, proxyPort string) (string, error)
+	// Get the decoded value of a key in a secret
+	GetSecretValue(namespace, name, key string) (string, error)
+	// Apply the objects in the manifests with server-side apply, rather than with kubectl. If force is true, fields
+	// that other managers own are taken over, rather than failing with a conflict.
+	ApplyManifests(manifests string, force bool) (ObjectResults, error)
+	// Delete the objects in the manifests, rather than with kubectl
+	DeleteManifests(manifests string) (ObjectResults, error)
+	// Patch an object with a json, merge or strategic merge patch, rather than with kubectl
+	PatchObject(kubeType, namespace, name, patchType, patch string) (*ObjectResult, error)
+	// Create a checker for the cluster, which lists objects and gets whether they are ready (i.e. a deployment is ready
+	// when its rollout is complete). The checker can be used repeatedly (i.e. while waiting for objects) without
+	// setting up the dynamic client and RESTMapper for each check.
+	NewResourceChecker() (ResourceChecker, error)
+	// Get the pods and events of a namespace, with the last lines of the logs of each container, to debug a failure
+	GetNamespaceDiagnostics(namespace string, logLines int64) (*NamespaceDiagnostics, error)
+	// Target the provided kubeconfig and kube context in subsequent calls. Empty values fall back
+	// to the default kubeconfig and the current context.
+	UseContext(kubeconfig, kubeContext string)
 }
 
-// Create a default kube client
+// Clients can implement LoggingClient, so their logs can be scoped, i.e. to the step that is running.
+type LoggingClient interface {
+	WithLogger(logger log.Logger) Client
+}
+
+// Create a default kube client, which logs with log.Default() (see WithLogger)
 func NewClient() *kubeClient {
-	return &kubeClient{}
+	return &kubeClient{
+		connection: &connection{},
+		logger:     log.Default(),
+	}
 }
 
+var _ LoggingClient = new(kubeClient)
+
 type kubeClient struct {
+	// Shared with the copies of the client from WithLogger, so they all target the same cluster
+	*connection
+	logger log.Logger
+}
+
+// The cluster that a client targets
+type connection struct {
+	lock        sync.RWMutex
+	kubeconfig  string
+	kubeContext string
+	// The dynamic client and RESTMapper for the cluster, created when first used (see resourceClients)
+	resources *resourceClients
+}
+
+// Returns a copy of the client that logs with the provided logger, and targets the same cluster.
+func (k *kubeClient) WithLogger(logger log.Logger) Client {
+	return &kubeClient{
+		connection: k.connection,
+		logger:     logger,
+	}
 }
 
 var (
 	TimedOutWaitingForPodsError = errors.Errorf("Timed out waiting for pods to come online")
+	SecretKeyNotFoundError      = func(namespace, name, key string) error {
+		return errors.Errorf("Secret %s.%s has no key %s", namespace, name, key)
+	}
 )
 
-func (k *kubeClient) GetIngressAddress(name, namespace, proxyPort string) (string, error) {
-	restCfg, err := kubeutils.GetConfig("", "")
+func (k *kubeClient) UseContext(kubeconfig, kubeContext string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.kubeconfig = kubeconfig
+	k.kubeContext = kubeContext
+	k.resources = nil
+}
+
+func (k *kubeClient) clientConfig() clientcmd.ClientConfig {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.newClientConfig()
+}
+
+// Returns the client config for the cluster. The caller must hold the lock.
+func (k *kubeClient) newClientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if k.kubeconfig != "" {
+		loadingRules.ExplicitPath = k.kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: k.kubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+func (k *kubeClient) restConfig() (*rest.Config, error) {
+	return k.clientConfig().ClientConfig()
+}
+
+func (k *kubeClient) kubernetes() (kubernetes.Interface, error) {
+	restCfg, err := k.restConfig()
 	if err != nil {
-		return "", errors.Wrapf(err, "getting kube rest config")
+		return nil, errors.Wrapf(err, "getting kube rest config")
 	}
 	kube, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
-		return "", errors.Wrapf(err, "starting kube client")
+		return nil, errors.Wrapf(err, "starting kube client")
+	}
+	return kube, nil
+}
+
+func (k *kubeClient) GetIngressAddress(name, namespace, proxyPort string) (string, error) {
+	kube, err := k.kubernetes()
+	if err != nil {
+		return "", err
 	}
 	svc, err := kube.CoreV1().Services(namespace).Get(name, v12.GetOptions{})
 	if err != nil {
@@ -148,57 +238,27 @@ func minikubeIp(clusterName string) (string, error) {
 
 
 func (k *kubeClient) WaitUntilPodsRunning(namespace string) error {
-	kubeClient, err := kube.KubeClient()
+	return k.WaitForPods(WaitForPodsOptions{Namespace: namespace})
+}
+
+func (k *kubeClient) GetSecretValue(namespace, name, key string) (string, error) {
+	kubeClient, err := k.kubernetes()
 	if err != nil {
-		return err
+		return "", err
 	}
-	pods := kubeClient.CoreV1().Pods(namespace)
-	podsReady := func() (bool, error) {
-		list, err := pods.List(v12.ListOptions{})
-		if err != nil {
-			return false, err
-		}
-		for _, pod := range list.Items {
-			if pod.Status.Phase == v1.PodSucceeded {
-				continue
-			}
-			var podReady bool
-			for _, cond := range pod.Status.Conditions {
-				if cond.Type == v1.ContainersReady && cond.Status == v1.ConditionTrue {
-					podReady = true
-					break
-				}
-			}
-			if !podReady {
-				return false, nil
-			}
-		}
-		return true, nil
-	}
-	failed := time.After(5 * time.Minute)
-	notYetRunning := make(map[string]struct{})
-	for {
-		select {
-		case <-failed:
-			cmd.Stderr().Println("Timed out waiting for pods to come online: %v", notYetRunning)
-			return TimedOutWaitingForPodsError
-		case <-time.After(time.Second / 2):
-			notYetRunning = make(map[string]struct{})
-			ready, err := podsReady()
-			if err != nil {
-				cmd.Stderr().Println("Error checking for ready pods: %s", err.Error())
-				return err
-			}
-			if ready {
-				cmd.Stdout().Println("Pods are ready")
-				return nil
-			}
-		}
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(name, v12.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "getting secret %s.%s", namespace, name)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", SecretKeyNotFoundError(namespace, name, key)
 	}
+	return string(value), nil
 }
 
 func (k *kubeClient) NamespaceIsActive(namespace string) (bool, error) {
-	kubeClient, err := kube.KubeClient()
+	kubeClient, err := k.kubernetes()
 	if err != nil {
 		return false, err
 	}
@@ -207,27 +267,27 @@ func (k *kubeClient) NamespaceIsActive(namespace string) (bool, error) {
 		if kubeerrs.IsNotFound(err) {
 			return false, nil
 		}
-		cmd.Stderr().Println("Error trying to get namespace %s: %s", namespace, err.Error())
+		k.logger.Errorf("Error trying to get namespace %s: %s", namespace, err.Error())
 		return false, err
 	}
 	if ns.Status.Phase != v1.NamespaceActive {
-		cmd.Stderr().Println("Namespace is not active (%s)", ns.Status.Phase)
+		k.logger.Warnf("Namespace is not active (%s)", ns.Status.Phase)
 	}
 	return true, nil
 }
 
 func (k *kubeClient) PodsReadyAndVersionsMatch(namespace, selector, version string) (bool, error) {
-	kubeClient, err := kube.KubeClient()
+	kubeClient, err := k.kubernetes()
 	if err != nil {
 		return false, err
 	}
 	pods, err := kubeClient.CoreV1().Pods(namespace).List(v12.ListOptions{LabelSelector: selector})
 	if err != nil {
-		cmd.Stderr().Println("Error listing pods: %s", err.Error())
+		k.logger.Errorf("Error listing pods: %s", err.Error())
 		return false, err
 	}
 	if len(pods.Items) == 0 {
-		cmd.Stdout().Println("No pods")
+		k.logger.Infof("No pods")
 		return false, nil
 	}
 	for _, pod := range pods.Items {
@@ -236,7 +296,7 @@ func (k *kubeClient) PodsReadyAndVersionsMatch(namespace, selector, version stri
 		}
 		for _, cond := range pod.Status.Conditions {
 			if cond.Type == v1.ContainersReady && cond.Status != v1.ConditionTrue {
-				cmd.Stdout().Println("Pods not ready")
+				k.logger.Infof("Pods not ready")
 				return false, nil
 			}
 		}
@@ -249,7 +309,7 @@ func (k *kubeClient) PodsReadyAndVersionsMatch(namespace, selector, version stri
 			}
 		}
 	}
-	cmd.Stdout().Println("Detected install, but did not find any containers with the expected version %s", version)
+	k.logger.Infof("Detected install, but did not find any containers with the expected version %s", version)
 	return false, nil
 }
 
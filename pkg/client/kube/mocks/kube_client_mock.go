@@ -6,6 +6,7 @@ package mock_kube
 
 import (
 	gomock "github.com/golang/mock/gomock"
+	kube "github.com/solo-io/valet/pkg/client/kube"
 	reflect "reflect"
 )
 
@@ -32,6 +33,36 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// ApplyManifests mocks base method
+func (m *MockClient) ApplyManifests(arg0 string, arg1 bool) (kube.ObjectResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyManifests", arg0, arg1)
+	ret0, _ := ret[0].(kube.ObjectResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyManifests indicates an expected call of ApplyManifests
+func (mr *MockClientMockRecorder) ApplyManifests(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyManifests", reflect.TypeOf((*MockClient)(nil).ApplyManifests), arg0, arg1)
+}
+
+// DeleteManifests mocks base method
+func (m *MockClient) DeleteManifests(arg0 string) (kube.ObjectResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteManifests", arg0)
+	ret0, _ := ret[0].(kube.ObjectResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteManifests indicates an expected call of DeleteManifests
+func (mr *MockClientMockRecorder) DeleteManifests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteManifests", reflect.TypeOf((*MockClient)(nil).DeleteManifests), arg0)
+}
+
 // GetIngressAddress mocks base method
 func (m *MockClient) GetIngressAddress(arg0, arg1, arg2 string) (string, error) {
 	m.ctrl.T.Helper()
@@ -47,6 +78,92 @@ func (mr *MockClientMockRecorder) GetIngressAddress(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIngressAddress", reflect.TypeOf((*MockClient)(nil).GetIngressAddress), arg0, arg1, arg2)
 }
 
+// GetNamespaceDiagnostics mocks base method
+func (m *MockClient) GetNamespaceDiagnostics(arg0 string, arg1 int64) (*kube.NamespaceDiagnostics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNamespaceDiagnostics", arg0, arg1)
+	ret0, _ := ret[0].(*kube.NamespaceDiagnostics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNamespaceDiagnostics indicates an expected call of GetNamespaceDiagnostics
+func (mr *MockClientMockRecorder) GetNamespaceDiagnostics(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNamespaceDiagnostics", reflect.TypeOf((*MockClient)(nil).GetNamespaceDiagnostics), arg0, arg1)
+}
+
+// GetSecretValue mocks base method
+func (m *MockClient) GetSecretValue(arg0, arg1, arg2 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecretValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecretValue indicates an expected call of GetSecretValue
+func (mr *MockClientMockRecorder) GetSecretValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecretValue", reflect.TypeOf((*MockClient)(nil).GetSecretValue), arg0, arg1, arg2)
+}
+
+// NewResourceChecker mocks base method
+func (m *MockClient) NewResourceChecker() (kube.ResourceChecker, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewResourceChecker")
+	ret0, _ := ret[0].(kube.ResourceChecker)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewResourceChecker indicates an expected call of NewResourceChecker
+func (mr *MockClientMockRecorder) NewResourceChecker() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewResourceChecker", reflect.TypeOf((*MockClient)(nil).NewResourceChecker))
+}
+
+// PatchObject mocks base method
+func (m *MockClient) PatchObject(arg0, arg1, arg2, arg3, arg4 string) (*kube.ObjectResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchObject", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*kube.ObjectResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchObject indicates an expected call of PatchObject
+func (mr *MockClientMockRecorder) PatchObject(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchObject", reflect.TypeOf((*MockClient)(nil).PatchObject), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UseContext mocks base method
+func (m *MockClient) UseContext(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UseContext", arg0, arg1)
+}
+
+// UseContext indicates an expected call of UseContext
+func (mr *MockClientMockRecorder) UseContext(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseContext", reflect.TypeOf((*MockClient)(nil).UseContext), arg0, arg1)
+}
+
+// WaitForPods mocks base method
+func (m *MockClient) WaitForPods(arg0 kube.WaitForPodsOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForPods", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForPods indicates an expected call of WaitForPods
+func (mr *MockClientMockRecorder) WaitForPods(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForPods", reflect.TypeOf((*MockClient)(nil).WaitForPods), arg0)
+}
+
 // WaitUntilPodsRunning mocks base method
 func (m *MockClient) WaitUntilPodsRunning(arg0 string) error {
 	m.ctrl.T.Helper()
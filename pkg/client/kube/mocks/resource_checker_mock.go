@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/solo-io/valet/pkg/client/kube (interfaces: ResourceChecker)
+
+// Package mock_kube is a generated GoMock package.
+package mock_kube
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	kube "github.com/solo-io/valet/pkg/client/kube"
+	reflect "reflect"
+)
+
+// MockResourceChecker is a mock of ResourceChecker interface
+type MockResourceChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceCheckerMockRecorder
+}
+
+// MockResourceCheckerMockRecorder is the mock recorder for MockResourceChecker
+type MockResourceCheckerMockRecorder struct {
+	mock *MockResourceChecker
+}
+
+// NewMockResourceChecker creates a new mock instance
+func NewMockResourceChecker(ctrl *gomock.Controller) *MockResourceChecker {
+	mock := &MockResourceChecker{ctrl: ctrl}
+	mock.recorder = &MockResourceCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockResourceChecker) EXPECT() *MockResourceCheckerMockRecorder {
+	return m.recorder
+}
+
+// GetReadiness mocks base method
+func (m *MockResourceChecker) GetReadiness(arg0 []kube.ResourceRef) ([]kube.Readiness, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReadiness", arg0)
+	ret0, _ := ret[0].([]kube.Readiness)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReadiness indicates an expected call of GetReadiness
+func (mr *MockResourceCheckerMockRecorder) GetReadiness(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReadiness", reflect.TypeOf((*MockResourceChecker)(nil).GetReadiness), arg0)
+}
+
+// ListResources mocks base method
+func (m *MockResourceChecker) ListResources(arg0 []string, arg1, arg2 string) ([]kube.ResourceRef, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListResources", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]kube.ResourceRef)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListResources indicates an expected call of ListResources
+func (mr *MockResourceCheckerMockRecorder) ListResources(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListResources", reflect.TypeOf((*MockResourceChecker)(nil).ListResources), arg0, arg1, arg2)
+}
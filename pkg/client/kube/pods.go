@@ -0,0 +1,260 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	DefaultPodsTimeout  = 5 * time.Minute
+	DefaultPodsInterval = time.Second / 2
+	// The number of lines of logs printed for each container of a pod that isn't ready
+	DiagnosticLogLines = 20
+)
+
+var (
+	PodFailedError = func(namespace, name, reason string) error {
+		return errors.Errorf("Pod %s/%s failed: %s", namespace, name, reason)
+	}
+)
+
+// Options for waiting for pods. The zero value (other than the namespace) waits up to DefaultPodsTimeout for every
+// pod in the namespace to be ready.
+type WaitForPodsOptions struct {
+	Namespace string
+	// Optional, only wait for the pods that match this label selector (i.e. "app=petclinic")
+	Selector string
+	// Optional, defaults to DefaultPodsTimeout
+	Timeout time.Duration
+	// Optional, defaults to DefaultPodsInterval
+	Interval time.Duration
+	// Optional, keep waiting until there are at least this many pods
+	MinPods int
+	// Optional, fail as soon as a container can't start (i.e. CrashLoopBackOff or ImagePullBackOff), rather than
+	// waiting for the timeout
+	FailFast bool
+	// Optional, prefixes of the names of pods to ignore (i.e. "migrate-db" for the pods of a job)
+	Ignore []string
+	// Optional, stop waiting when this is done (i.e. when the step times out)
+	Context context.Context
+}
+
+// Returns the last lines of the logs of a container, or of its previous instance if it restarted.
+type ContainerLogsFunc func(namespace, pod, container string, previous bool, lines int64) (string, error)
+
+// Waits for pods to be ready (or completed successfully). If they aren't ready in time, the events and last container
+// logs of the pods that aren't ready are logged.
+type PodWaiter struct {
+	kube   kubernetes.Interface
+	logger log.Logger
+	logs   ContainerLogsFunc
+}
+
+func NewPodWaiter(kube kubernetes.Interface, logger log.Logger) *PodWaiter {
+	return &PodWaiter{
+		kube:   kube,
+		logger: logger,
+		logs:   containerLogs(kube),
+	}
+}
+
+func containerLogs(kube kubernetes.Interface) ContainerLogsFunc {
+	return func(namespace, pod, container string, previous bool, lines int64) (string, error) {
+		raw, err := kube.CoreV1().Pods(namespace).GetLogs(pod, &v1.PodLogOptions{
+			Container: container,
+			Previous:  previous,
+			TailLines: &lines,
+		}).DoRaw()
+		return string(raw), err
+	}
+}
+
+// Use a different function to get container logs for diagnostics.
+func (p *PodWaiter) WithContainerLogs(logs ContainerLogsFunc) *PodWaiter {
+	p.logs = logs
+	return p
+}
+
+func (k *kubeClient) WaitForPods(options WaitForPodsOptions) error {
+	kubeClient, err := k.kubernetes()
+	if err != nil {
+		return err
+	}
+	return NewPodWaiter(kubeClient, k.logger).Wait(options)
+}
+
+func (p *PodWaiter) Wait(options WaitForPodsOptions) error {
+	timeout, interval := options.Timeout, options.Interval
+	if timeout == 0 {
+		timeout = DefaultPodsTimeout
+	}
+	if interval == 0 {
+		interval = DefaultPodsInterval
+	}
+	var done <-chan struct{}
+	if options.Context != nil {
+		done = options.Context.Done()
+	}
+	timedOut := time.After(timeout)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		pods, err := p.listPods(options)
+		if err != nil {
+			p.logger.Errorf("Error checking for ready pods: %s", err.Error())
+			return err
+		}
+		notYetRunning := make(map[string]string)
+		for _, pod := range pods {
+			ready, failed, reason := podStatus(pod)
+			if failed && options.FailFast {
+				p.logDiagnostics(pod, reason)
+				return PodFailedError(pod.Namespace, pod.Name, reason)
+			}
+			if !ready {
+				notYetRunning[pod.Name] = reason
+			}
+		}
+		if len(notYetRunning) == 0 && len(pods) >= options.MinPods {
+			p.logger.Infof("Pods are ready")
+			return nil
+		}
+		select {
+		case <-timedOut:
+			if len(pods) < options.MinPods {
+				p.logger.Errorf("Timed out waiting for pods to come online: found %d pods, expected at least %d", len(pods), options.MinPods)
+			}
+			if len(notYetRunning) > 0 {
+				p.logger.Errorf("Timed out waiting for pods to come online: %s", formatNotYetRunning(notYetRunning))
+			}
+			for _, pod := range pods {
+				if reason, ok := notYetRunning[pod.Name]; ok {
+					p.logDiagnostics(pod, reason)
+				}
+			}
+			return TimedOutWaitingForPodsError
+		case <-done:
+			return options.Context.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+func (p *PodWaiter) listPods(options WaitForPodsOptions) ([]*v1.Pod, error) {
+	list, err := p.kube.CoreV1().Pods(options.Namespace).List(v12.ListOptions{LabelSelector: options.Selector})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*v1.Pod
+	for i := range list.Items {
+		if !ignored(list.Items[i].Name, options.Ignore) {
+			pods = append(pods, &list.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+func ignored(name string, ignore []string) bool {
+	for _, prefix := range ignore {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether a pod is ready or has failed, and why not if it isn't ready. A pod has failed if it failed, or if
+// one of its containers can't start.
+func podStatus(pod *v1.Pod) (bool, bool, string) {
+	switch pod.Status.Phase {
+	case v1.PodSucceeded:
+		return true, false, ""
+	case v1.PodFailed:
+		if pod.Status.Reason != "" {
+			return false, true, pod.Status.Reason
+		}
+		return false, true, "failed"
+	}
+	statuses := containerStatuses(pod)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		for _, failedReason := range failedContainerReasons {
+			if status.State.Waiting.Reason == failedReason {
+				return false, true, fmt.Sprintf("container %s: %s", status.Name, failedReason)
+			}
+		}
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.ContainersReady && cond.Status == v1.ConditionTrue {
+			return true, false, ""
+		}
+	}
+	for _, status := range statuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return false, false, fmt.Sprintf("container %s: %s", status.Name, status.State.Waiting.Reason)
+		}
+	}
+	return false, false, strings.ToLower(string(pod.Status.Phase))
+}
+
+func containerStatuses(pod *v1.Pod) []v1.ContainerStatus {
+	return append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+}
+
+func formatNotYetRunning(notYetRunning map[string]string) string {
+	var pods []string
+	for name, reason := range notYetRunning {
+		pods = append(pods, fmt.Sprintf("%s (%s)", name, reason))
+	}
+	sort.Strings(pods)
+	return strings.Join(pods, ", ")
+}
+
+// Log the events and the last lines of the container logs of a pod that isn't ready.
+func (p *PodWaiter) logDiagnostics(pod *v1.Pod, reason string) {
+	p.logger.Warnf("Pod %s/%s is not ready: %s", pod.Namespace, pod.Name, reason)
+	events, err := p.kube.CoreV1().Events(pod.Namespace).List(v12.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", pod.Name),
+	})
+	if err != nil {
+		p.logger.Warnf("Unable to get events of pod %s: %v", pod.Name, err)
+	} else {
+		var lines []string
+		for _, event := range events.Items {
+			if event.InvolvedObject.Name != pod.Name {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s\t%s\t%s", event.Type, event.Reason, event.Message))
+		}
+		if len(lines) > 0 {
+			p.logger.Warnf("Events of pod %s:\n%s", pod.Name, strings.Join(lines, "\n"))
+		}
+	}
+	statuses := containerStatuses(pod)
+	for _, status := range statuses {
+		if status.Ready {
+			continue
+		}
+		// The logs of a crashing container are in its previous instance
+		previous := status.RestartCount > 0 && status.State.Running == nil
+		logs, err := p.logs(pod.Namespace, pod.Name, status.Name, previous, DiagnosticLogLines)
+		if err != nil {
+			p.logger.Warnf("Unable to get logs of container %s of pod %s: %v", status.Name, pod.Name, err)
+			continue
+		}
+		if logs = strings.TrimSpace(logs); logs != "" {
+			p.logger.Warnf("Last logs of container %s of pod %s:\n%s", status.Name, pod.Name, logs)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package kube_test
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/client/kube"
+	"github.com/solo-io/valet/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("pod waiter", func() {
+
+	const (
+		namespace = "petclinic"
+	)
+
+	var (
+		out    *bytes.Buffer
+		logger log.Logger
+	)
+
+	newPod := func(name string, labels map[string]string, status v1.PodStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: v12.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Status:     status,
+		}
+	}
+
+	ready := v1.PodStatus{
+		Phase:      v1.PodRunning,
+		Conditions: []v1.PodCondition{{Type: v1.ContainersReady, Status: v1.ConditionTrue}},
+	}
+
+	crashing := v1.PodStatus{
+		Phase: v1.PodRunning,
+		ContainerStatuses: []v1.ContainerStatus{{
+			Name:         "petclinic",
+			RestartCount: 3,
+			State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}},
+	}
+
+	newWaiter := func(objects ...runtime.Object) *kube.PodWaiter {
+		return kube.NewPodWaiter(fake.NewSimpleClientset(objects...), logger).
+			WithContainerLogs(func(namespace, pod, container string, previous bool, lines int64) (string, error) {
+				Expect(previous).To(BeTrue())
+				Expect(lines).To(BeNumerically("==", kube.DiagnosticLogLines))
+				return "connecting to db: connection refused\n", nil
+			})
+	}
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		logger = log.New(log.Options{Out: out, Err: out})
+	})
+
+	It("waits for the selected pods, ignoring others", func() {
+		waiter := newWaiter(
+			newPod("petclinic-1", map[string]string{"app": "petclinic"}, ready),
+			newPod("petclinic-db-migrate", map[string]string{"app": "petclinic"}, crashing),
+			newPod("other", map[string]string{"app": "other"}, crashing),
+		)
+		err := waiter.Wait(kube.WaitForPodsOptions{
+			Namespace: namespace,
+			Selector:  "app=petclinic",
+			Ignore:    []string{"petclinic-db"},
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("waits for a minimum number of pods", func() {
+		waiter := newWaiter(newPod("petclinic-1", nil, ready))
+		err := waiter.Wait(kube.WaitForPodsOptions{
+			Namespace: namespace,
+			MinPods:   2,
+			Timeout:   10 * time.Millisecond,
+			Interval:  time.Millisecond,
+		})
+		Expect(err).To(Equal(kube.TimedOutWaitingForPodsError))
+		Expect(out.String()).To(ContainSubstring("found 1 pods, expected at least 2"))
+	})
+
+	It("logs the events and logs of pods that aren't ready when it times out", func() {
+		event := &v1.Event{
+			ObjectMeta:     v12.ObjectMeta{Name: "petclinic-1.1", Namespace: namespace},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "petclinic-1", Namespace: namespace},
+			Type:           v1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+		}
+		waiter := newWaiter(newPod("petclinic-1", nil, crashing), event)
+		err := waiter.Wait(kube.WaitForPodsOptions{
+			Namespace: namespace,
+			Timeout:   10 * time.Millisecond,
+			Interval:  time.Millisecond,
+		})
+		Expect(err).To(Equal(kube.TimedOutWaitingForPodsError))
+		Expect(out.String()).To(ContainSubstring("petclinic-1 (container petclinic: CrashLoopBackOff)"))
+		Expect(out.String()).To(ContainSubstring("Back-off restarting failed container"))
+		Expect(out.String()).To(ContainSubstring("connecting to db: connection refused"))
+	})
+
+	It("fails fast if a container can't start", func() {
+		waiter := newWaiter(newPod("petclinic-1", nil, crashing))
+		err := waiter.Wait(kube.WaitForPodsOptions{
+			Namespace: namespace,
+			FailFast:  true,
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Pod petclinic/petclinic-1 failed: container petclinic: CrashLoopBackOff"))
+		Expect(out.String()).To(ContainSubstring("connecting to db: connection refused"))
+	})
+})
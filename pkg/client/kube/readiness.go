@@ -0,0 +1,435 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	kubeerrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	GetObjectError = func(object string, err error) error {
+		return errors.Wrapf(err, "Error getting %s", object)
+	}
+	ListObjectsError = func(kind string, err error) error {
+		return errors.Wrapf(err, "Error listing %s", kind)
+	}
+
+	// Container states that won't recover without a change to the pod, so waiting for them is pointless
+	failedContainerReasons = []string{"CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "CreateContainerConfigError"}
+
+	endpoints = schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}
+)
+
+// A reference to an object. The kind can be a resource name as it would be passed to kubectl (i.e. "deploy") if the
+// api version is empty.
+type ResourceRef struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+func (r ResourceRef) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// Whether an object is ready, and if not, why. An object has failed if it won't become ready without a change,
+// i.e. a job that failed or a pod with an image that can't be pulled.
+type Readiness struct {
+	Resource ResourceRef
+	Ready    bool
+	Failed   bool
+	Reason   string
+}
+
+//go:generate mockgen -destination ./mocks/resource_checker_mock.go github.com/solo-io/valet/pkg/client/kube ResourceChecker
+
+// Lists objects and checks whether they are ready, in the cluster that it was created for (see
+// Client.NewResourceChecker).
+type ResourceChecker interface {
+	// Get whether each object is ready, using rules for the kind of the object
+	GetReadiness(refs []ResourceRef) ([]Readiness, error)
+	// List the objects of each kind (i.e. "deploy") in the namespace that match a label selector
+	ListResources(kinds []string, namespace, selector string) ([]ResourceRef, error)
+}
+
+var _ ResourceChecker = new(ReadinessChecker)
+
+// Checks whether objects are ready in process, with a dynamic client and a RESTMapper, using rules for the kind of
+// each object (see GetReadiness).
+type ReadinessChecker struct {
+	resourceClients
+}
+
+func NewReadinessChecker(dynamicClient dynamic.Interface, mapper meta.RESTMapper, defaultNamespace string) *ReadinessChecker {
+	return &ReadinessChecker{
+		resourceClients: newResourceClients(dynamicClient, mapper, defaultNamespace),
+	}
+}
+
+func (k *kubeClient) NewResourceChecker() (ResourceChecker, error) {
+	resources, err := k.resourceClients()
+	if err != nil {
+		return nil, err
+	}
+	return &ReadinessChecker{resourceClients: resources}, nil
+}
+
+// Returns the objects of each kind in the namespace (or the default namespace) that match a label selector. Kinds are
+// resource names as they would be passed to kubectl (i.e. "deploy").
+func (r *ReadinessChecker) ListResources(kinds []string, namespace, selector string) ([]ResourceRef, error) {
+	if namespace == "" {
+		namespace = r.defaultNamespace
+	}
+	var refs []ResourceRef
+	for _, kind := range kinds {
+		gvk, err := r.kindFor(kind)
+		if err != nil {
+			return nil, err
+		}
+		mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, UnknownKindError(kind, err)
+		}
+		var client dynamic.ResourceInterface = r.dynamic.Resource(mapping.Resource)
+		namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+		if namespaced {
+			client = r.dynamic.Resource(mapping.Resource).Namespace(namespace)
+		}
+		list, err := client.List(v12.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, ListObjectsError(kind, err)
+		}
+		for _, item := range list.Items {
+			ref := ResourceRef{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       item.GetName(),
+			}
+			if namespaced {
+				ref.Namespace = item.GetNamespace()
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// Returns the readiness of each object, in order. The rules depend on the kind of the object:
+//   - deployments, stateful sets and daemon sets are ready when their rollout is complete and their replicas are available
+//   - jobs are ready when they complete, and fail if they fail
+//   - pods are ready when they are ready or succeeded, and fail if they fail or a container can't start
+//   - services are ready when they have an address (load balancers) or endpoints with addresses (other types)
+//   - custom resource definitions are ready when they are established, namespaces when they are active and
+//     persistent volume claims when they are bound
+//   - other objects are ready when their Ready condition is true, or when their status.state is Accepted (i.e. gloo
+//     virtual services), and fail if it is Rejected
+//   - any other object is ready once it exists
+//
+// An object that doesn't exist yet isn't ready.
+func (r *ReadinessChecker) GetReadiness(refs []ResourceRef) ([]Readiness, error) {
+	var readiness []Readiness
+	for _, ref := range refs {
+		ready, err := r.getReadiness(ref)
+		if err != nil {
+			return nil, err
+		}
+		readiness = append(readiness, *ready)
+	}
+	return readiness, nil
+}
+
+func (r *ReadinessChecker) getReadiness(ref ResourceRef) (*Readiness, error) {
+	object := &unstructured.Unstructured{}
+	if ref.APIVersion == "" {
+		gvk, err := r.kindFor(ref.Kind)
+		if err != nil {
+			return nil, err
+		}
+		object.SetGroupVersionKind(gvk)
+	} else {
+		object.SetAPIVersion(ref.APIVersion)
+		object.SetKind(ref.Kind)
+	}
+	object.SetNamespace(ref.Namespace)
+	object.SetName(ref.Name)
+	client, result, err := r.resourceFor(object)
+	if err != nil {
+		return nil, err
+	}
+	readiness := &Readiness{
+		Resource: ResourceRef{
+			APIVersion: object.GetAPIVersion(),
+			Kind:       result.Kind,
+			Namespace:  result.Namespace,
+			Name:       result.Name,
+		},
+	}
+	existing, err := client.Get(ref.Name, v12.GetOptions{})
+	if kubeerrs.IsNotFound(err) {
+		readiness.Reason = "not found"
+		return readiness, nil
+	} else if err != nil {
+		return nil, GetObjectError(result.object(), err)
+	}
+	if existing.GetKind() == "Service" {
+		return r.serviceReadiness(readiness, existing)
+	}
+	readiness.Ready, readiness.Failed, readiness.Reason = objectReadiness(existing)
+	return readiness, nil
+}
+
+// A load balancer is ready when it has an address. Other services are ready when their endpoints have an address,
+// unless they don't select pods, in which case their endpoints are managed some other way.
+func (r *ReadinessChecker) serviceReadiness(readiness *Readiness, service *unstructured.Unstructured) (*Readiness, error) {
+	serviceType, _, _ := unstructured.NestedString(service.Object, "spec", "type")
+	switch serviceType {
+	case "ExternalName":
+		readiness.Ready = true
+		return readiness, nil
+	case "LoadBalancer":
+		ingress, _, _ := unstructured.NestedSlice(service.Object, "status", "loadBalancer", "ingress")
+		if readiness.Ready = len(ingress) > 0; !readiness.Ready {
+			readiness.Reason = "waiting for a load balancer address"
+		}
+		return readiness, nil
+	}
+	selector, _, _ := unstructured.NestedMap(service.Object, "spec", "selector")
+	if len(selector) == 0 {
+		readiness.Ready = true
+		return readiness, nil
+	}
+	existing, err := r.dynamic.Resource(endpoints).Namespace(service.GetNamespace()).Get(service.GetName(), v12.GetOptions{})
+	if kubeerrs.IsNotFound(err) {
+		readiness.Reason = "no endpoints with addresses"
+		return readiness, nil
+	} else if err != nil {
+		return nil, GetObjectError(fmt.Sprintf("Endpoints %s/%s", service.GetNamespace(), service.GetName()), err)
+	}
+	subsets, _, _ := unstructured.NestedSlice(existing.Object, "subsets")
+	for _, subset := range subsets {
+		if addresses, ok := subset.(map[string]interface{})["addresses"].([]interface{}); ok && len(addresses) > 0 {
+			readiness.Ready = true
+			return readiness, nil
+		}
+	}
+	readiness.Reason = "no endpoints with addresses"
+	return readiness, nil
+}
+
+// Returns whether an object that exists is ready or has failed, and why not if it isn't ready.
+func objectReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	if object.GetDeletionTimestamp() != nil {
+		return false, false, "being deleted"
+	}
+	switch object.GetKind() {
+	case "Deployment":
+		return deploymentReadiness(object)
+	case "StatefulSet":
+		return statefulSetReadiness(object)
+	case "DaemonSet":
+		return daemonSetReadiness(object)
+	case "Job":
+		return jobReadiness(object)
+	case "Pod":
+		return podReadiness(object)
+	case "CustomResourceDefinition":
+		if condition := getCondition(object, "Established"); condition == nil || condition["status"] != "True" {
+			return false, false, "not established"
+		}
+		return true, false, ""
+	case "Namespace":
+		return phaseReadiness(object, "Active")
+	case "PersistentVolumeClaim":
+		return phaseReadiness(object, "Bound")
+	}
+	if condition := getCondition(object, "Ready"); condition != nil {
+		if condition["status"] == "True" {
+			return true, false, ""
+		}
+		return false, false, conditionReason(condition, "not ready")
+	}
+	if state, ok, _ := unstructured.NestedFieldNoCopy(object.Object, "status", "state"); ok {
+		return stateReadiness(object, state)
+	}
+	return true, false, ""
+}
+
+func deploymentReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	if !observed(object) {
+		return false, false, "waiting for the rollout to be observed"
+	}
+	if condition := getCondition(object, "Progressing"); condition != nil && condition["reason"] == "ProgressDeadlineExceeded" {
+		return false, true, conditionReason(condition, "progress deadline exceeded")
+	}
+	replicas := getInt(object, 1, "spec", "replicas")
+	updated := getInt(object, 0, "status", "updatedReplicas")
+	current := getInt(object, 0, "status", "replicas")
+	available := getInt(object, 0, "status", "availableReplicas")
+	switch {
+	case updated < replicas:
+		return false, false, fmt.Sprintf("%d of %d replicas updated", updated, replicas)
+	case current > updated:
+		return false, false, fmt.Sprintf("%d old replicas pending termination", current-updated)
+	case available < updated:
+		return false, false, fmt.Sprintf("%d of %d updated replicas available", available, updated)
+	}
+	return true, false, ""
+}
+
+func statefulSetReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	if !observed(object) {
+		return false, false, "waiting for the rollout to be observed"
+	}
+	replicas := getInt(object, 1, "spec", "replicas")
+	ready := getInt(object, 0, "status", "readyReplicas")
+	if ready < replicas {
+		return false, false, fmt.Sprintf("%d of %d replicas ready", ready, replicas)
+	}
+	strategy, _, _ := unstructured.NestedString(object.Object, "spec", "updateStrategy", "type")
+	if strategy == "OnDelete" {
+		return true, false, ""
+	}
+	updateRevision, _, _ := unstructured.NestedString(object.Object, "status", "updateRevision")
+	currentRevision, _, _ := unstructured.NestedString(object.Object, "status", "currentRevision")
+	if updateRevision != currentRevision {
+		updated := getInt(object, 0, "status", "updatedReplicas")
+		return false, false, fmt.Sprintf("%d of %d replicas updated", updated, replicas)
+	}
+	return true, false, ""
+}
+
+func daemonSetReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	if !observed(object) {
+		return false, false, "waiting for the rollout to be observed"
+	}
+	desired := getInt(object, 0, "status", "desiredNumberScheduled")
+	updated := getInt(object, 0, "status", "updatedNumberScheduled")
+	available := getInt(object, 0, "status", "numberAvailable")
+	switch {
+	case updated < desired:
+		return false, false, fmt.Sprintf("%d of %d pods updated", updated, desired)
+	case available < desired:
+		return false, false, fmt.Sprintf("%d of %d pods available", available, desired)
+	}
+	return true, false, ""
+}
+
+func jobReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	if condition := getCondition(object, "Failed"); condition != nil && condition["status"] == "True" {
+		return false, true, conditionReason(condition, "failed")
+	}
+	if condition := getCondition(object, "Complete"); condition != nil && condition["status"] == "True" {
+		return true, false, ""
+	}
+	return false, false, "not complete"
+}
+
+func podReadiness(object *unstructured.Unstructured) (bool, bool, string) {
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return true, false, ""
+	case "Failed":
+		reason, _, _ := unstructured.NestedString(object.Object, "status", "reason")
+		if reason == "" {
+			reason = "failed"
+		}
+		return false, true, reason
+	}
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _, _ := unstructured.NestedSlice(object.Object, "status", field)
+		for _, status := range statuses {
+			container, _ := status.(map[string]interface{})
+			reason, _, _ := unstructured.NestedString(container, "state", "waiting", "reason")
+			for _, failedReason := range failedContainerReasons {
+				if reason == failedReason {
+					return false, true, fmt.Sprintf("container %v: %s", container["name"], reason)
+				}
+			}
+		}
+	}
+	if condition := getCondition(object, "Ready"); condition != nil && condition["status"] == "True" {
+		return true, false, ""
+	}
+	if phase == "" {
+		return false, false, "not ready"
+	}
+	return false, false, strings.ToLower(phase)
+}
+
+func phaseReadiness(object *unstructured.Unstructured, readyPhase string) (bool, bool, string) {
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	if phase != readyPhase {
+		return false, false, fmt.Sprintf("phase is %q", phase)
+	}
+	return true, false, ""
+}
+
+// Solo-kit resources (i.e. gloo virtual services) report a state, which is either a name or the number of the
+// name when serialized as an enum.
+func stateReadiness(object *unstructured.Unstructured, state interface{}) (bool, bool, string) {
+	switch fmt.Sprintf("%v", state) {
+	case "Accepted", "1", "Warning", "3":
+		return true, false, ""
+	case "Rejected", "2":
+		reason, _, _ := unstructured.NestedString(object.Object, "status", "reason")
+		if reason == "" {
+			reason = "rejected"
+		}
+		return false, true, reason
+	}
+	return false, false, "pending"
+}
+
+// Returns false if the controller hasn't seen the latest generation of the object yet.
+func observed(object *unstructured.Unstructured) bool {
+	observedGeneration, ok, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+	return !ok || observedGeneration >= object.GetGeneration()
+}
+
+func getInt(object *unstructured.Unstructured, defaultValue int64, fields ...string) int64 {
+	value, ok, _ := unstructured.NestedFieldNoCopy(object.Object, fields...)
+	if !ok {
+		return defaultValue
+	}
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return defaultValue
+}
+
+func getCondition(object *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, condition := range conditions {
+		if c, ok := condition.(map[string]interface{}); ok && c["type"] == conditionType {
+			return c
+		}
+	}
+	return nil
+}
+
+func conditionReason(condition map[string]interface{}, defaultReason string) string {
+	if message, ok := condition["message"].(string); ok && message != "" {
+		return message
+	}
+	if reason, ok := condition["reason"].(string); ok && reason != "" {
+		return reason
+	}
+	return defaultReason
+}
@@ -0,0 +1,178 @@
+package kube_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/client/kube"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("readiness", func() {
+
+	var (
+		mapper *meta.DefaultRESTMapper
+	)
+
+	newObject := func(apiVersion, kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: fields}
+		if object.Object == nil {
+			object.Object = map[string]interface{}{}
+		}
+		object.SetAPIVersion(apiVersion)
+		object.SetKind(kind)
+		object.SetNamespace(namespace)
+		object.SetName(name)
+		return object
+	}
+
+	newChecker := func(objects ...runtime.Object) *kube.ReadinessChecker {
+		return kube.NewReadinessChecker(fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), mapper, "gloo-system")
+	}
+
+	getReadiness := func(ref kube.ResourceRef, objects ...runtime.Object) kube.Readiness {
+		readiness, err := newChecker(objects...).GetReadiness([]kube.ResourceRef{ref})
+		Expect(err).To(BeNil())
+		Expect(readiness).To(HaveLen(1))
+		return readiness[0]
+	}
+
+	BeforeEach(func() {
+		mapper = meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+		mapper.Add(schema.GroupVersionKind{Group: "gateway.solo.io", Version: "v1", Kind: "VirtualService"}, meta.RESTScopeNamespace)
+	})
+
+	It("isn't ready if the object doesn't exist", func() {
+		readiness := getReadiness(kube.ResourceRef{Kind: "deployment", Name: "petclinic"})
+		Expect(readiness).To(Equal(kube.Readiness{
+			Resource: kube.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "gloo-system", Name: "petclinic"},
+			Reason:   "not found",
+		}))
+		Expect(readiness.Resource.String()).To(Equal("Deployment gloo-system/petclinic"))
+	})
+
+	Context("deployments", func() {
+		ref := kube.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "petclinic", Name: "petclinic"}
+
+		deployment := func(status map[string]interface{}) *unstructured.Unstructured {
+			return newObject("apps/v1", "Deployment", "petclinic", "petclinic", map[string]interface{}{
+				"spec":   map[string]interface{}{"replicas": int64(2)},
+				"status": status,
+			})
+		}
+
+		It("is ready when the rollout is complete", func() {
+			readiness := getReadiness(ref, deployment(map[string]interface{}{
+				"replicas":          int64(2),
+				"updatedReplicas":   int64(2),
+				"availableReplicas": int64(2),
+			}))
+			Expect(readiness.Ready).To(BeTrue())
+		})
+
+		It("isn't ready while replicas are updating", func() {
+			readiness := getReadiness(ref, deployment(map[string]interface{}{
+				"replicas":          int64(3),
+				"updatedReplicas":   int64(2),
+				"availableReplicas": int64(1),
+			}))
+			Expect(readiness.Ready).To(BeFalse())
+			Expect(readiness.Reason).To(Equal("1 old replicas pending termination"))
+		})
+
+		It("fails if the progress deadline is exceeded", func() {
+			readiness := getReadiness(ref, deployment(map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+				},
+			}))
+			Expect(readiness.Ready).To(BeFalse())
+			Expect(readiness.Failed).To(BeTrue())
+			Expect(readiness.Reason).To(Equal("ProgressDeadlineExceeded"))
+		})
+	})
+
+	It("fails jobs that failed", func() {
+		job := newObject("batch/v1", "Job", "default", "migrate", map[string]interface{}{
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Failed", "status": "True", "message": "Job has reached the specified backoff limit"},
+				},
+			},
+		})
+		readiness := getReadiness(kube.ResourceRef{Kind: "job", Namespace: "default", Name: "migrate"}, job)
+		Expect(readiness.Failed).To(BeTrue())
+		Expect(readiness.Reason).To(Equal("Job has reached the specified backoff limit"))
+	})
+
+	It("fails pods with containers that can't start", func() {
+		pod := newObject("v1", "Pod", "default", "petclinic", map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": "Pending",
+				"containerStatuses": []interface{}{
+					map[string]interface{}{"name": "petclinic", "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "ImagePullBackOff"}}},
+				},
+			},
+		})
+		readiness := getReadiness(kube.ResourceRef{Kind: "pod", Namespace: "default", Name: "petclinic"}, pod)
+		Expect(readiness.Failed).To(BeTrue())
+		Expect(readiness.Reason).To(Equal("container petclinic: ImagePullBackOff"))
+	})
+
+	It("waits for service endpoints", func() {
+		service := newObject("v1", "Service", "default", "petclinic", map[string]interface{}{
+			"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "petclinic"}},
+		})
+		ref := kube.ResourceRef{Kind: "service", Namespace: "default", Name: "petclinic"}
+		readiness := getReadiness(ref, service)
+		Expect(readiness.Ready).To(BeFalse())
+		Expect(readiness.Reason).To(Equal("no endpoints with addresses"))
+
+		endpoints := newObject("v1", "Endpoints", "default", "petclinic", map[string]interface{}{
+			"subsets": []interface{}{
+				map[string]interface{}{"addresses": []interface{}{map[string]interface{}{"ip": "10.0.0.1"}}},
+			},
+		})
+		Expect(getReadiness(ref, service, endpoints).Ready).To(BeTrue())
+	})
+
+	It("uses the state of solo-kit resources", func() {
+		virtualService := func(state interface{}) *unstructured.Unstructured {
+			return newObject("gateway.solo.io/v1", "VirtualService", "gloo-system", "default", map[string]interface{}{
+				"status": map[string]interface{}{"state": state, "reason": "domain conflict"},
+			})
+		}
+		ref := kube.ResourceRef{APIVersion: "gateway.solo.io/v1", Kind: "VirtualService", Name: "default"}
+		Expect(getReadiness(ref, virtualService(int64(0))).Ready).To(BeFalse())
+		Expect(getReadiness(ref, virtualService("Accepted")).Ready).To(BeTrue())
+		readiness := getReadiness(ref, virtualService(int64(2)))
+		Expect(readiness.Failed).To(BeTrue())
+		Expect(readiness.Reason).To(Equal("domain conflict"))
+	})
+
+	It("is ready once other objects exist", func() {
+		Expect(getReadiness(kube.ResourceRef{Kind: "configmaps", Name: "settings"}).Ready).To(BeFalse())
+		Expect(getReadiness(kube.ResourceRef{Kind: "configmap", Name: "settings"}, newObject("v1", "ConfigMap", "gloo-system", "settings", nil)).Ready).To(BeTrue())
+	})
+
+	It("lists objects by kind and label selector", func() {
+		labeled := newObject("apps/v1", "Deployment", "petclinic", "petclinic", nil)
+		labeled.SetLabels(map[string]string{"app": "petclinic"})
+		other := newObject("apps/v1", "Deployment", "petclinic", "other", nil)
+		refs, err := newChecker(labeled, other).ListResources([]string{"deployments", "jobs"}, "petclinic", "app=petclinic")
+		Expect(err).To(BeNil())
+		Expect(refs).To(Equal([]kube.ResourceRef{
+			{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "petclinic", Name: "petclinic"},
+		}))
+	})
+})
@@ -3,8 +3,8 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/log"
 )
 
 //go:generate mockgen -destination ./mocks/command_runner_mock.go github.com/solo-io/valet/pkg/cmd Runner
@@ -21,6 +22,9 @@ import (
 const (
 	Redacted = "REDACTED"
 	Empty    = "EMPTY"
+
+	// How long a request can take, unless the runner is given another timeout (see RequestTimeoutRunner)
+	DefaultRequestTimeout = time.Second
 )
 
 var (
@@ -37,20 +41,125 @@ type Command struct {
 	PrintCommands   bool
 	Redactions      map[string]string
 	SwallowErrorLog bool
+	// Optional, Output returns only stdout, rather than stdout and stderr combined. Stderr is still logged if the
+	// command fails.
+	StdOutOnly bool
+	// Optional, hides sensitive values anywhere in the command when it is shown with ToString
+	Redactor Redactor
+}
+
+// A Redactor hides sensitive values (i.e. secrets) in text before it is shown to users.
+type Redactor interface {
+	Redact(text string) string
 }
 
 type Runner interface {
 	Run(c *Command) error
 	Output(c *Command) (string, error)
 	Stream(c *Command) (*CommandStreamHandler, error)
-	Request(req *http.Request) (string, int, error)
+	Request(req *http.Request) (*HttpResponse, error)
 	Kill(process *os.Process) error
 }
 
-type commandRunner struct{}
+// Runners can implement ContextRunner, so the commands and requests they run are stopped when a context is done
+// (i.e. when a step times out).
+type ContextRunner interface {
+	WithContext(ctx context.Context) Runner
+}
+
+// Returns a runner whose commands and requests are stopped when ctx is done. If the runner doesn't implement
+// ContextRunner, it is returned as is.
+func RunnerWithContext(runner Runner, ctx context.Context) Runner {
+	if contextRunner, ok := runner.(ContextRunner); ok {
+		return contextRunner.WithContext(ctx)
+	}
+	return runner
+}
+
+// Runners can implement LoggingRunner, so the commands they run (and their output) are logged with a scoped logger,
+// i.e. for the step that is running.
+type LoggingRunner interface {
+	WithLogger(logger log.Logger) Runner
+}
+
+// Runners can implement RequestTimeoutRunner, so requests can take longer than DefaultRequestTimeout, i.e. when a
+// step measures how long a response takes.
+type RequestTimeoutRunner interface {
+	WithRequestTimeout(timeout time.Duration) Runner
+}
+
+// Returns a runner whose requests time out after timeout. If the runner doesn't implement RequestTimeoutRunner, it is
+// returned as is.
+func RunnerWithRequestTimeout(runner Runner, timeout time.Duration) Runner {
+	if timeoutRunner, ok := runner.(RequestTimeoutRunner); ok {
+		return timeoutRunner.WithRequestTimeout(timeout)
+	}
+	return runner
+}
+
+type HttpResponse struct {
+	StatusCode int
+	Body       string
+	Headers    http.Header
+}
+
+type commandRunner struct {
+	logger   log.Logger
+	redactor Redactor
+	// Optional, commands and requests are stopped when this is done
+	ctx context.Context
+	// Optional, defaults to DefaultRequestTimeout
+	requestTimeout time.Duration
+}
 
 func DefaultCommandRunner() Runner {
-	return &commandRunner{}
+	return NewCommandRunner(log.Default())
+}
+
+func NewCommandRunner(logger log.Logger) Runner {
+	return &commandRunner{
+		logger: logger,
+	}
+}
+
+// Create a runner that hides sensitive values in the input and output of commands it logs when they fail.
+func NewRedactingCommandRunner(logger log.Logger, redactor Redactor) Runner {
+	return &commandRunner{
+		logger:   logger,
+		redactor: redactor,
+	}
+}
+
+func (r *commandRunner) WithContext(ctx context.Context) Runner {
+	scoped := *r
+	scoped.ctx = ctx
+	return &scoped
+}
+
+func (r *commandRunner) WithLogger(logger log.Logger) Runner {
+	scoped := *r
+	scoped.logger = logger
+	return &scoped
+}
+
+func (r *commandRunner) WithRequestTimeout(timeout time.Duration) Runner {
+	scoped := *r
+	scoped.requestTimeout = timeout
+	return &scoped
+}
+
+func (r *commandRunner) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+func (r *commandRunner) redact(text string) string {
+	if r.redactor == nil {
+		return text
+	}
+	return r.redactor.Redact(text)
 }
 
 func (r *commandRunner) Kill(process *os.Process) error {
@@ -63,18 +172,29 @@ func (r *commandRunner) Run(c *Command) error {
 }
 
 func (r *commandRunner) Output(c *Command) (string, error) {
-	cmd := exec.Command(c.Name, c.Args...)
+	cmd := exec.CommandContext(r.context(), c.Name, c.Args...)
 	cmd.Stdin = strings.NewReader(c.StdIn)
-	bytes, err := cmd.CombinedOutput()
+	var out []byte
+	var err error
+	stderr := &bytes.Buffer{}
+	if c.StdOutOnly {
+		cmd.Stderr = stderr
+		out, err = cmd.Output()
+	} else {
+		out, err = cmd.CombinedOutput()
+	}
 	if err != nil {
 		if !c.SwallowErrorLog {
-			Stderr().Println("Error running command: %s", err.Error())
-			Stderr().Println("STDIN: %s", c.StdIn)
-			Stderr().Println(string(bytes))
+			r.logger.Errorf("Error running command: %s", err.Error())
+			r.logger.Errorf("STDIN: %s", r.redact(c.StdIn))
+			r.logger.Errorf("%s", r.redact(string(out)))
+			if stderr.Len() > 0 {
+				r.logger.Errorf("%s", r.redact(stderr.String()))
+			}
 		}
 		err = CommandError(err)
 	}
-	return string(bytes), err
+	return string(out), err
 }
 
 type CommandStreamHandler struct {
@@ -82,28 +202,42 @@ type CommandStreamHandler struct {
 	Stdout   io.Reader
 	Stderr   io.Reader
 	Process  *exec.Cmd
+	// Optional, defaults to log.Default()
+	Logger log.Logger
+	// Optional, hides sensitive values in the output that is logged
+	Redactor Redactor
 }
 
 func (c *CommandStreamHandler) StreamHelper(inputErr error) error {
+	logger := c.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	redact := func(text string) string {
+		if c.Redactor == nil {
+			return text
+		}
+		return c.Redactor.Redact(text)
+	}
 	go func() {
 		stdoutScanner := bufio.NewScanner(c.Stdout)
 		for stdoutScanner.Scan() {
-			Stdout().Println(stdoutScanner.Text())
+			logger.Infof("%s", redact(stdoutScanner.Text()))
 		}
 		if err := stdoutScanner.Err(); err != nil {
-			Stderr().Println("reading stdout from current command context:", err)
+			logger.Errorf("reading stdout from current command context: %s", err.Error())
 		}
 	}()
 	stderr, _ := ioutil.ReadAll(c.Stderr)
 	if err := c.WaitFunc(); err != nil {
-		Stderr().Println(fmt.Sprintf("%s\n", stderr))
+		logger.Errorf("%s\n", redact(string(stderr)))
 		return inputErr
 	}
 	return nil
 }
 
 func (r *commandRunner) Stream(c *Command) (*CommandStreamHandler, error) {
-	cmd := exec.Command(c.Name, c.Args...)
+	cmd := exec.CommandContext(r.context(), c.Name, c.Args...)
 	cmd.Stdin = strings.NewReader(c.StdIn)
 	outReader, err := cmd.StdoutPipe()
 	if err != nil {
@@ -121,32 +255,42 @@ func (r *commandRunner) Stream(c *Command) (*CommandStreamHandler, error) {
 		WaitFunc: func() error {
 			return cmd.Wait()
 		},
-		Stdout: outReader,
-		Stderr: errReader,
-		Process: cmd,
+		Stdout:   outReader,
+		Stderr:   errReader,
+		Process:  cmd,
+		Logger:   r.logger,
+		Redactor: r.redactor,
 	}, nil
 }
 
-func (c *commandRunner) Request(req *http.Request) (string, int, error) {
+func (c *commandRunner) Request(req *http.Request) (*HttpResponse, error) {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
+	timeout := c.requestTimeout
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
 	httpClient := &http.Client{
-		Timeout: time.Second * 1,
+		Timeout: timeout,
 		Transport: tr,
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := httpClient.Do(req.WithContext(c.context()))
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 	p := new(bytes.Buffer)
 	_, err = io.Copy(p, resp.Body)
 	defer resp.Body.Close()
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
-	return p.String(), resp.StatusCode, nil
+	return &HttpResponse{
+		StatusCode: resp.StatusCode,
+		Body:       p.String(),
+		Headers:    resp.Header,
+	}, nil
 }
 
 func (c *Command) ToString() string {
@@ -168,6 +312,9 @@ func (c *Command) ToString() string {
 		parts = append(parts, processed)
 	}
 	command := strings.Join(parts, " ")
+	if c.Redactor != nil {
+		command = c.Redactor.Redact(command)
+	}
 	return command
 }
 
@@ -181,6 +328,11 @@ func (c *Command) With(args ...string) *Command {
 	return c
 }
 
+func (c *Command) WithRedactor(redactor Redactor) *Command {
+	c.Redactor = redactor
+	return c
+}
+
 func (c *Command) Redact(unredacted, redacted string) *Command {
 	if c.Redactions == nil {
 		c.Redactions = make(map[string]string)
@@ -43,10 +43,18 @@ func (k *Kubectl) Context(context string) *Kubectl {
 	return k.With("--context", context)
 }
 
+func (k *Kubectl) Kubeconfig(kubeconfig string) *Kubectl {
+	return k.With("--kubeconfig", kubeconfig)
+}
+
 func (k *Kubectl) DryRun() *Kubectl {
 	return k.With("--dry-run")
 }
 
+func (k *Kubectl) ServerDryRun() *Kubectl {
+	return k.With("--dry-run=server")
+}
+
 func (k *Kubectl) OutYaml() *Kubectl {
 	return k.With("-oyaml")
 }
@@ -83,6 +91,11 @@ func (k *Kubectl) Redact(unredacted, redacted string) *Kubectl {
 	return k
 }
 
+func (k *Kubectl) WithRedactor(redactor Redactor) *Kubectl {
+	k.cmd.Redactor = redactor
+	return k
+}
+
 func (k *Kubectl) UseContext(context string) *Kubectl {
 	return k.With("config", "use-context", context)
 }
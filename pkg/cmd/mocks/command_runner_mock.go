@@ -65,13 +65,12 @@ func (mr *MockRunnerMockRecorder) Output(arg0 interface{}) *gomock.Call {
 }
 
 // Request mocks base method
-func (m *MockRunner) Request(arg0 *http.Request) (string, int, error) {
+func (m *MockRunner) Request(arg0 *http.Request) (*cmd.HttpResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Request", arg0)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret0, _ := ret[0].(*cmd.HttpResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Request indicates an expected call of Request
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/solo-io/valet/pkg/cmd (interfaces: Prompter)
+
+// Package mock_cmd is a generated GoMock package.
+package mock_cmd
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	cmd "github.com/solo-io/valet/pkg/cmd"
+	reflect "reflect"
+)
+
+// MockPrompter is a mock of Prompter interface
+type MockPrompter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrompterMockRecorder
+}
+
+// MockPrompterMockRecorder is the mock recorder for MockPrompter
+type MockPrompterMockRecorder struct {
+	mock *MockPrompter
+}
+
+// NewMockPrompter creates a new mock instance
+func NewMockPrompter(ctrl *gomock.Controller) *MockPrompter {
+	mock := &MockPrompter{ctrl: ctrl}
+	mock.recorder = &MockPrompterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPrompter) EXPECT() *MockPrompterMockRecorder {
+	return m.recorder
+}
+
+// Prompt mocks base method
+func (m *MockPrompter) Prompt(arg0 string, arg1 ...cmd.PromptChoice) (cmd.PromptChoice, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Prompt", varargs...)
+	ret0, _ := ret[0].(cmd.PromptChoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prompt indicates an expected call of Prompt
+func (mr *MockPrompterMockRecorder) Prompt(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prompt", reflect.TypeOf((*MockPrompter)(nil).Prompt), varargs...)
+}
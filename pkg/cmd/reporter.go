@@ -5,47 +5,75 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"time"
+	"strings"
+
+	"github.com/solo-io/valet/pkg/log"
 )
 
-type Reporter interface {
-	Print(format string, args ...interface{})
-	Println(format string, args ...interface{})
-}
+//go:generate mockgen -destination ./mocks/prompter_mock.go github.com/solo-io/valet/pkg/cmd Prompter
 
-func Stdout() Reporter {
-	return &Printer{
-		w: os.Stdout,
-	}
+type PromptChoice string
+
+const (
+	ContinueChoice PromptChoice = "continue"
+	SkipChoice     PromptChoice = "skip"
+	RetryChoice    PromptChoice = "retry"
+	AbortChoice    PromptChoice = "abort"
+)
+
+// A Prompter waits for the presenter of an interactive workflow to decide what to do next.
+type Prompter interface {
+	// Show the message and wait for the presenter to pick one of the choices. Pressing enter picks the first one.
+	Prompt(message string, choices ...PromptChoice) (PromptChoice, error)
 }
 
-func Stderr() Reporter {
-	return &Printer{
-		w: os.Stderr,
+func PromptPressAnyKeyToContinue(logger log.Logger, nextStep string) error {
+	if nextStep == "" {
+		return nil
 	}
+	_, err := NewPrompter(logger, os.Stdin).Prompt(fmt.Sprintf("Next: %s.", nextStep), ContinueChoice)
+	return err
 }
 
-type Printer struct {
-	w io.Writer
+// Returns a Prompter that reads the presenter's choices from in (usually os.Stdin), one per line. A choice is
+// picked by typing its first letter.
+func NewPrompter(logger log.Logger, in io.Reader) *prompter {
+	return &prompter{
+		logger: logger,
+		reader: bufio.NewReader(in),
+	}
 }
 
-func (p *Printer) Print(format string, args ...interface{}) {
-	_, _ = fmt.Fprintf(p.w, format, args...)
-}
+var _ Prompter = new(prompter)
 
-func (p *Printer) Println(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	_, _ = fmt.Fprintf(p.w, "[%s] %s\n", time.Now().Format(time.RFC3339), msg)
+type prompter struct {
+	logger log.Logger
+	reader *bufio.Reader
 }
 
-const fallbackNextStep = "Press any key to continue..."
-
-func PromptPressAnyKeyToContinue(nextStep string) error {
-	if nextStep == "" {
-		return nil
+func (p *prompter) Prompt(message string, choices ...PromptChoice) (PromptChoice, error) {
+	if len(choices) == 0 {
+		return "", nil
+	}
+	var options []string
+	options = append(options, fmt.Sprintf("enter to %s", choices[0]))
+	for _, choice := range choices[1:] {
+		options = append(options, fmt.Sprintf("%s to %s", string(choice)[:1], choice))
+	}
+	for {
+		p.logger.Infof("%s [%s]", message, strings.Join(options, ", "))
+		line, err := p.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || len(choices) == 1 {
+			return choices[0], nil
+		}
+		for _, choice := range choices {
+			if line == string(choice) || line == string(choice)[:1] {
+				return choice, nil
+			}
+		}
 	}
-	Stdout().Println("Next: %s. %s", nextStep, fallbackNextStep)
-	reader := bufio.NewReader(os.Stdin)
-	_, _, err := reader.ReadRune()
-	return err
 }
@@ -0,0 +1,185 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+)
+
+var (
+	WriteBundleError = func(path string, err error) error {
+		return errors.Wrapf(err, "Error writing diagnostics to %s", path)
+	}
+)
+
+// A helm release that the workflow installed.
+type Release struct {
+	Name      string
+	Namespace string
+}
+
+// A Bundle records the namespaces and helm releases that a workflow touches, so that when a step fails, the state of
+// the cluster can be collected into a directory, or a tarball if the path ends with .tar.gz or .tgz. Each failure is
+// written to its own directory in the bundle, named after the step. A tarball is written once, by Close, when the
+// workflow finishes.
+//
+// A bundle is safe to use from parallel steps.
+type Bundle struct {
+	Path string
+
+	lock       sync.Mutex
+	namespaces []string
+	releases   []Release
+	// The files of every failure, which are written by Close when the bundle is a tarball
+	files map[string]string
+	dirs  map[string]bool
+}
+
+func NewBundle(path string) *Bundle {
+	return &Bundle{
+		Path:  path,
+		files: make(map[string]string),
+		dirs:  make(map[string]bool),
+	}
+}
+
+// Returns true if the bundle is written as a gzipped tarball, rather than to a directory.
+func (b *Bundle) IsTarball() bool {
+	return strings.HasSuffix(b.Path, ".tar.gz") || strings.HasSuffix(b.Path, ".tgz")
+}
+
+// Record a namespace that the workflow touched. Empty and repeated namespaces are ignored.
+func (b *Bundle) AddNamespace(namespace string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if namespace == "" {
+		return
+	}
+	for _, existing := range b.namespaces {
+		if existing == namespace {
+			return
+		}
+	}
+	b.namespaces = append(b.namespaces, namespace)
+}
+
+// Record a helm release that the workflow installed. Repeated releases are ignored.
+func (b *Bundle) AddRelease(name, namespace string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	release := Release{Name: name, Namespace: namespace}
+	for _, existing := range b.releases {
+		if existing == release {
+			return
+		}
+	}
+	b.releases = append(b.releases, release)
+}
+
+// Returns the namespaces that the workflow touched, sorted by name.
+func (b *Bundle) GetNamespaces() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	namespaces := append([]string{}, b.namespaces...)
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Returns the helm releases that the workflow installed, in the order they were installed.
+func (b *Bundle) GetReleases() []Release {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]Release{}, b.releases...)
+}
+
+// Write the files collected for a failure (by relative path) into a directory of the bundle, named after the step
+// (i.e. "steps-3"). If the directory was already used by an earlier failure (i.e. in another combination of a matrix),
+// a number is appended. Returns the name of the directory. For a tarball, the files are held until Close.
+func (b *Bundle) Write(name string, files map[string]string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	dir := name
+	for i := 2; b.dirs[dir]; i++ {
+		dir = fmt.Sprintf("%s-%d", name, i)
+	}
+	b.dirs[dir] = true
+	if !b.IsTarball() {
+		for file, contents := range files {
+			target := filepath.Join(b.Path, dir, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", WriteBundleError(b.Path, err)
+			}
+			if err := ioutil.WriteFile(target, []byte(contents), 0644); err != nil {
+				return "", WriteBundleError(b.Path, err)
+			}
+		}
+		return dir, nil
+	}
+	for file, contents := range files {
+		b.files[path.Join(dir, file)] = contents
+	}
+	return dir, nil
+}
+
+// Write the tarball with the files of every failure. Does nothing if the bundle is a directory, or no step failed.
+func (b *Bundle) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.IsTarball() || len(b.files) == 0 {
+		return nil
+	}
+	if err := b.writeTarball(); err != nil {
+		return WriteBundleError(b.Path, err)
+	}
+	return nil
+}
+
+func (b *Bundle) writeTarball() error {
+	var names []string
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range names {
+		contents := []byte(b.files[name])
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(contents)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(contents); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(b.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(b.Path, buf.Bytes(), 0644)
+}
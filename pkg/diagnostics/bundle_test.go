@@ -0,0 +1,100 @@
+package diagnostics_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/diagnostics"
+)
+
+var _ = Describe("bundle", func() {
+
+	var (
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "valet-diagnostics")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("records each namespace and release once", func() {
+		bundle := diagnostics.NewBundle(dir)
+		bundle.AddNamespace("petclinic")
+		bundle.AddNamespace("")
+		bundle.AddNamespace("default")
+		bundle.AddNamespace("petclinic")
+		bundle.AddRelease("gloo", "gloo-system")
+		bundle.AddRelease("gloo", "gloo-system")
+		Expect(bundle.GetNamespaces()).To(Equal([]string{"default", "petclinic"}))
+		Expect(bundle.GetReleases()).To(Equal([]diagnostics.Release{{Name: "gloo", Namespace: "gloo-system"}}))
+	})
+
+	It("writes each failure to its own directory", func() {
+		bundle := diagnostics.NewBundle(filepath.Join(dir, "bundle"))
+		Expect(bundle.IsTarball()).To(BeFalse())
+		name, err := bundle.Write("steps-3", map[string]string{"error.txt": "curl failed", "namespaces/default/events.txt": "BackOff"})
+		Expect(err).To(BeNil())
+		Expect(name).To(Equal("steps-3"))
+		name, err = bundle.Write("steps-3", map[string]string{"error.txt": "curl failed again"})
+		Expect(err).To(BeNil())
+		Expect(name).To(Equal("steps-3-2"))
+
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "bundle", "steps-3", "namespaces", "default", "events.txt"))
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal("BackOff"))
+		contents, err = ioutil.ReadFile(filepath.Join(dir, "bundle", "steps-3-2", "error.txt"))
+		Expect(err).To(BeNil())
+		Expect(string(contents)).To(Equal("curl failed again"))
+	})
+
+	It("doesn't write a tarball if no step failed", func() {
+		path := filepath.Join(dir, "diagnostics.tar.gz")
+		Expect(diagnostics.NewBundle(path).Close()).To(BeNil())
+		Expect(path).NotTo(BeAnExistingFile())
+	})
+
+	It("writes every failure to a tarball", func() {
+		path := filepath.Join(dir, "diagnostics.tar.gz")
+		bundle := diagnostics.NewBundle(path)
+		Expect(bundle.IsTarball()).To(BeTrue())
+		_, err := bundle.Write("setup-0", map[string]string{"error.txt": "install failed"})
+		Expect(err).To(BeNil())
+		_, err = bundle.Write("steps-3", map[string]string{"error.txt": "curl failed"})
+		Expect(err).To(BeNil())
+		// The tarball is written once, when the bundle is closed
+		Expect(path).NotTo(BeAnExistingFile())
+		Expect(bundle.Close()).To(BeNil())
+
+		file, err := os.Open(path)
+		Expect(err).To(BeNil())
+		defer file.Close()
+		gz, err := gzip.NewReader(file)
+		Expect(err).To(BeNil())
+		tr := tar.NewReader(gz)
+		files := make(map[string]string)
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			contents, err := ioutil.ReadAll(tr)
+			Expect(err).To(BeNil())
+			files[header.Name] = string(contents)
+		}
+		Expect(files).To(Equal(map[string]string{
+			"setup-0/error.txt": "install failed",
+			"steps-3/error.txt": "curl failed",
+		}))
+	})
+})
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Create a logger that holds messages until Flush is called, and then writes them to logger in order. This keeps
+// the output of work that runs concurrently (i.e. parallel steps) from being interleaved.
+func NewBuffered(logger Logger) *Buffered {
+	return &Buffered{
+		logger: logger,
+		buffer: &buffer{},
+	}
+}
+
+var _ Logger = new(Buffered)
+
+type Buffered struct {
+	logger Logger
+	buffer *buffer
+}
+
+type buffer struct {
+	lock    sync.Mutex
+	entries []entry
+}
+
+type entry struct {
+	level   Level
+	logger  Logger
+	message string
+}
+
+func (b *Buffered) Debugf(format string, args ...interface{}) {
+	b.add(DebugLevel, format, args...)
+}
+
+func (b *Buffered) Infof(format string, args ...interface{}) {
+	b.add(InfoLevel, format, args...)
+}
+
+func (b *Buffered) Warnf(format string, args ...interface{}) {
+	b.add(WarnLevel, format, args...)
+}
+
+func (b *Buffered) Errorf(format string, args ...interface{}) {
+	b.add(ErrorLevel, format, args...)
+}
+
+// Messages from the returned logger are held in the same buffer.
+func (b *Buffered) With(key string, value interface{}) Logger {
+	return &Buffered{
+		logger: b.logger.With(key, value),
+		buffer: b.buffer,
+	}
+}
+
+// Write the messages held so far, and clear the buffer.
+func (b *Buffered) Flush() {
+	b.buffer.lock.Lock()
+	entries := b.buffer.entries
+	b.buffer.entries = nil
+	b.buffer.lock.Unlock()
+	for _, e := range entries {
+		switch e.level {
+		case DebugLevel:
+			e.logger.Debugf("%s", e.message)
+		case InfoLevel:
+			e.logger.Infof("%s", e.message)
+		case WarnLevel:
+			e.logger.Warnf("%s", e.message)
+		case ErrorLevel:
+			e.logger.Errorf("%s", e.message)
+		}
+	}
+}
+
+func (b *Buffered) add(level Level, format string, args ...interface{}) {
+	b.buffer.lock.Lock()
+	defer b.buffer.lock.Unlock()
+	b.buffer.entries = append(b.buffer.entries, entry{
+		level:   level,
+		logger:  b.logger,
+		message: fmt.Sprintf(format, args...),
+	})
+}
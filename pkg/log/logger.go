@@ -0,0 +1,180 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+)
+
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var (
+	levelNames = map[Level]string{
+		DebugLevel: "debug",
+		InfoLevel:  "info",
+		WarnLevel:  "warn",
+		ErrorLevel: "error",
+	}
+
+	UnknownLevelError = func(level string) error {
+		return errors.Errorf("Unknown log level %s, must be one of [debug, info, warn, error]", level)
+	}
+)
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+func ParseLevel(level string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(name, level) {
+			return l, nil
+		}
+	}
+	return InfoLevel, UnknownLevelError(level)
+}
+
+// A log.Logger is used by workflow steps and clients to report progress. Messages are formatted with fmt.Sprintf.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	// Returns a logger that adds the key and value as a field on every message, i.e. to scope output to a step
+	With(key string, value interface{}) Logger
+}
+
+type Options struct {
+	// Destination for debug and info messages (default os.Stdout)
+	Out io.Writer
+	// Destination for warn and error messages (default os.Stderr)
+	Err io.Writer
+	// Messages below this level are dropped (default info)
+	Level Level
+	// Write each message as a json object, rather than timestamped text
+	Json bool
+}
+
+// Create a logger that writes timestamped text for info and above to stdout and stderr
+func Default() Logger {
+	return New(Options{Level: InfoLevel})
+}
+
+// Create a logger that drops every message
+func Discard() Logger {
+	return New(Options{Out: ioutil.Discard, Err: ioutil.Discard})
+}
+
+func New(opts Options) Logger {
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	if opts.Err == nil {
+		opts.Err = os.Stderr
+	}
+	return &logger{
+		opts: opts,
+		lock: &sync.Mutex{},
+	}
+}
+
+var _ Logger = new(logger)
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+type logger struct {
+	opts   Options
+	fields []field
+	lock   *sync.Mutex
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	l.log(DebugLevel, format, args...)
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	l.log(InfoLevel, format, args...)
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.log(WarnLevel, format, args...)
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	l.log(ErrorLevel, format, args...)
+}
+
+func (l *logger) With(key string, value interface{}) Logger {
+	fields := make([]field, 0, len(l.fields)+1)
+	for _, f := range l.fields {
+		if f.key != key {
+			fields = append(fields, f)
+		}
+	}
+	return &logger{
+		opts:   l.opts,
+		fields: append(fields, field{key: key, value: value}),
+		lock:   l.lock,
+	}
+}
+
+func (l *logger) log(level Level, format string, args ...interface{}) {
+	if level < l.opts.Level {
+		return
+	}
+	w := l.opts.Out
+	if level >= WarnLevel {
+		w = l.opts.Err
+	}
+	msg := fmt.Sprintf(format, args...)
+	now := time.Now()
+	var line string
+	if l.opts.Json {
+		line = l.jsonLine(now, level, msg)
+	} else {
+		line = l.textLine(now, msg)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	_, _ = fmt.Fprintln(w, line)
+}
+
+func (l *logger) textLine(now time.Time, msg string) string {
+	line := fmt.Sprintf("[%s] %s", now.Format(time.RFC3339), msg)
+	for _, f := range l.fields {
+		line = fmt.Sprintf("%s %s=%v", line, f.key, f.value)
+	}
+	return line
+}
+
+func (l *logger) jsonLine(now time.Time, level Level, msg string) string {
+	entry := make(map[string]interface{})
+	for _, f := range l.fields {
+		entry[f.key] = f.value
+	}
+	entry["time"] = now.Format(time.RFC3339)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Sprintf(`{"level":"error","msg":%q}`, err.Error())
+	}
+	return string(b)
+}
@@ -0,0 +1,97 @@
+package log_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/log"
+)
+
+var _ = Describe("logger", func() {
+
+	var (
+		out, errOut *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		errOut = &bytes.Buffer{}
+	})
+
+	It("writes timestamped text with fields", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut})
+		logger.With("step", "deploy").Infof("Applying %s", "petclinic.yaml")
+		Expect(out.String()).To(MatchRegexp(`^\[.*\] Applying petclinic.yaml step=deploy\n$`))
+		Expect(errOut.String()).To(BeEmpty())
+	})
+
+	It("writes warnings and errors to the error writer", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut})
+		logger.Warnf("careful")
+		logger.Errorf("failed")
+		Expect(out.String()).To(BeEmpty())
+		Expect(strings.Count(errOut.String(), "\n")).To(Equal(2))
+	})
+
+	It("drops messages below the level", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut, Level: log.WarnLevel})
+		logger.Debugf("debug")
+		logger.Infof("info")
+		Expect(out.String()).To(BeEmpty())
+		logger = log.New(log.Options{Out: out, Err: errOut, Level: log.DebugLevel})
+		logger.Debugf("debug")
+		Expect(out.String()).To(ContainSubstring("debug"))
+	})
+
+	It("writes json", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut, Json: true})
+		logger.With("step", "first").With("step", "deploy").Infof("Applying")
+		entry := make(map[string]interface{})
+		Expect(json.Unmarshal(out.Bytes(), &entry)).To(BeNil())
+		Expect(entry["msg"]).To(Equal("Applying"))
+		Expect(entry["level"]).To(Equal("info"))
+		Expect(entry["step"]).To(Equal("deploy"))
+		Expect(entry).To(HaveKey("time"))
+	})
+
+	It("logs each line written to a writer", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut})
+		writer := log.NewWriter(logger.With("step", "install"))
+		_, err := writer.Write([]byte("first\nsec"))
+		Expect(err).To(BeNil())
+		_, err = writer.Write([]byte("ond\n"))
+		Expect(err).To(BeNil())
+		Expect(out.String()).To(MatchRegexp(`^\[.*\] first step=install\n\[.*\] second step=install\n$`))
+	})
+
+	It("holds buffered messages until they are flushed", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut})
+		buffered := log.NewBuffered(logger)
+		buffered.With("branch", "a").Infof("first")
+		buffered.Warnf("second")
+		Expect(out.String()).To(BeEmpty())
+		buffered.Flush()
+		Expect(out.String()).To(MatchRegexp(`^\[.*\] first branch=a\n$`))
+		Expect(errOut.String()).To(ContainSubstring("second"))
+	})
+
+	It("redacts messages and string fields", func() {
+		logger := log.New(log.Options{Out: out, Err: errOut})
+		redacting := log.NewRedacting(logger, func(s string) string {
+			return strings.ReplaceAll(s, "hunter2", "REDACTED")
+		})
+		redacting.With("token", "hunter2").With("attempt", 1).Infof("logging in with %s", "hunter2")
+		Expect(out.String()).To(MatchRegexp(`^\[.*\] logging in with REDACTED token=REDACTED attempt=1\n$`))
+	})
+
+	It("parses levels", func() {
+		level, err := log.ParseLevel("WARN")
+		Expect(err).To(BeNil())
+		Expect(level).To(Equal(log.WarnLevel))
+		_, err = log.ParseLevel("verbose")
+		Expect(err).To(HaveOccurred())
+	})
+})
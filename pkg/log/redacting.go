@@ -0,0 +1,45 @@
+package log
+
+import "fmt"
+
+// Create a logger that passes every message, and the value of every string field, through redact before writing
+// it to logger, i.e. to hide secrets.
+func NewRedacting(logger Logger, redact func(string) string) Logger {
+	return &redacting{
+		logger: logger,
+		redact: redact,
+	}
+}
+
+var _ Logger = new(redacting)
+
+type redacting struct {
+	logger Logger
+	redact func(string) string
+}
+
+func (r *redacting) Debugf(format string, args ...interface{}) {
+	r.logger.Debugf("%s", r.redact(fmt.Sprintf(format, args...)))
+}
+
+func (r *redacting) Infof(format string, args ...interface{}) {
+	r.logger.Infof("%s", r.redact(fmt.Sprintf(format, args...)))
+}
+
+func (r *redacting) Warnf(format string, args ...interface{}) {
+	r.logger.Warnf("%s", r.redact(fmt.Sprintf(format, args...)))
+}
+
+func (r *redacting) Errorf(format string, args ...interface{}) {
+	r.logger.Errorf("%s", r.redact(fmt.Sprintf(format, args...)))
+}
+
+func (r *redacting) With(key string, value interface{}) Logger {
+	if str, ok := value.(string); ok {
+		value = r.redact(str)
+	}
+	return &redacting{
+		logger: r.logger.With(key, value),
+		redact: r.redact,
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Create a writer that logs each line written to it at info level, i.e. for the output of a library that writes to
+// an io.Writer rather than a Logger. A line without a trailing newline is held until the rest of it is written.
+func NewWriter(logger Logger) io.Writer {
+	return &writer{
+		logger: logger,
+	}
+}
+
+type writer struct {
+	lock    sync.Mutex
+	logger  Logger
+	partial []byte
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.Infof("%s", string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+	"sync"
+
+	cmd_runner "github.com/solo-io/valet/pkg/cmd"
+)
+
+// Values that are only suspected to be sensitive (see AddSuspected) aren't redacted if they are shorter than this.
+// Short values, like "true" or "admin", are likely to appear in output that has nothing to do with the secret, so
+// redacting them would hide more than it protects.
+const MinSecretLength = 6
+
+var _ cmd_runner.Redactor = new(Redactor)
+
+// A Redactor replaces sensitive values in text that is shown to users, such as step descriptions, logs, commands,
+// errors and reports. Each workflow context has its own, so the secrets of one workflow aren't redacted from another.
+type Redactor struct {
+	lock    sync.RWMutex
+	secrets map[string]bool
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets: make(map[string]bool),
+	}
+}
+
+// Mark a value from an explicitly sensitive source (i.e. a vault: value) as sensitive, however short it is. Surrounding
+// whitespace (i.e. the trailing newline of a command's output) is ignored. The base64 encoding of the value is
+// sensitive too, since that is how it appears in the data of a kube secret.
+func (r *Redactor) Add(secret string) {
+	trimmed := strings.TrimSpace(secret)
+	if trimmed == "" {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.secrets[trimmed] = true
+	r.secrets[base64.StdEncoding.EncodeToString([]byte(secret))] = true
+	r.secrets[base64.StdEncoding.EncodeToString([]byte(trimmed))] = true
+}
+
+// Mark a value that may be sensitive as sensitive, i.e. an entry of a kube secret, which can also hold settings like
+// "true". Unlike Add, values shorter than MinSecretLength are skipped.
+func (r *Redactor) AddSuspected(value string) {
+	if len(strings.TrimSpace(value)) < MinSecretLength {
+		return
+	}
+	r.Add(value)
+}
+
+// Returns text with every sensitive value replaced by REDACTED.
+func (r *Redactor) Redact(text string) string {
+	r.lock.RLock()
+	secrets := make([]string, 0, len(r.secrets))
+	for secret := range r.secrets {
+		secrets = append(secrets, secret)
+	}
+	r.lock.RUnlock()
+	// Replace longer secrets first, in case one secret contains another
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, cmd_runner.Redacted)
+	}
+	return text
+}
+
+// Returns an error with the sensitive values in its message redacted, or err if its message has none. The redacted
+// error wraps err, so errors.Is and errors.As still see the original error.
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	redacted := r.Redact(err.Error())
+	if redacted == err.Error() {
+		return err
+	}
+	return &redactedError{
+		err:     err,
+		message: redacted,
+	}
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
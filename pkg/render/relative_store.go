@@ -0,0 +1,83 @@
+package render
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Resolve a relative path against dir, which may be a local directory or a url. Absolute paths, urls, and paths
+// that start with ~ or an environment variable are returned as is.
+func ResolvePath(dir, toResolve string) string {
+	if dir == "" || toResolve == "" || isRemote(toResolve) || filepath.IsAbs(toResolve) ||
+		strings.HasPrefix(toResolve, "~") || strings.HasPrefix(toResolve, "$") {
+		return toResolve
+	}
+	if isRemote(dir) {
+		base, err := url.Parse(dir)
+		if err != nil {
+			return toResolve
+		}
+		base.Path = path.Join(base.Path, toResolve)
+		return base.String()
+	}
+	return filepath.Join(dir, toResolve)
+}
+
+// Return the directory of a local path or url.
+func Dir(toResolve string) string {
+	if isRemote(toResolve) {
+		parsed, err := url.Parse(toResolve)
+		if err != nil {
+			return ""
+		}
+		parsed.Path = path.Dir(parsed.Path)
+		return parsed.String()
+	}
+	return filepath.Dir(toResolve)
+}
+
+func isRemote(toTest string) bool {
+	parsed, err := url.ParseRequestURI(toTest)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https")
+}
+
+// Returns a FileStore that resolves relative paths against dir, i.e. the directory of an included workflow.
+func NewRelativeFileStore(store FileStore, dir string) FileStore {
+	// dir is already resolved, so it replaces the directory of a relative store instead of being nested in it
+	if relative, ok := store.(*relativeFileStore); ok {
+		store = relative.store
+	}
+	return &relativeFileStore{
+		store: store,
+		dir:   dir,
+	}
+}
+
+var _ FileStore = new(relativeFileStore)
+
+type relativeFileStore struct {
+	store FileStore
+	dir   string
+}
+
+func (r *relativeFileStore) Load(path string) (string, error) {
+	return r.store.Load(ResolvePath(r.dir, path))
+}
+
+func (r *relativeFileStore) LoadYaml(path string, i interface{}) error {
+	return r.store.LoadYaml(ResolvePath(r.dir, path), i)
+}
+
+func (r *relativeFileStore) Save(path, contents string) error {
+	return r.store.Save(ResolvePath(r.dir, path), contents)
+}
+
+func (r *relativeFileStore) SaveYaml(path string, i interface{}) error {
+	return r.store.SaveYaml(ResolvePath(r.dir, path), i)
+}
+
+func (r *relativeFileStore) Exists(path string) (bool, error) {
+	return r.store.Exists(ResolvePath(r.dir, path))
+}
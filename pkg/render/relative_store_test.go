@@ -0,0 +1,32 @@
+package render_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ = Describe("relative paths", func() {
+
+	It("resolves relative paths against a directory", func() {
+		Expect(render.ResolvePath("common", "apply.yaml")).To(Equal("common/apply.yaml"))
+		Expect(render.ResolvePath("common", "../apply.yaml")).To(Equal("apply.yaml"))
+		Expect(render.ResolvePath("", "apply.yaml")).To(Equal("apply.yaml"))
+	})
+
+	It("resolves relative paths against a url", func() {
+		Expect(render.ResolvePath("https://example.com/workflows", "apply.yaml")).To(Equal("https://example.com/workflows/apply.yaml"))
+	})
+
+	It("doesn't change absolute paths, urls, or paths with env vars", func() {
+		Expect(render.ResolvePath("common", "/tmp/apply.yaml")).To(Equal("/tmp/apply.yaml"))
+		Expect(render.ResolvePath("common", "https://example.com/apply.yaml")).To(Equal("https://example.com/apply.yaml"))
+		Expect(render.ResolvePath("common", "$HOME/apply.yaml")).To(Equal("$HOME/apply.yaml"))
+		Expect(render.ResolvePath("common", "~/apply.yaml")).To(Equal("~/apply.yaml"))
+	})
+
+	It("gets the directory of a path or url", func() {
+		Expect(render.Dir("common/workflow.yaml")).To(Equal("common"))
+		Expect(render.Dir("https://example.com/workflows/workflow.yaml")).To(Equal("https://example.com/workflows"))
+	})
+})
@@ -0,0 +1,326 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	errors "github.com/rotisserie/eris"
+	cmd_runner "github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
+)
+
+const (
+	VaultPrefix  = "vault:"
+	SopsPrefix   = "sops:"
+	AgePrefix    = "age:"
+	SecretPrefix = "secret:"
+
+	VaultAddressEnv = "VAULT_ADDR"
+	VaultTokenEnv   = "VAULT_TOKEN"
+	AgeKeyFileEnv   = "SOPS_AGE_KEY_FILE"
+	// How long a request to vault can take, unless the source has its own client
+	DefaultVaultTimeout = 30 * time.Second
+	// The default location of age keys used by sops, which age values share
+	DefaultAgeKeyFile = "~/.config/sops/age/keys.txt"
+)
+
+var (
+	SecretSourceNotConfiguredError = func(prefix string) error {
+		return errors.Errorf("No secret source is configured for %s values", prefix)
+	}
+	InvalidSecretRefError = func(ref, format string) error {
+		return errors.Errorf("Invalid secret reference %s, must be of the form %s", ref, format)
+	}
+	VaultRequestError = func(path string, statusCode int, body string) error {
+		return errors.Errorf("Reading %s from vault returned status %d: %s", path, statusCode, body)
+	}
+	SecretFieldNotFoundError = func(path, field string) error {
+		return errors.Errorf("Secret %s has no field %s", path, field)
+	}
+
+	// The sources that are registered with RegisterSecretSource
+	defaultSecretSources = &SecretSources{
+		sources: map[string]SecretSource{
+			VaultPrefix: &VaultSource{},
+			SopsPrefix:  &SopsSource{},
+			AgePrefix:   &AgeSource{},
+			// Reading secrets from the cluster needs a kube client, so each workflow context adds this source
+			SecretPrefix: nil,
+		},
+	}
+)
+
+// A SecretSource resolves values with a prefix, i.e. "vault:secret/data/gloo#license-key". Secrets are sensitive,
+// so they are redacted from descriptions and logs.
+type SecretSource interface {
+	// Return the secret for a reference, which is the value without its prefix
+	GetSecret(ref string, runner cmd_runner.Runner) (string, error)
+}
+
+// Use source to resolve values with the prefix, replacing the default source if there is one. Register sources once,
+// before creating workflow contexts, since each context starts with a copy of the registered sources.
+func RegisterSecretSource(prefix string, source SecretSource) {
+	defaultSecretSources.Register(prefix, source)
+}
+
+// SecretSources resolve values by their prefix. Each workflow context keeps its own sources, since some of them
+// (i.e. secret:) read from the clients of the context. Use WithSecretSources to resolve values with them.
+type SecretSources struct {
+	lock    sync.RWMutex
+	sources map[string]SecretSource
+	// Optional, the secrets that are resolved are added to this redactor
+	redactor *Redactor
+}
+
+// Returns a copy of the registered sources. The secrets they resolve are added to redactor, which is usually the
+// redactor of the workflow context.
+func NewSecretSources(redactor *Redactor) *SecretSources {
+	defaultSecretSources.lock.RLock()
+	defer defaultSecretSources.lock.RUnlock()
+	sources := make(map[string]SecretSource, len(defaultSecretSources.sources))
+	for prefix, source := range defaultSecretSources.sources {
+		sources[prefix] = source
+	}
+	return &SecretSources{
+		sources:  sources,
+		redactor: redactor,
+	}
+}
+
+// Use source to resolve values with the prefix, replacing the source if there is one.
+func (s *SecretSources) Register(prefix string, source SecretSource) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sources[prefix] = source
+}
+
+// Returns the prefix of the value if it is resolved from a secret source.
+func (s *SecretSources) getPrefix(val string) (string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for prefix := range s.sources {
+		if strings.HasPrefix(val, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func (s *SecretSources) resolve(prefix, val string, runner cmd_runner.Runner) (string, error) {
+	s.lock.RLock()
+	source := s.sources[prefix]
+	s.lock.RUnlock()
+	if source == nil {
+		return "", SecretSourceNotConfiguredError(prefix)
+	}
+	secret, err := source.GetSecret(strings.TrimPrefix(val, prefix), runner)
+	if err != nil {
+		return "", err
+	}
+	if s.redactor != nil {
+		s.redactor.Add(secret)
+	}
+	return secret, nil
+}
+
+// Returns a runner that resolves secret values with sources instead of the registered sources.
+func WithSecretSources(runner cmd_runner.Runner, sources *SecretSources) cmd_runner.Runner {
+	return &secretSourcesRunner{
+		Runner:  runner,
+		sources: sources,
+	}
+}
+
+// Returns the secret sources of the runner, or the registered sources if it has none.
+func getSecretSources(runner cmd_runner.Runner) *SecretSources {
+	if withSources, ok := runner.(*secretSourcesRunner); ok {
+		return withSources.sources
+	}
+	return defaultSecretSources
+}
+
+type secretSourcesRunner struct {
+	cmd_runner.Runner
+	sources *SecretSources
+}
+
+func (r *secretSourcesRunner) WithContext(ctx context.Context) cmd_runner.Runner {
+	return WithSecretSources(cmd_runner.RunnerWithContext(r.Runner, ctx), r.sources)
+}
+
+func (r *secretSourcesRunner) WithRequestTimeout(timeout time.Duration) cmd_runner.Runner {
+	return WithSecretSources(cmd_runner.RunnerWithRequestTimeout(r.Runner, timeout), r.sources)
+}
+
+func (r *secretSourcesRunner) WithLogger(logger log.Logger) cmd_runner.Runner {
+	if loggingRunner, ok := r.Runner.(cmd_runner.LoggingRunner); ok {
+		return WithSecretSources(loggingRunner.WithLogger(logger), r.sources)
+	}
+	return r
+}
+
+// Returns true if the value is resolved from a secret source, i.e. "vault:secret/data/gloo#license-key".
+func IsSecretRef(val interface{}, runner cmd_runner.Runner) bool {
+	str, ok := val.(string)
+	if !ok {
+		return false
+	}
+	_, ok = getSecretSources(runner).getPrefix(str)
+	return ok
+}
+
+// Split a secret reference into its path and field, i.e. "secret/data/gloo" and "license-key" for
+// "secret/data/gloo#license-key". The field is empty if the reference doesn't have one.
+func SplitSecretRef(ref string) (string, string) {
+	index := strings.LastIndex(ref, "#")
+	if index < 0 {
+		return ref, ""
+	}
+	return ref[:index], ref[index+1:]
+}
+
+// Reads a field of a secret from the Vault KV secrets engine (version 1 or 2) over its HTTP API, i.e.
+// "vault:secret/data/gloo#license-key".
+type VaultSource struct {
+	// Optional, defaults to the VAULT_ADDR environment variable
+	Address string
+	// Optional, defaults to the VAULT_TOKEN environment variable
+	Token string
+	// Optional, defaults to a client that verifies the certificate of vault and times out after DefaultVaultTimeout.
+	// The token is sent with each request, so the client shouldn't skip verifying certificates.
+	Client *http.Client
+}
+
+func (v *VaultSource) GetSecret(ref string, runner cmd_runner.Runner) (string, error) {
+	path, field := SplitSecretRef(ref)
+	if path == "" || field == "" {
+		return "", InvalidSecretRefError(ref, "path#field")
+	}
+	address, token := v.Address, v.Token
+	if address == "" {
+		address = os.Getenv(VaultAddressEnv)
+	}
+	if token == "" {
+		token = os.Getenv(VaultTokenEnv)
+	}
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(address, "/"), strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultVaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", VaultRequestError(path, resp.StatusCode, string(contents))
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(contents, &body); err != nil {
+		return "", err
+	}
+	data := body.Data
+	// Version 2 of the KV engine nests the secret under data, next to its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", SecretFieldNotFoundError(path, field)
+	}
+	return FormatValue(value)
+}
+
+// Decrypts a file encrypted with sops, i.e. "sops:secrets.yaml#gloo.licenseKey". Nested keys are separated by dots.
+// Without a key, the whole decrypted file is returned. The sops binary must be on the path, and is configured as usual
+// (i.e. with SOPS_AGE_KEY_FILE or cloud KMS credentials).
+type SopsSource struct{}
+
+func (s *SopsSource) GetSecret(ref string, runner cmd_runner.Runner) (string, error) {
+	path, key := SplitSecretRef(ref)
+	if path == "" {
+		return "", InvalidSecretRefError(ref, "file#key")
+	}
+	cmd := &cmd_runner.Command{
+		Name: "sops",
+		Args: []string{"--decrypt"},
+		// Warnings on stderr aren't part of the secret
+		StdOutOnly: true,
+	}
+	if key != "" {
+		var extract strings.Builder
+		for _, part := range strings.Split(key, ".") {
+			extract.WriteString(fmt.Sprintf("[%q]", part))
+		}
+		cmd = cmd.With("--extract", extract.String())
+	}
+	return runner.Output(cmd.With(expandEnv(path)))
+}
+
+// Decrypts a file encrypted with age, i.e. "age:license.age". If the reference has a key, i.e.
+// "age:secrets.yaml.age#gloo.licenseKey", the decrypted file is read as yaml and the value of the key is returned.
+// The age binary must be on the path.
+type AgeSource struct {
+	// Optional, defaults to the SOPS_AGE_KEY_FILE environment variable, and then to DefaultAgeKeyFile
+	IdentityFile string
+}
+
+func (a *AgeSource) GetSecret(ref string, runner cmd_runner.Runner) (string, error) {
+	path, key := SplitSecretRef(ref)
+	if path == "" {
+		return "", InvalidSecretRefError(ref, "file#key")
+	}
+	identityFile := a.IdentityFile
+	if identityFile == "" {
+		identityFile = os.Getenv(AgeKeyFileEnv)
+	}
+	if identityFile == "" {
+		identityFile = DefaultAgeKeyFile
+	}
+	cmd := &cmd_runner.Command{
+		Name: "age",
+		Args: []string{"--decrypt", "--identity", expandEnv(identityFile), expandEnv(path)},
+		// Warnings on stderr aren't part of the secret
+		StdOutOnly: true,
+	}
+	decrypted, err := runner.Output(cmd)
+	if err != nil || key == "" {
+		return decrypted, err
+	}
+	var contents interface{}
+	if err := yaml.Unmarshal([]byte(decrypted), &contents); err != nil {
+		return "", err
+	}
+	for _, part := range strings.Split(key, ".") {
+		fields, ok := contents.(map[string]interface{})
+		if !ok {
+			return "", SecretFieldNotFoundError(path, key)
+		}
+		if contents, ok = fields[part]; !ok {
+			return "", SecretFieldNotFoundError(path, key)
+		}
+	}
+	return FormatValue(contents)
+}
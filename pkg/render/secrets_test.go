@@ -0,0 +1,187 @@
+package render_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ = Describe("secrets", func() {
+
+	var (
+		ctrl       *gomock.Controller
+		mockRunner *mock_cmd.MockRunner
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		mockRunner = mock_cmd.NewMockRunner(ctrl)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("vault", func() {
+		var (
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Vault-Token") != "root" {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, `{"errors":["permission denied"]}`)
+					return
+				}
+				switch r.URL.Path {
+				case "/v1/secret/data/gloo":
+					fmt.Fprint(w, `{"data":{"data":{"license":"kv2-license"},"metadata":{"version":1}}}`)
+				case "/v1/kv/gloo":
+					fmt.Fprint(w, `{"data":{"license":"kv1-license"}}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"errors":[]}`)
+				}
+			}))
+			render.RegisterSecretSource(render.VaultPrefix, &render.VaultSource{Address: server.URL, Token: "root"})
+		})
+
+		AfterEach(func() {
+			server.Close()
+			render.RegisterSecretSource(render.VaultPrefix, &render.VaultSource{})
+		})
+
+		It("reads a field from version 1 and 2 of the kv engine and marks it sensitive", func() {
+			values := render.Values{
+				"V2": "vault:secret/data/gloo#license",
+				"V1": "vault:kv/gloo#license",
+			}
+			redactor := render.NewRedactor()
+			runner := render.WithSecretSources(cmd.DefaultCommandRunner(), render.NewSecretSources(redactor))
+			Expect(values.GetValue("V2", runner)).To(Equal("kv2-license"))
+			Expect(values.GetValue("V1", runner)).To(Equal("kv1-license"))
+			Expect(redactor.Redact("license is kv2-license")).To(Equal("license is " + cmd.Redacted))
+		})
+
+		It("returns an error for a missing field, a missing secret or a bad token", func() {
+			runner := cmd.DefaultCommandRunner()
+			_, err := render.Values{"V": "vault:secret/data/gloo#missing"}.GetValue("V", runner)
+			Expect(err).To(MatchError(render.SecretFieldNotFoundError("secret/data/gloo", "missing").Error()))
+			_, err = render.Values{"V": "vault:secret/data/missing#license"}.GetValue("V", runner)
+			Expect(err).To(HaveOccurred())
+			_, err = render.Values{"V": "vault:secret/data/gloo"}.GetValue("V", runner)
+			Expect(err).To(MatchError(render.InvalidSecretRefError("secret/data/gloo", "path#field").Error()))
+			_, err = (&render.VaultSource{Address: server.URL, Token: "wrong"}).GetSecret("secret/data/gloo#license", runner)
+			Expect(err).To(MatchError(render.VaultRequestError("secret/data/gloo", http.StatusForbidden, `{"errors":["permission denied"]}`).Error()))
+		})
+
+		It("times out a slow request", func() {
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(100 * time.Millisecond)
+			}))
+			defer slow.Close()
+			source := &render.VaultSource{Address: slow.URL, Token: "root", Client: &http.Client{Timeout: 10 * time.Millisecond}}
+			_, err := source.GetSecret("secret/data/gloo#license", mockRunner)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("decrypts a key of a sops file", func() {
+		mockRunner.EXPECT().Output(&cmd.Command{
+			Name:       "sops",
+			Args:       []string{"--decrypt", "--extract", `["gloo"]["license"]`, "secrets.yaml"},
+			StdOutOnly: true,
+		}).Return("sops-license", nil)
+		values := render.Values{"License": "sops:secrets.yaml#gloo.license"}
+		redactor := render.NewRedactor()
+		Expect(values.GetValue("License", render.WithSecretSources(mockRunner, render.NewSecretSources(redactor)))).To(Equal("sops-license"))
+		Expect(redactor.Redact("sops-license")).To(Equal(cmd.Redacted))
+	})
+
+	It("decrypts an age file, and reads a key if it is yaml", func() {
+		decrypt := &cmd.Command{
+			Name:       "age",
+			Args:       []string{"--decrypt", "--identity", "keys.txt", "secrets.yaml.age"},
+			StdOutOnly: true,
+		}
+		mockRunner.EXPECT().Output(decrypt).Return("gloo:\n  license: age-license\n", nil).Times(2)
+		source := &render.AgeSource{IdentityFile: "keys.txt"}
+		Expect(source.GetSecret("secrets.yaml.age#gloo.license", mockRunner)).To(Equal("age-license"))
+		_, err := source.GetSecret("secrets.yaml.age#gloo.missing", mockRunner)
+		Expect(err).To(MatchError(render.SecretFieldNotFoundError("secrets.yaml.age", "gloo.missing").Error()))
+	})
+
+	It("resolves values with the secret sources of the runner", func() {
+		sources := render.NewSecretSources(render.NewRedactor())
+		sources.Register(render.SecretPrefix, &render.SopsSource{})
+		mockRunner.EXPECT().Output(&cmd.Command{
+			Name:       "sops",
+			Args:       []string{"--decrypt", "license.yaml"},
+			StdOutOnly: true,
+		}).Return("cluster-license", nil)
+		values := render.Values{"License": "secret:license.yaml"}
+		Expect(values.GetValue("License", render.WithSecretSources(mockRunner, sources))).To(Equal("cluster-license"))
+		// Other runners use the registered sources, which don't read secret: values
+		_, err := values.GetValue("License", mockRunner)
+		Expect(err).To(MatchError(render.SecretSourceNotConfiguredError(render.SecretPrefix).Error()))
+		Expect(render.IsSecretRef("secret:license.yaml", mockRunner)).To(BeTrue())
+		Expect(render.IsSecretRef("env:LICENSE", mockRunner)).To(BeFalse())
+	})
+
+	It("returns an error if no source is configured for the prefix", func() {
+		render.RegisterSecretSource(render.SecretPrefix, nil)
+		_, err := render.Values{"S": "secret:gloo-system/license#key"}.GetValue("S", mockRunner)
+		Expect(err).To(MatchError(render.SecretSourceNotConfiguredError(render.SecretPrefix).Error()))
+	})
+
+	It("redacts longer secrets first and ignores whitespace", func() {
+		redactor := render.NewRedactor()
+		redactor.Add("abcdef")
+		redactor.Add("abcdefghi\n")
+		redactor.Add("  ")
+		Expect(redactor.Redact("abcdefghi abcdef xyz")).To(Equal(fmt.Sprintf("%s %s xyz", cmd.Redacted, cmd.Redacted)))
+	})
+
+	It("doesn't redact short values that are only suspected to be sensitive", func() {
+		redactor := render.NewRedactor()
+		redactor.AddSuspected("true")
+		redactor.AddSuspected("admin")
+		redactor.AddSuspected("hunter2")
+		Expect(redactor.Redact("admin is true, hunter2")).To(Equal("admin is true, " + cmd.Redacted))
+	})
+
+	It("redacts short values from secret sources", func() {
+		mockRunner.EXPECT().Output(&cmd.Command{
+			Name:       "sops",
+			Args:       []string{"--decrypt", "--extract", `["pin"]`, "secrets.yaml"},
+			StdOutOnly: true,
+		}).Return("1234", nil)
+		values := render.Values{"Pin": "sops:secrets.yaml#pin"}
+		redactor := render.NewRedactor()
+		Expect(values.GetValue("Pin", render.WithSecretSources(mockRunner, render.NewSecretSources(redactor)))).To(Equal("1234"))
+		Expect(redactor.Redact("the pin is 1234")).To(Equal("the pin is " + cmd.Redacted))
+	})
+
+	It("redacts base64 encoded secrets and error messages", func() {
+		redactor := render.NewRedactor()
+		redactor.Add("hunter2\n")
+		Expect(redactor.Redact("data: aHVudGVyMgo= aHVudGVyMg==")).To(Equal(fmt.Sprintf("data: %s %s", cmd.Redacted, cmd.Redacted)))
+		err := errors.Errorf("invalid password hunter2")
+		redacted := redactor.RedactError(err)
+		Expect(redacted).To(MatchError("invalid password " + cmd.Redacted))
+		Expect(errors.Is(redacted, err)).To(BeTrue())
+		unchanged := errors.Errorf("no secrets")
+		Expect(redactor.RedactError(unchanged)).To(Equal(unchanged))
+		Expect(redactor.RedactError(nil)).To(BeNil())
+	})
+})
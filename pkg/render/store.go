@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"github.com/ghodss/yaml"
 	"github.com/solo-io/go-utils/osutils"
-	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -23,13 +23,31 @@ type FileStore interface {
 	Exists(path string) (bool, error)
 }
 
+// File stores can implement LoggingFileStore, so their logs can be scoped, i.e. to the step that is running.
+type LoggingFileStore interface {
+	WithLogger(logger log.Logger) FileStore
+}
+
+// Create a file store that logs with log.Default(), see WithLogger
 func NewFileStore() *fileStore {
-	return &fileStore{}
+	return &fileStore{
+		logger: log.Default(),
+	}
 }
 
 var _ FileStore = new(fileStore)
+var _ LoggingFileStore = new(fileStore)
 
-type fileStore struct {}
+// Returns a copy of the file store that logs with the provided logger
+func (f *fileStore) WithLogger(logger log.Logger) FileStore {
+	return &fileStore{
+		logger: logger,
+	}
+}
+
+type fileStore struct {
+	logger log.Logger
+}
 
 func (f *fileStore) Exists(path string) (bool, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -84,7 +102,7 @@ func (f *fileStore) loadBytes(path string) ([]byte, error) {
 	expandedPath := expandEnv(path)
 	contents, err := osClient.ReadFile(expandedPath)
 	if err != nil {
-		cmd.Stderr().Println("Failed to read file '%s': %s", expandedPath, err.Error())
+		f.logger.Errorf("Failed to read file '%s': %s", expandedPath, err.Error())
 		return nil, err
 	}
 	return contents, nil
@@ -0,0 +1,186 @@
+package render
+
+import (
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/go-utils/stringutils"
+)
+
+var (
+	MissingValueError = func(key string) error {
+		return errors.Errorf("No value provided for %s", key)
+	}
+	InvalidTemplateError = func(err error) error {
+		return errors.Wrapf(err, "Invalid template")
+	}
+	FileNotFoundError = func(path string) error {
+		return errors.Errorf("File %s does not exist", path)
+	}
+	InvalidDurationError = func(field, value string) error {
+		return errors.Errorf("Invalid duration %s for %s", value, field)
+	}
+)
+
+// Returns the names of the values referenced by a go template, i.e. "Namespace" for "{{ .Namespace }}".
+// References inside range and with blocks are relative, so they are not included.
+func TemplateValueNames(tmpl string) ([]string, error) {
+	parsed, err := template.New("").Funcs(sprig.HermeticTxtFuncMap()).Parse(tmpl)
+	if err != nil {
+		return nil, InvalidTemplateError(err)
+	}
+	var names []string
+	if parsed.Tree != nil {
+		collectValueNames(parsed.Tree.Root, &names)
+	}
+	return names, nil
+}
+
+func collectValueNames(node parse.Node, names *[]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectValueNames(child, names)
+		}
+	case *parse.ActionNode:
+		collectValueNames(n.Pipe, names)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, command := range n.Cmds {
+			collectValueNames(command, names)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectValueNames(arg, names)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 && !stringutils.ContainsString(n.Ident[0], *names) {
+			*names = append(*names, n.Ident[0])
+		}
+	case *parse.IfNode:
+		collectValueNames(n.Pipe, names)
+		collectValueNames(n.List, names)
+		collectValueNames(n.ElseList, names)
+	case *parse.RangeNode:
+		collectValueNames(n.Pipe, names)
+	case *parse.WithNode:
+		collectValueNames(n.Pipe, names)
+	case *parse.TemplateNode:
+		collectValueNames(n.Pipe, names)
+	}
+}
+
+// Checks that a template parses, and that every value it references is provided.
+func (v Values) ValidateTemplate(tmpl string) error {
+	names, err := TemplateValueNames(tmpl)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !v.ContainsKey(name) {
+			return MissingValueError(name)
+		}
+	}
+	return nil
+}
+
+// The static counterpart to RenderFields. Checks that the templates in fields tagged with "template" parse and only
+// reference provided values, and that fields tagged with a key and no default are set or have a value provided.
+func (v Values) ValidateFields(input interface{}) []error {
+	var errs []error
+	structVal := reflect.ValueOf(input).Elem()
+	structType := reflect.TypeOf(input).Elem()
+	for i := 0; i < structType.NumField(); i++ {
+		fieldType := structType.Field(i)
+		valetTags := strings.Split(fieldType.Tag.Get(ValetField), ",")
+		fieldValue := structVal.Field(i)
+		switch fieldValue.Kind() {
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool, reflect.Slice, reflect.Map:
+			key := getTagValue(valetTags, KeyTag)
+			if fieldValue.IsZero() && key != "" && getTagValue(valetTags, DefaultTag) == "" && !v.ContainsKey(key) {
+				errs = append(errs, MissingValueError(key))
+			}
+			if stringutils.ContainsString(TemplateTag, valetTags) {
+				for _, tmpl := range templateStrings(fieldValue) {
+					if err := v.ValidateTemplate(tmpl); err != nil {
+						errs = append(errs, err)
+					}
+				}
+			}
+			continue
+		}
+		if fieldValue.Kind() == reflect.Struct {
+			errs = append(errs, v.ValidateFields(fieldValue.Addr().Interface())...)
+		} else if fieldValue.Kind() == reflect.Ptr {
+			originalValue := fieldValue.Elem()
+			if !originalValue.IsValid() || originalValue.Kind() != reflect.Struct {
+				continue
+			}
+			errs = append(errs, v.ValidateFields(fieldValue.Interface())...)
+		}
+	}
+	return errs
+}
+
+// Returns true if the value contains a go template, which can't be checked until it is rendered.
+func IsTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// Returns the strings of a string field, or a list or map field of strings, which are rendered as templates.
+func templateStrings(fieldValue reflect.Value) []string {
+	var strs []string
+	switch fieldValue.Kind() {
+	case reflect.String:
+		if fieldValue.String() != "" {
+			strs = append(strs, fieldValue.String())
+		}
+	case reflect.Slice:
+		for i := 0; i < fieldValue.Len(); i++ {
+			strs = append(strs, templateStrings(fieldValue.Index(i))...)
+		}
+	case reflect.Map:
+		for _, mapKey := range fieldValue.MapKeys() {
+			strs = append(strs, templateStrings(fieldValue.MapIndex(mapKey))...)
+		}
+	}
+	return strs
+}
+
+// Checks that a file exists. Remote files are not fetched, and templated paths (i.e. using the item of a forEach)
+// can't be checked until they are rendered, so both are considered valid.
+func ValidateFile(store FileStore, path string) error {
+	if isRemote(path) || IsTemplate(path) {
+		return nil
+	}
+	exists, err := store.Exists(expandEnv(path))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return FileNotFoundError(path)
+	}
+	return nil
+}
+
+// Checks that a field is a valid duration (i.e. "30s"). Empty fields use a default, and templated fields can't be
+// checked until they are rendered, so both are considered valid.
+func ValidateDuration(field, value string) error {
+	if value == "" || IsTemplate(value) {
+		return nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		return InvalidDurationError(field, value)
+	}
+	return nil
+}
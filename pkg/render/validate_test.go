@@ -0,0 +1,60 @@
+package render_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ = Describe("validate", func() {
+
+	type nested struct {
+		Name string `valet:"template"`
+	}
+
+	type fields struct {
+		Name      string  `valet:"template"`
+		Namespace string  `valet:"key=Namespace"`
+		Type      string  `valet:"key=Type,default=Opaque"`
+		Nested    *nested `valet:""`
+	}
+
+	It("finds the values referenced by a template", func() {
+		names, err := render.TemplateValueNames(`{{ .A }}-{{ if .B }}{{ .C | upper }}{{ end }}{{ range .D }}{{ .E }}{{ end }}`)
+		Expect(err).To(BeNil())
+		Expect(names).To(ConsistOf("A", "B", "C", "D"))
+	})
+
+	It("fails for templates that don't parse", func() {
+		_, err := render.TemplateValueNames(`{{ .A `)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("flags missing values in templates", func() {
+		values := render.Values{"A": "a"}
+		Expect(values.ValidateTemplate(`{{ .A }}`)).To(BeNil())
+		Expect(values.ValidateTemplate(`{{ .A }}{{ .B }}`)).To(MatchError(render.MissingValueError("B").Error()))
+	})
+
+	It("flags missing values in fields", func() {
+		input := &fields{Name: "{{ .Name }}", Nested: &nested{Name: "{{ .Other }}"}}
+		errs := render.Values{"Name": "foo"}.ValidateFields(input)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs[0]).To(MatchError(render.MissingValueError("Namespace").Error()))
+		Expect(errs[1]).To(MatchError(render.MissingValueError("Other").Error()))
+	})
+
+	It("accepts fields that are set or provided", func() {
+		input := &fields{Name: "{{ .Name }}", Namespace: "ns"}
+		Expect(render.Values{"Name": "foo"}.ValidateFields(input)).To(BeEmpty())
+		input = &fields{}
+		Expect(render.Values{"Namespace": "ns"}.ValidateFields(input)).To(BeEmpty())
+	})
+
+	It("validates durations", func() {
+		Expect(render.ValidateDuration("timeout", "30s")).To(BeNil())
+		Expect(render.ValidateDuration("timeout", "")).To(BeNil())
+		Expect(render.ValidateDuration("timeout", "{{ .Timeout }}")).To(BeNil())
+		Expect(render.ValidateDuration("timeout", "30 seconds")).To(MatchError(render.InvalidDurationError("timeout", "30 seconds").Error()))
+	})
+})
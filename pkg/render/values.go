@@ -1,6 +1,8 @@
 package render
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
 	"io/ioutil"
@@ -29,6 +31,7 @@ const (
 	KeyPrefix      = "key:"
 	CmdPrefix      = "cmd:"
 	FilePrefix     = "file:"
+	RawPrefix      = "raw:"
 
 	ValetField  = "valet"
 	TemplateTag = "template"
@@ -43,18 +46,78 @@ var (
 	RequiredValueNotProvidedError = func(key string) error {
 		return errors.Errorf("Required value %s not found", key)
 	}
+	FieldTypeError = func(fieldType, value string) error {
+		return errors.Errorf("Value %s can't be converted to %s", value, fieldType)
+	}
 )
 
-type Values map[string]string
+// Values are provided to workflows and steps, and are used to render templates and fields. A value can be any yaml
+// type: a string, number, boolean, list or map. Strings (including strings in lists and maps) can use a prefix
+// to load the value from another source, i.e. "env:LICENSE_KEY".
+type Values map[string]interface{}
+
+// Numbers are decoded as int64 (or float64 if they aren't whole), so that they aren't rendered as i.e. "1e+06".
+func (v *Values) UnmarshalJSON(b []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var decoded map[string]interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return err
+	}
+	if decoded == nil {
+		*v = nil
+		return nil
+	}
+	*v = convertNumbers(decoded).(map[string]interface{})
+	return nil
+}
+
+func convertNumbers(val interface{}) interface{} {
+	switch typed := val.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		f, _ := typed.Float64()
+		return f
+	case []interface{}:
+		for i := range typed {
+			typed[i] = convertNumbers(typed[i])
+		}
+	case map[string]interface{}:
+		for k := range typed {
+			typed[k] = convertNumbers(typed[k])
+		}
+	}
+	return val
+}
 
 func (v Values) DeepCopy() Values {
-	values := make(map[string]string)
+	values := make(Values)
 	for k, val := range v {
-		values[k] = val
+		values[k] = deepCopyValue(val)
 	}
 	return values
 }
 
+func deepCopyValue(val interface{}) interface{} {
+	switch typed := val.(type) {
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i := range typed {
+			copied[i] = deepCopyValue(typed[i])
+		}
+		return copied
+	case map[string]interface{}:
+		copied := make(map[string]interface{})
+		for k := range typed {
+			copied[k] = deepCopyValue(typed[k])
+		}
+		return copied
+	}
+	return val
+}
+
 func (v Values) Load(tmpl string, runner cmd_runner.Runner) (string, error) {
 	parsed, err := template.New("").Parse(tmpl)
 	if err != nil {
@@ -69,10 +132,11 @@ func (v Values) Load(tmpl string, runner cmd_runner.Runner) (string, error) {
 	return out.String(), err
 }
 
+// Resolve every value, keeping its type, so templates can use lists and maps (i.e. with range).
 func (v Values) Render(runner cmd_runner.Runner) (map[string]interface{}, error) {
 	vals := make(map[string]interface{})
 	for k := range v {
-		v, err := v.GetValue(k, runner)
+		v, err := v.Get(k, runner)
 		if err != nil {
 			return nil, err
 		}
@@ -89,14 +153,63 @@ func (v Values) ContainsKey(key string) bool {
 	return ok
 }
 
+// Return the value as a string. Numbers and booleans are formatted, and lists and maps are formatted as json.
 func (v Values) GetValue(key string, runner cmd_runner.Runner) (string, error) {
+	val, err := v.Get(key, runner)
+	if err != nil {
+		return "", err
+	}
+	return FormatValue(val)
+}
+
+// Return the value with its type. String values (including strings in lists and maps) are resolved by their prefix.
+func (v Values) Get(key string, runner cmd_runner.Runner) (interface{}, error) {
 	val, ok := v[key]
 	if !ok {
-		return "", ValueNotFoundError(key)
+		return nil, ValueNotFoundError(key)
+	}
+	return v.resolve(key, val, runner)
+}
+
+func (v Values) resolve(key string, val interface{}, runner cmd_runner.Runner) (interface{}, error) {
+	if str, ok := val.(string); ok {
+		return v.resolveString(key, str, runner)
+	}
+	reflectVal := reflect.ValueOf(val)
+	switch reflectVal.Kind() {
+	case reflect.Slice:
+		resolved := make([]interface{}, reflectVal.Len())
+		for i := 0; i < reflectVal.Len(); i++ {
+			item, err := v.resolve(key, reflectVal.Index(i).Interface(), runner)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	case reflect.Map:
+		if reflectVal.Type().Key().Kind() != reflect.String {
+			return val, nil
+		}
+		resolved := make(map[string]interface{})
+		for _, mapKey := range reflectVal.MapKeys() {
+			item, err := v.resolve(key, reflectVal.MapIndex(mapKey).Interface(), runner)
+			if err != nil {
+				return nil, err
+			}
+			resolved[mapKey.String()] = item
+		}
+		return resolved, nil
 	}
-	if strings.HasPrefix(val, KeyPrefix) {
+	return val, nil
+}
+
+func (v Values) resolveString(key, val string, runner cmd_runner.Runner) (interface{}, error) {
+	if strings.HasPrefix(val, RawPrefix) {
+		return strings.TrimPrefix(val, RawPrefix), nil
+	} else if strings.HasPrefix(val, KeyPrefix) {
 		key := strings.TrimPrefix(val, KeyPrefix)
-		return v.GetValue(key, runner)
+		return v.Get(key, runner)
 	} else if strings.HasPrefix(val, TemplatePrefix) {
 		tmpl := strings.TrimPrefix(val, TemplatePrefix)
 		otherVals := v.DeepCopy()
@@ -136,19 +249,45 @@ func (v Values) GetValue(key string, runner cmd_runner.Runner) (string, error) {
 			return "", err
 		}
 		return string(byt), nil
+	} else if prefix, ok := getSecretSources(runner).getPrefix(val); ok {
+		return getSecretSources(runner).resolve(prefix, val, runner)
 	} else {
 		return val, nil
 	}
 }
 
+// Format a resolved value as a string. Lists and maps are formatted as json.
+func FormatValue(val interface{}) (string, error) {
+	switch typed := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typed, nil
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64), nil
+	}
+	switch reflect.ValueOf(val).Kind() {
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return fmt.Sprint(val), nil
+}
+
 func (v Values) ToString() string {
 	var entries []string
 	for k, v := range v {
-		entries = append(entries, fmt.Sprintf("%s=%s", k, v))
+		entries = append(entries, fmt.Sprintf("%s=%v", k, v))
 	}
 	return fmt.Sprintf("{%s}", strings.Join(entries, ", "))
 }
 
+// Set the fields of input from the values. Fields tagged with a key are set from that value if they are empty,
+// and fields tagged with a default are set to the default if they are still empty. String fields (and the strings
+// of list and map fields) tagged with template are rendered as templates. Nested structs are rendered too.
 func (v Values) RenderFields(input interface{}, runner cmd_runner.Runner) error {
 	structVal := reflect.ValueOf(input).Elem()
 	structType := reflect.TypeOf(input).Elem()
@@ -156,47 +295,39 @@ func (v Values) RenderFields(input interface{}, runner cmd_runner.Runner) error
 		fieldType := structType.Field(i)
 		valetTags := strings.Split(fieldType.Tag.Get(ValetField), ",")
 		fieldValue := structVal.Field(i)
-		if fieldValue.Kind() == reflect.String {
-			rendered := fieldValue.String()
-
-			if key := getTagValue(valetTags, KeyTag); key != "" && rendered == "" && v.ContainsKey(key) {
-				val, err := v.GetValue(key, runner)
+		switch fieldValue.Kind() {
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Float64, reflect.Bool, reflect.Slice, reflect.Map:
+			if !fieldValue.CanSet() {
+				continue
+			}
+			// A value for the key is used even if it is the zero value (i.e. false), except for an empty string
+			// (i.e. an env: value that isn't set)
+			fromKey := false
+			if key := getTagValue(valetTags, KeyTag); key != "" && fieldValue.IsZero() && v.ContainsKey(key) {
+				val, err := v.Get(key, runner)
 				if err != nil {
 					return err
 				}
-				rendered = val
-			}
-
-			if defaultValue := getTagValue(valetTags, DefaultTag); defaultValue != "" && rendered == "" {
-				rendered = defaultValue
-			}
-
-			if stringutils.ContainsString(TemplateTag, valetTags) {
-				loaded, err := LoadTemplate(rendered, v, runner)
-				if err != nil {
+				if err := setField(fieldValue, val); err != nil {
 					return err
 				}
-				rendered = loaded
+				fromKey = fieldValue.Kind() != reflect.String
 			}
-
-			fieldValue.SetString(rendered)
-		} else if fieldValue.Kind() == reflect.Int {
-			if fieldValue.Int() == 0 {
-				rendered := getTagValue(valetTags, DefaultTag)
-				if rendered == "" {
-					continue
+			if defaultValue := getTagValue(valetTags, DefaultTag); defaultValue != "" && !fromKey && fieldValue.IsZero() {
+				if err := setField(fieldValue, defaultValue); err != nil {
+					return err
 				}
-				val, err := strconv.Atoi(rendered)
-				if err != nil {
+			}
+			if stringutils.ContainsString(TemplateTag, valetTags) {
+				if err := v.renderTemplates(fieldValue, runner); err != nil {
 					return err
 				}
-				fieldValue.SetInt(int64(val))
 			}
-		} else if fieldValue.Kind() == reflect.Struct {
+		case reflect.Struct:
 			if err := v.RenderFields(fieldValue.Addr().Interface(), runner); err != nil {
 				return err
 			}
-		} else if fieldValue.Kind() == reflect.Ptr {
+		case reflect.Ptr:
 			originalValue := fieldValue.Elem()
 			if !originalValue.IsValid() || originalValue.Kind() != reflect.Struct {
 				continue
@@ -209,16 +340,90 @@ func (v Values) RenderFields(input interface{}, runner cmd_runner.Runner) error
 	return nil
 }
 
-func (v Values) RenderValues(runner cmd_runner.Runner) (map[string]interface{}, error) {
-	vals := make(map[string]interface{})
-	for k := range v {
-		v, err := v.GetValue(k, runner)
+// Set a field to a value. Strings are parsed for number and boolean fields, and other values are converted
+// to the type of the field (i.e. a list of values to a []string).
+func setField(fieldValue reflect.Value, val interface{}) error {
+	if str, ok := val.(string); ok {
+		switch fieldValue.Kind() {
+		case reflect.String:
+			fieldValue.SetString(str)
+			return nil
+		case reflect.Int, reflect.Int64:
+			parsed, err := strconv.ParseInt(str, 10, 64)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetInt(parsed)
+			return nil
+		case reflect.Float64:
+			parsed, err := strconv.ParseFloat(str, 64)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetFloat(parsed)
+			return nil
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(str)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetBool(parsed)
+			return nil
+		}
+	}
+	if fieldValue.Kind() == reflect.String {
+		formatted, err := FormatValue(val)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		vals[k] = v
+		fieldValue.SetString(formatted)
+		return nil
 	}
-	return vals, nil
+	b, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	converted := reflect.New(fieldValue.Type())
+	if err := json.Unmarshal(b, converted.Interface()); err != nil {
+		return FieldTypeError(fieldValue.Type().String(), string(b))
+	}
+	fieldValue.Set(converted.Elem())
+	return nil
+}
+
+func (v Values) renderTemplates(fieldValue reflect.Value, runner cmd_runner.Runner) error {
+	switch fieldValue.Kind() {
+	case reflect.String:
+		loaded, err := LoadTemplate(fieldValue.String(), v, runner)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetString(loaded)
+	case reflect.Slice:
+		for i := 0; i < fieldValue.Len(); i++ {
+			if item := fieldValue.Index(i); item.Kind() == reflect.String {
+				if err := v.renderTemplates(item, runner); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		if fieldValue.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		for _, mapKey := range fieldValue.MapKeys() {
+			loaded, err := LoadTemplate(fieldValue.MapIndex(mapKey).String(), v, runner)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetMapIndex(mapKey, reflect.ValueOf(loaded).Convert(fieldValue.Type().Elem()))
+		}
+	}
+	return nil
+}
+
+func (v Values) RenderValues(runner cmd_runner.Runner) (map[string]interface{}, error) {
+	return v.Render(runner)
 }
 
 func (v Values) RenderStringValues(runner cmd_runner.Runner) (map[string]string, error) {
@@ -233,6 +438,18 @@ func (v Values) RenderStringValues(runner cmd_runner.Runner) (map[string]string,
 	return vals, nil
 }
 
+// Return values from a map of strings, i.e. values provided with a command line flag.
+func FromStrings(values map[string]string) Values {
+	if values == nil {
+		return nil
+	}
+	converted := make(Values)
+	for k, v := range values {
+		converted[k] = v
+	}
+	return converted
+}
+
 func getTagValue(fieldTags []string, tag string) string {
 	prefix := fmt.Sprintf("%s=", tag)
 	for _, fieldTag := range fieldTags {
@@ -1,6 +1,7 @@
 package render_test
 
 import (
+	"github.com/ghodss/yaml"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/solo-io/valet/pkg/cmd"
@@ -63,4 +64,116 @@ var _ = Describe("Values", func() {
 		Expect(vals.GetValue("VirtualServiceName", runner)).To(Equal("glooui"))
 		Expect(vals.GetValue("Domain", runner)).To(Equal("glooui.testing.valet.corp.solo.io"))
 	})
+
+	It("doesn't expand raw values", func() {
+		vals := render.Values{
+			"Body": "raw:cmd:echo hello",
+		}
+		Expect(vals.GetValue("Body", runner)).To(Equal("cmd:echo hello"))
+	})
+
+	Context("typed values", func() {
+		It("decodes yaml types, keeping whole numbers as integers", func() {
+			var vals render.Values
+			Expect(yaml.Unmarshal([]byte(`
+Replicas: 1000000
+Ratio: 0.5
+Enabled: true
+Namespaces: [default, gloo-system]
+Gateway:
+  ports: [80, 443]
+Version: "1.10"
+`), &vals)).To(BeNil())
+			Expect(vals).To(Equal(render.Values{
+				"Replicas":   int64(1000000),
+				"Ratio":      0.5,
+				"Enabled":    true,
+				"Namespaces": []interface{}{"default", "gloo-system"},
+				"Gateway":    map[string]interface{}{"ports": []interface{}{int64(80), int64(443)}},
+				"Version":    "1.10",
+			}))
+			Expect(vals.GetValue("Replicas", runner)).To(Equal("1000000"))
+			Expect(vals.GetValue("Ratio", runner)).To(Equal("0.5"))
+			Expect(vals.GetValue("Namespaces", runner)).To(Equal(`["default","gloo-system"]`))
+		})
+
+		It("resolves prefixes of strings in lists and maps", func() {
+			vals := render.Values{
+				"Namespace": "gloo-system",
+				"Targets":   []interface{}{"key:Namespace", "default"},
+				"Gateway":   map[string]interface{}{"namespace": "template:{{ .Namespace }}", "replicas": 2},
+			}
+			Expect(vals.Get("Targets", runner)).To(Equal([]interface{}{"gloo-system", "default"}))
+			Expect(vals.Get("Gateway", runner)).To(Equal(map[string]interface{}{"namespace": "gloo-system", "replicas": 2}))
+		})
+
+		It("lets templates range over lists and use maps", func() {
+			vals := render.Values{
+				"Namespaces": []string{"default", "gloo-system"},
+				"Gateway":    map[string]interface{}{"replicas": 2},
+			}
+			rendered, err := render.LoadTemplate("{{ range .Namespaces }}{{ . }} {{ end }}{{ .Gateway.replicas }}", vals, runner)
+			Expect(err).To(BeNil())
+			Expect(rendered).To(Equal("default gloo-system 2"))
+		})
+
+		It("doesn't share lists and maps between copies", func() {
+			vals := render.Values{"Gateway": map[string]interface{}{"replicas": 2}}
+			copied := vals.DeepCopy()
+			copied["Gateway"].(map[string]interface{})["replicas"] = 3
+			Expect(vals["Gateway"]).To(Equal(map[string]interface{}{"replicas": 2}))
+		})
+	})
+
+	Context("render fields", func() {
+		type nested struct {
+			Replicas int `valet:"key=Replicas"`
+		}
+		type fields struct {
+			Name       string            `valet:"template"`
+			Replicas   int               `valet:"key=Replicas,default=1"`
+			Enabled    bool              `valet:"key=Enabled,default=true"`
+			Ratio      float64           `valet:"key=Ratio"`
+			Namespaces []string          `valet:"key=Namespaces,template"`
+			Labels     map[string]string `valet:"key=Labels,template"`
+			Nested     *nested
+		}
+
+		It("fills typed fields from values", func() {
+			vals := render.Values{
+				"Namespace":  "gloo-system",
+				"Replicas":   int64(3),
+				"Enabled":    "false",
+				"Ratio":      0.5,
+				"Namespaces": []interface{}{"default", "{{ .Namespace }}"},
+				"Labels":     map[string]interface{}{"app": "{{ .Namespace }}"},
+			}
+			input := &fields{Name: "{{ .Namespace }}", Nested: &nested{}}
+			Expect(vals.RenderFields(input, runner)).To(BeNil())
+			Expect(input).To(Equal(&fields{
+				Name:       "gloo-system",
+				Replicas:   3,
+				Enabled:    false,
+				Ratio:      0.5,
+				Namespaces: []string{"default", "gloo-system"},
+				Labels:     map[string]string{"app": "gloo-system"},
+				Nested:     &nested{Replicas: 3},
+			}))
+		})
+
+		It("uses defaults for typed fields and doesn't override fields that are set", func() {
+			input := &fields{Namespaces: []string{"default"}}
+			Expect(render.Values{"Namespaces": []interface{}{"other"}}.RenderFields(input, runner)).To(BeNil())
+			Expect(input.Replicas).To(Equal(1))
+			Expect(input.Enabled).To(BeTrue())
+			Expect(input.Namespaces).To(Equal([]string{"default"}))
+		})
+
+		It("errors when a value can't be converted to the type of the field", func() {
+			input := &fields{}
+			err := render.Values{"Replicas": []interface{}{"one"}}.RenderFields(input, runner)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(render.FieldTypeError("int", `["one"]`).Error()))
+		})
+	})
 })
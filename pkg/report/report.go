@@ -0,0 +1,308 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+)
+
+const (
+	JsonFormat  = "json"
+	JunitFormat = "junit"
+
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+var (
+	UnknownReportFormatError = func(format string) error {
+		return errors.Errorf("Unknown report format %s, must be one of [%s, %s]", format, JsonFormat, JunitFormat)
+	}
+)
+
+// A report.Report is a machine-readable record of a workflow run, with a result for each step.
+// All methods are safe to call on a nil report, in which case nothing is recorded.
+type Report struct {
+	Workflow string `json:"workflow"`
+	// The values of the matrix combination the workflow ran with, if it has a matrix
+	Values   map[string]interface{} `json:"values,omitempty"`
+	Start    time.Time              `json:"start"`
+	End      time.Time              `json:"end"`
+	Duration time.Duration          `json:"duration"`
+	Status   string                 `json:"status"`
+	Error    string                 `json:"error,omitempty"`
+	Steps    []*StepResult          `json:"steps"`
+
+	lock sync.Mutex
+}
+
+type StepResult struct {
+	// The phase of the workflow the step belongs to (i.e. setup, steps or cleanup)
+	Phase       string        `json:"phase"`
+	Index       int           `json:"index"`
+	Id          string        `json:"id,omitempty"`
+	Type        string        `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Duration    time.Duration `json:"duration"`
+	Status      string        `json:"status"`
+	Error       string        `json:"error,omitempty"`
+	// The outputs the step published, i.e. whether destroyCluster removed the cluster
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+func New(workflow string) *Report {
+	return &Report{
+		Workflow: workflow,
+		Start:    time.Now(),
+	}
+}
+
+// Record the start of a step. The returned result should be finished when the step completes.
+func (r *Report) StartStep(phase string, index int, id, stepType string) *StepResult {
+	if r == nil {
+		return nil
+	}
+	result := &StepResult{
+		Phase: phase,
+		Index: index,
+		Id:    id,
+		Type:  stepType,
+		Start: time.Now(),
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Steps = append(r.Steps, result)
+	return result
+}
+
+// Record a step that wasn't run, because an earlier step failed.
+func (r *Report) SkipStep(phase string, index int, id, stepType string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Steps = append(r.Steps, &StepResult{
+		Phase:  phase,
+		Index:  index,
+		Id:     id,
+		Type:   stepType,
+		Status: StatusSkipped,
+	})
+}
+
+func (r *Report) Finish(err error) {
+	if r == nil {
+		return
+	}
+	r.End = time.Now()
+	r.Duration = r.End.Sub(r.Start)
+	r.Status, r.Error = getStatus(err)
+}
+
+func (s *StepResult) SetDescription(description string) {
+	if s == nil {
+		return
+	}
+	s.Description = description
+}
+
+func (s *StepResult) SetOutputs(outputs map[string]string) {
+	if s == nil || len(outputs) == 0 {
+		return
+	}
+	s.Outputs = make(map[string]string)
+	for name, value := range outputs {
+		s.Outputs[name] = value
+	}
+}
+
+func (s *StepResult) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	s.Duration = s.End.Sub(s.Start)
+	s.Status, s.Error = getStatus(err)
+}
+
+// Record that the step was skipped after it was started, i.e. by the presenter of an interactive workflow.
+func (s *StepResult) Skip() {
+	if s == nil {
+		return
+	}
+	s.End = time.Now()
+	s.Duration = s.End.Sub(s.Start)
+	s.Status = StatusSkipped
+}
+
+// The name of the step in reports, i.e. "steps[3] deploy-monolith (apply)"
+func (s *StepResult) Name() string {
+	name := fmt.Sprintf("%s[%d]", s.Phase, s.Index)
+	if s.Id != "" {
+		name = fmt.Sprintf("%s %s", name, s.Id)
+	}
+	return fmt.Sprintf("%s (%s)", name, s.Type)
+}
+
+func getStatus(err error) (string, string) {
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+	return StatusPassed, ""
+}
+
+// Serialize the report in the provided format (json or junit).
+func (r *Report) Marshal(format string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch format {
+	case JsonFormat:
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case JunitFormat:
+		b, err := xml.MarshalIndent(r.toJunit(), "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return xml.Header + string(b), nil
+	}
+	return "", UnknownReportFormatError(format)
+}
+
+// The reports of a workflow with a matrix, one for each combination of values.
+type Reports []*Report
+
+// Serialize the reports in the provided format: a json list, or a junit test suite for each report.
+func (r Reports) Marshal(format string) (string, error) {
+	switch format {
+	case JsonFormat:
+		for _, report := range r {
+			report.lock.Lock()
+			defer report.lock.Unlock()
+		}
+		b, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case JunitFormat:
+		suites := &junitTestSuites{}
+		for _, report := range r {
+			report.lock.Lock()
+			suites.Suites = append(suites.Suites, report.toJunit().Suites...)
+			report.lock.Unlock()
+		}
+		b, err := xml.MarshalIndent(suites, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return xml.Header + string(b), nil
+	}
+	return "", UnknownReportFormatError(format)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func (r *Report) toJunit() *junitTestSuites {
+	suite := junitTestSuite{
+		Name:      r.getName(),
+		Time:      junitSeconds(r.Duration),
+		Timestamp: r.Start.Format(time.RFC3339),
+	}
+	for _, step := range r.Steps {
+		testCase := junitTestCase{
+			Name:      step.Name(),
+			ClassName: fmt.Sprintf("%s.%s", strings.TrimSuffix(r.Workflow, ".yaml"), step.Phase),
+			Time:      junitSeconds(step.Duration),
+			SystemOut: strings.Join(append(nonEmpty(step.Description), formatOutputs(step.Outputs)...), "\n"),
+		}
+		switch step.Status {
+		case StatusFailed:
+			suite.Failures++
+			testCase.Failure = &junitFailure{
+				Message:  step.Error,
+				Contents: step.Error,
+			}
+		case StatusSkipped:
+			suite.Skipped++
+			testCase.Skipped = &struct{}{}
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return &junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+// The name of the report, including the values of its matrix combination, i.e. "workflow.yaml [GlooVersion=1.3.17]"
+func (r *Report) getName() string {
+	if len(r.Values) == 0 {
+		return r.Workflow
+	}
+	var entries []string
+	for name, value := range r.Values {
+		entries = append(entries, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(entries)
+	return fmt.Sprintf("%s [%s]", r.Workflow, strings.Join(entries, ", "))
+}
+
+// Format outputs as lines of "name: value", sorted by name.
+func formatOutputs(outputs map[string]string) []string {
+	var lines []string
+	for name, value := range outputs {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, value))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func nonEmpty(line string) []string {
+	if line == "" {
+		return nil
+	}
+	return []string{line}
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
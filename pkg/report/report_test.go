@@ -0,0 +1,108 @@
+package report_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/report"
+)
+
+var _ = Describe("report", func() {
+
+	var (
+		r *report.Report
+	)
+
+	BeforeEach(func() {
+		r = report.New("workflow.yaml")
+		passed := r.StartStep("steps", 0, "deploy", "apply")
+		passed.SetDescription("Running command: kubectl apply -f petclinic.yaml")
+		passed.Finish(nil)
+		failed := r.StartStep("steps", 1, "", "curl")
+		failed.Finish(errors.Errorf("Curl got unexpected status code 503"))
+		r.SkipStep("steps", 2, "vs-2", "apply")
+		r.Finish(errors.Errorf("Curl got unexpected status code 503"))
+	})
+
+	It("records the status of each step", func() {
+		Expect(r.Status).To(Equal(report.StatusFailed))
+		Expect(r.Steps).To(HaveLen(3))
+		Expect(r.Steps[0].Status).To(Equal(report.StatusPassed))
+		Expect(r.Steps[1].Status).To(Equal(report.StatusFailed))
+		Expect(r.Steps[1].Error).To(Equal("Curl got unexpected status code 503"))
+		Expect(r.Steps[2].Status).To(Equal(report.StatusSkipped))
+	})
+
+	It("marshals to json", func() {
+		out, err := r.Marshal(report.JsonFormat)
+		Expect(err).To(BeNil())
+		deserialized := &report.Report{}
+		Expect(json.Unmarshal([]byte(out), deserialized)).To(BeNil())
+		Expect(deserialized.Steps).To(HaveLen(3))
+		Expect(deserialized.Steps[0].Id).To(Equal("deploy"))
+		Expect(deserialized.Steps[0].Description).To(Equal("Running command: kubectl apply -f petclinic.yaml"))
+	})
+
+	It("marshals to junit with one test case per step", func() {
+		out, err := r.Marshal(report.JunitFormat)
+		Expect(err).To(BeNil())
+		Expect(out).To(ContainSubstring(`<testsuite name="workflow.yaml" tests="3" failures="1" skipped="1"`))
+		Expect(out).To(ContainSubstring(`<testcase name="steps[0] deploy (apply)" classname="workflow.steps"`))
+		Expect(out).To(ContainSubstring(`<failure message="Curl got unexpected status code 503">`))
+		Expect(out).To(ContainSubstring(`<testcase name="steps[2] vs-2 (apply)" classname="workflow.steps" time="0.000">`))
+	})
+
+	It("marshals the reports of a matrix with a test suite for each combination", func() {
+		r.Values = map[string]interface{}{"GlooVersion": "1.3.17", "ClusterType": "kind"}
+		other := report.New("workflow.yaml")
+		other.Values = map[string]interface{}{"GlooVersion": "1.4.0", "ClusterType": "kind"}
+		other.StartStep("steps", 0, "deploy", "apply").Finish(nil)
+		other.Finish(nil)
+		reports := report.Reports{r, other}
+
+		out, err := reports.Marshal(report.JunitFormat)
+		Expect(err).To(BeNil())
+		Expect(out).To(ContainSubstring(`<testsuite name="workflow.yaml [ClusterType=kind, GlooVersion=1.3.17]" tests="3" failures="1"`))
+		Expect(out).To(ContainSubstring(`<testsuite name="workflow.yaml [ClusterType=kind, GlooVersion=1.4.0]" tests="1" failures="0"`))
+
+		out, err = reports.Marshal(report.JsonFormat)
+		Expect(err).To(BeNil())
+		var deserialized []*report.Report
+		Expect(json.Unmarshal([]byte(out), &deserialized)).To(BeNil())
+		Expect(deserialized).To(HaveLen(2))
+		Expect(deserialized[1].Values).To(HaveKeyWithValue("GlooVersion", "1.4.0"))
+		Expect(deserialized[1].Status).To(Equal(report.StatusPassed))
+	})
+
+	It("records the outputs of a step", func() {
+		destroyed := r.StartStep("cleanup", 0, "", "destroyCluster")
+		destroyed.SetOutputs(map[string]string{"removed": "true"})
+		destroyed.Finish(nil)
+		out, err := r.Marshal(report.JsonFormat)
+		Expect(err).To(BeNil())
+		deserialized := &report.Report{}
+		Expect(json.Unmarshal([]byte(out), deserialized)).To(BeNil())
+		Expect(deserialized.Steps[3].Outputs).To(Equal(map[string]string{"removed": "true"}))
+		out, err = r.Marshal(report.JunitFormat)
+		Expect(err).To(BeNil())
+		Expect(out).To(ContainSubstring("<system-out>removed: true</system-out>"))
+	})
+
+	It("returns an error for an unknown format", func() {
+		_, err := r.Marshal("csv")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("ignores a nil report", func() {
+		var nilReport *report.Report
+		result := nilReport.StartStep("steps", 0, "", "apply")
+		result.SetDescription("description")
+		result.SetOutputs(map[string]string{"revision": "1"})
+		result.Finish(nil)
+		nilReport.SkipStep("steps", 1, "", "apply")
+		nilReport.Finish(nil)
+		Expect(result).To(BeNil())
+	})
+})
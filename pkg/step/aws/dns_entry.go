@@ -52,9 +52,10 @@ func (d *DnsEntry) Run(ctx *api.WorkflowContext, values render.Values) error {
 	if err := ctx.AwsDnsClient.CreateMapping(ctx.Ctx, d.HostedZone, d.Domain, ip); err != nil {
 		return UnableToCreateDnsMappingError(err)
 	}
+	ctx.SetOutput("ip", ip)
 	return nil
 }
 
 func (d *DnsEntry) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
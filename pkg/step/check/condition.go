@@ -19,6 +19,11 @@ var (
 	ConditionNotMetError = errors.Errorf("Condition wasn't met")
 )
 
+var (
+	_ api.Step           = new(Condition)
+	_ api.ValidatingStep = new(Condition)
+)
+
 type Condition struct {
 	Type      string `json:"type"`
 	Name      string `json:"name" valet:"template"`
@@ -33,7 +38,7 @@ func (c *Condition) GetDescription(ctx *api.WorkflowContext, values render.Value
 	if err := values.RenderFields(c, ctx.Runner); err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("Waiting for the following command to return '%s': %s", c.Value, c.GetCmd().ToString()), nil
+	return fmt.Sprintf("Waiting for the following command to return '%s': %s", c.Value, c.GetCmd(ctx).ToString()), nil
 }
 
 func (c *Condition) Run(ctx *api.WorkflowContext, values render.Values) error {
@@ -57,6 +62,8 @@ func (c *Condition) Run(ctx *api.WorkflowContext, values render.Values) error {
 		select {
 		case <-timeout:
 			return ConditionNotMetError
+		case <-ctx.GetContext().Done():
+			return ctx.GetContext().Err()
 		case <-tick:
 			if c.conditionMet(ctx) {
 				return nil
@@ -65,25 +72,33 @@ func (c *Condition) Run(ctx *api.WorkflowContext, values render.Values) error {
 	}
 }
 
+func (c *Condition) Validate(_ *api.WorkflowContext, _ render.Values) error {
+	if err := render.ValidateDuration("timeout", c.Timeout); err != nil {
+		return err
+	}
+	return render.ValidateDuration("interval", c.Interval)
+}
+
 func (c *Condition) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
 
 func (c *Condition) conditionMet(ctx *api.WorkflowContext) bool {
-	out, err := ctx.Runner.Output(c.GetCmd())
+	out, err := ctx.Runner.Output(c.GetCmd(ctx))
 	if err != nil {
-		cmd.Stderr().Println("Error checking condition: %v", err)
+		ctx.GetLogger().Warnf("Error checking condition: %v", err)
 		return false
 	}
 	if out == c.Value {
-		cmd.Stdout().Println("Condition met!")
+		ctx.SetOutput("value", out)
+		ctx.GetLogger().Infof("Condition met!")
 		return true
 	}
 	return false
 }
 
-func (c *Condition) GetCmd() *cmd.Command {
-	return cmd.New().Kubectl().
+func (c *Condition) GetCmd(ctx *api.WorkflowContext) *cmd.Command {
+	return ctx.Kubectl().
 		With("get", c.Type, c.Name).
 		Namespace(c.Namespace).
 		OutJsonpath(c.Jsonpath).Cmd()
@@ -98,7 +98,7 @@ var _ = Describe("condition", func() {
 		)
 
 		BeforeEach(func() {
-			values = make(map[string]string)
+			values = make(render.Values)
 			values[nameKey] = name
 			values[timeoutKey] = timeout
 			values[intervalKey] = interval
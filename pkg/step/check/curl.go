@@ -1,6 +1,8 @@
 package check
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"github.com/avast/retry-go"
 	errors "github.com/rotisserie/eris"
@@ -8,7 +10,11 @@ import (
 	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
 	"io"
+	"k8s.io/client-go/util/jsonpath"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -27,6 +33,27 @@ var (
 	UnexpectedResponseBodyError = func(responseBody string) error {
 		return errors.Errorf("Curl got unexpected response body:\n%s", responseBody)
 	}
+	MissingResponseHeaderError = func(name string) error {
+		return errors.Errorf("Curl got no response header %s", name)
+	}
+	UnexpectedResponseHeaderError = func(name, value string) error {
+		return errors.Errorf("Curl got unexpected value for response header %s: %s", name, value)
+	}
+	UnexpectedJsonPathError = func(path, value string) error {
+		return errors.Errorf("Curl got unexpected value for %s: %s", path, value)
+	}
+	InvalidJsonResponseError = func(err error, responseBody string) error {
+		return errors.Wrapf(err, "Curl got a response body that isn't valid JSON:\n%s", responseBody)
+	}
+	InvalidJsonPathError = func(path string, err error) error {
+		return errors.Wrapf(err, "Invalid jsonpath %s", path)
+	}
+	InvalidRegexError = func(field, regex string, err error) error {
+		return errors.Wrapf(err, "Invalid regex for %s: %s", field, regex)
+	}
+	ResponseTooSlowError = func(latency, maxLatency time.Duration) error {
+		return errors.Errorf("Curl took %s, longer than the max latency of %s", latency, maxLatency)
+	}
 )
 
 // Use Curl to simulate testing an endpoint with an HTTP request using curl.
@@ -41,25 +68,41 @@ var (
 //
 // The request can be customized with the path, host, headers, and requestBody fields.
 //
-// The response can be validated with the statusCode, responseBody, and responseBodySubstring fields.
+// The response can be validated with the statusCode, responseBody, and responseBodySubstring fields. The body can
+// also be matched with responseBodyRegex, or required not to contain responseBodyNotSubstring. responseHeaders and
+// jsonPath check the headers of the response, and the values of jsonpath expressions (i.e. "{.error.code}") against a
+// JSON response body. maxLatency fails attempts that take too long to respond.
+//
+// Each request times out after requestTimeout, which defaults to 1 second, or to maxLatency if that is longer.
 //
 // Curl will by default try 10 times if the validation criteria isn't met for any reason, with a delay
 // of 1 second between attempt. Customize these with the attempts and delay fields.
 type Curl struct {
-	Path                  string            `json:"path,omitempty"`
-	Host                  string            `json:"host,omitempty"`
-	Headers               map[string]string `json:"headers,omitempty"`
-	StatusCode            int               `json:"statusCode,omitempty" valet:"default=200"`
-	Method                string            `json:"method,omitempty" valet:"default=GET"`
-	RequestBody           string            `json:"body,omitempty"`
-	ResponseBody          string            `json:"responseBody,omitempty"`
-	ResponseBodySubstring string            `json:"responseBodySubstring,omitempty"`
-	Service               *ServiceRef       `json:"service,omitempty"`
-	PortForward           *PortForward      `json:"portForward,omitempty"`
-	Attempts              int               `json:"attempts,omitempty" valet:"default=10"`
-	Delay                 string            `json:"delay,omitempty" valet:"default=1s"`
+	Path                     string              `json:"path,omitempty" valet:"template"`
+	Host                     string              `json:"host,omitempty"`
+	Headers                  map[string]string   `json:"headers,omitempty"`
+	StatusCode               int                 `json:"statusCode,omitempty" valet:"default=200"`
+	Method                   string              `json:"method,omitempty" valet:"default=GET"`
+	RequestBody              string              `json:"body,omitempty"`
+	ResponseBody             string              `json:"responseBody,omitempty"`
+	ResponseBodySubstring    string              `json:"responseBodySubstring,omitempty"`
+	ResponseBodyRegex        string              `json:"responseBodyRegex,omitempty"`
+	ResponseBodyNotSubstring string              `json:"responseBodyNotSubstring,omitempty"`
+	ResponseHeaders          []HeaderAssertion   `json:"responseHeaders,omitempty"`
+	JsonPath                 []JsonPathAssertion `json:"jsonPath,omitempty"`
+	MaxLatency               string              `json:"maxLatency,omitempty"`
+	RequestTimeout           string              `json:"requestTimeout,omitempty"`
+	Service                  *ServiceRef         `json:"service,omitempty"`
+	PortForward              *PortForward        `json:"portForward,omitempty"`
+	Attempts                 int                 `json:"attempts,omitempty" valet:"default=10"`
+	Delay                    string              `json:"delay,omitempty" valet:"default=1s"`
 }
 
+var (
+	_ api.Step           = new(Curl)
+	_ api.ValidatingStep = new(Curl)
+)
+
 func (c *Curl) Run(ctx *api.WorkflowContext, values render.Values) error {
 	if err := values.RenderFields(c, ctx.Runner); err != nil {
 		return err
@@ -88,11 +131,55 @@ func (c *Curl) GetDescription(ctx *api.WorkflowContext, values render.Values) (s
 	} else if c.ResponseBodySubstring != "" {
 		str += fmt.Sprintf("\nExpected response substring: %s", c.ResponseBodySubstring)
 	}
+	if c.ResponseBodyRegex != "" {
+		str += fmt.Sprintf("\nExpected response matching: %s", c.ResponseBodyRegex)
+	}
+	if c.ResponseBodyNotSubstring != "" {
+		str += fmt.Sprintf("\nExpected response not containing: %s", c.ResponseBodyNotSubstring)
+	}
+	for _, header := range c.ResponseHeaders {
+		str += fmt.Sprintf("\nExpected header: %s", header.describe(header.Name))
+	}
+	for _, path := range c.JsonPath {
+		str += fmt.Sprintf("\nExpected json: %s", path.describe(path.Path))
+	}
+	if c.MaxLatency != "" {
+		str += fmt.Sprintf("\nExpected latency under: %s", c.MaxLatency)
+	}
 	return str, nil
 }
 
 func (c *Curl) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
+}
+
+func (c *Curl) Validate(_ *api.WorkflowContext, _ render.Values) error {
+	if err := render.ValidateDuration("delay", c.Delay); err != nil {
+		return err
+	}
+	if err := render.ValidateDuration("maxLatency", c.MaxLatency); err != nil {
+		return err
+	}
+	if err := render.ValidateDuration("requestTimeout", c.RequestTimeout); err != nil {
+		return err
+	}
+	if err := validateRegex("responseBodyRegex", c.ResponseBodyRegex); err != nil {
+		return err
+	}
+	for _, header := range c.ResponseHeaders {
+		if err := validateRegex("responseHeaders", header.Regex); err != nil {
+			return err
+		}
+	}
+	for _, path := range c.JsonPath {
+		if _, err := parseJsonPath(path.Path); err != nil {
+			return err
+		}
+		if err := validateRegex("jsonPath", path.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *Curl) doCurl(ctx *api.WorkflowContext, values render.Values) error {
@@ -100,6 +187,42 @@ func (c *Curl) doCurl(ctx *api.WorkflowContext, values render.Values) error {
 	if err != nil {
 		return err
 	}
+	var maxLatency time.Duration
+	if c.MaxLatency != "" {
+		if maxLatency, err = time.ParseDuration(c.MaxLatency); err != nil {
+			return err
+		}
+	}
+	// A response that takes longer than the max latency should fail the latency check, rather than time out first
+	requestTimeout := cmd.DefaultRequestTimeout
+	if maxLatency > requestTimeout {
+		requestTimeout = maxLatency
+	}
+	if c.RequestTimeout != "" {
+		if requestTimeout, err = time.ParseDuration(c.RequestTimeout); err != nil {
+			return err
+		}
+	}
+	// Invalid regexes and jsonpath expressions would fail every attempt, so fail before the first one
+	if err := c.Validate(ctx, values); err != nil {
+		return err
+	}
+	var bodyRegex *regexp.Regexp
+	if c.ResponseBodyRegex != "" {
+		if bodyRegex, err = regexp.Compile(c.ResponseBodyRegex); err != nil {
+			return InvalidRegexError("responseBodyRegex", c.ResponseBodyRegex, err)
+		}
+	}
+	for i := range c.ResponseHeaders {
+		if err := c.ResponseHeaders[i].compile("responseHeaders"); err != nil {
+			return err
+		}
+	}
+	for i := range c.JsonPath {
+		if err := c.JsonPath[i].compile("jsonPath"); err != nil {
+			return err
+		}
+	}
 	fullUrl, err := c.GetUrl(ctx, values)
 	if err != nil {
 		return err
@@ -117,18 +240,24 @@ func (c *Curl) doCurl(ctx *api.WorkflowContext, values render.Values) error {
 			_ = handler.StreamHelper(nil)
 		}()
 
-		cmd.Stdout().Println("Initiated port forward")
+		ctx.GetLogger().Infof("Initiated port forward")
 	}
 
+	runner := cmd.RunnerWithRequestTimeout(ctx.Runner, requestTimeout)
 	curlErr := retry.Do(func() error {
 		req, err := c.GetHttpRequest(fullUrl)
 		if err != nil {
 			return err
 		}
-		responseBody, statusCode, err := ctx.Runner.Request(req)
-		if err != nil {
+		start := time.Now()
+		resp, err := runner.Request(req)
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() && maxLatency > 0 && time.Since(start) >= maxLatency {
+			return ResponseTooSlowError(time.Since(start), maxLatency)
+		} else if err != nil {
 			return err
 		}
+		latency := time.Since(start)
+		responseBody, statusCode := resp.Body, resp.StatusCode
 		if c.StatusCode != statusCode {
 			return UnexpectedStatusCodeError(statusCode)
 		}
@@ -139,10 +268,37 @@ func (c *Curl) doCurl(ctx *api.WorkflowContext, values render.Values) error {
 		if c.ResponseBodySubstring != "" && !strings.Contains(strings.TrimSpace(responseBody), strings.TrimSpace(c.ResponseBodySubstring)) {
 			return UnexpectedResponseBodyError(responseBody)
 		}
+		if c.ResponseBodyNotSubstring != "" && strings.Contains(responseBody, strings.TrimSpace(c.ResponseBodyNotSubstring)) {
+			return UnexpectedResponseBodyError(responseBody)
+		}
+		if bodyRegex != nil && !bodyRegex.MatchString(responseBody) {
+			return UnexpectedResponseBodyError(responseBody)
+		}
+		for _, header := range c.ResponseHeaders {
+			if err := header.check(resp.Headers); err != nil {
+				return err
+			}
+		}
+		if len(c.JsonPath) > 0 {
+			if err := c.checkJsonPath(responseBody); err != nil {
+				return err
+			}
+		}
+		if maxLatency > 0 && latency > maxLatency {
+			return ResponseTooSlowError(latency, maxLatency)
+		}
 
-		cmd.Stdout().Println("Curl successful")
+		ctx.SetOutput("body", responseBody)
+		ctx.SetOutput("statusCode", strconv.Itoa(statusCode))
+		for name := range resp.Headers {
+			ctx.SetOutput("header:"+name, resp.Headers.Get(name))
+		}
+		ctx.GetLogger().Infof("Curl successful")
 		return nil
-	}, retry.Delay(delay), retry.Attempts(uint(c.Attempts)), retry.DelayType(retry.FixedDelay), retry.LastErrorOnly(true))
+	}, retry.Delay(delay), retry.Attempts(uint(c.Attempts)), retry.DelayType(retry.FixedDelay), retry.LastErrorOnly(true),
+		retry.RetryIf(func(error) bool {
+			return ctx.GetContext().Err() == nil
+		}))
 
 	if portForwardCmd != nil {
 		_ = ctx.Runner.Kill(portForwardCmd.Process.Process)
@@ -182,6 +338,148 @@ func (c *Curl) GetHttpRequest(url string) (*http.Request, error) {
 	return req, nil
 }
 
+func (c *Curl) checkJsonPath(responseBody string) error {
+	decoder := json.NewDecoder(strings.NewReader(responseBody))
+	// Keep numbers as they were written, rather than formatting them as floats
+	decoder.UseNumber()
+	var body interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return InvalidJsonResponseError(err, responseBody)
+	}
+	for _, assertion := range c.JsonPath {
+		if err := assertion.check(body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A ValueMatch checks a value in the response. By default, the value only has to be present. With value or regex, it
+// must also be equal to or match it, and with absent it must not be present at all. An empty value (value: "") requires
+// the value to be empty.
+type ValueMatch struct {
+	Value  *string `json:"value,omitempty"`
+	Regex  string  `json:"regex,omitempty"`
+	Absent bool    `json:"absent,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// Compile the regex once, rather than on every attempt.
+func (m *ValueMatch) compile(field string) error {
+	if m.Regex == "" || m.regex != nil {
+		return nil
+	}
+	regex, err := regexp.Compile(m.Regex)
+	if err != nil {
+		return InvalidRegexError(field, m.Regex, err)
+	}
+	m.regex = regex
+	return nil
+}
+
+// Returns true if a value that was found in the response (or not) matches. The error is only set for an invalid regex.
+func (m *ValueMatch) match(field, value string, found bool) (bool, error) {
+	if m.Absent || !found {
+		return m.Absent != found, nil
+	}
+	if m.Value != nil && value != *m.Value {
+		return false, nil
+	}
+	if err := m.compile(field); err != nil {
+		return false, err
+	}
+	if m.regex != nil {
+		return m.regex.MatchString(value), nil
+	}
+	return true, nil
+}
+
+func (m *ValueMatch) describe(name string) string {
+	switch {
+	case m.Absent:
+		return fmt.Sprintf("%s absent", name)
+	case m.Value != nil:
+		return fmt.Sprintf("%s = %q", name, *m.Value)
+	case m.Regex != "":
+		return fmt.Sprintf("%s matching %s", name, m.Regex)
+	}
+	return fmt.Sprintf("%s present", name)
+}
+
+// A HeaderAssertion checks a header of the response, i.e. that "x-envoy-ratelimited" is present.
+type HeaderAssertion struct {
+	Name string `json:"name,omitempty"`
+	ValueMatch
+}
+
+func (h *HeaderAssertion) check(headers http.Header) error {
+	values, found := headers[http.CanonicalHeaderKey(h.Name)]
+	value := strings.Join(values, ",")
+	matched, err := h.match("responseHeaders", value, found)
+	if err != nil {
+		return err
+	}
+	if matched {
+		return nil
+	}
+	if !found {
+		return MissingResponseHeaderError(h.Name)
+	}
+	return UnexpectedResponseHeaderError(h.Name, value)
+}
+
+// A JsonPathAssertion checks the value of a jsonpath expression against a JSON response body, i.e. that
+// "{.error.code}" is 429. Expressions use the same syntax as kubectl, and the braces are optional.
+type JsonPathAssertion struct {
+	Path string `json:"path,omitempty"`
+	ValueMatch
+}
+
+func (j *JsonPathAssertion) check(body interface{}) error {
+	parser, err := parseJsonPath(j.Path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	// A path that isn't in the body is treated as absent, rather than an error
+	found := parser.Execute(&buf, body) == nil
+	value := buf.String()
+	matched, err := j.match("jsonPath", value, found)
+	if err != nil {
+		return err
+	}
+	if matched {
+		return nil
+	}
+	if !found {
+		return UnexpectedJsonPathError(j.Path, "<missing>")
+	}
+	return UnexpectedJsonPathError(j.Path, value)
+}
+
+func parseJsonPath(path string) (*jsonpath.JSONPath, error) {
+	expression := path
+	if !strings.HasPrefix(expression, "{") {
+		expression = fmt.Sprintf("{%s}", expression)
+	}
+	parser := jsonpath.New(path)
+	if err := parser.Parse(expression); err != nil {
+		return nil, InvalidJsonPathError(path, err)
+	}
+	return parser, nil
+}
+
+func validateRegex(field, regex string) error {
+	if regex == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(regex); err != nil {
+		return InvalidRegexError(field, regex, err)
+	}
+	return nil
+}
+
 type PortForward struct {
 	Namespace      string `json:"namespace,omitempty" valet:"key=Namespace"`
 	DeploymentName string `json:"deploymentName,omitempty"`
@@ -191,7 +489,7 @@ type PortForward struct {
 func (p *PortForward) Initiate(ctx *api.WorkflowContext, values render.Values) (*cmd.CommandStreamHandler, error) {
 	port := fmt.Sprintf("%d", p.Port)
 	deployment := fmt.Sprintf("deploy/%s", p.DeploymentName)
-	kubectl := cmd.New().Kubectl().With("port-forward").Namespace(p.Namespace).With(deployment, port).Cmd()
+	kubectl := ctx.Kubectl().With("port-forward").Namespace(p.Namespace).With(deployment, port).Cmd()
 	return ctx.Runner.Stream(kubectl)
 }
 
@@ -1,6 +1,7 @@
 package check_test
 
 import (
+	"github.com/ghodss/yaml"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,9 +12,11 @@ import (
 	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/step/check"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 var _ = Describe("apply", func() {
@@ -58,7 +61,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := http.NewRequest(check.DefaultMethod, "http://host/path", nil)
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "", StatusCode: 200}, nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).To(BeNil())
 	})
@@ -74,7 +77,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := http.NewRequest(check.DefaultMethod, "http://host/path", nil)
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("", 503, nil).Times(10)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "", StatusCode: 503}, nil).Times(10)
 		err = curl.Run(ctx, nil)
 		Expect(err).NotTo(BeNil())
 		Expect(err.Error()).To(Equal(check.UnexpectedStatusCodeError(503).Error()))
@@ -93,7 +96,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := http.NewRequest(check.DefaultMethod, "http://host/path", nil)
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("bar", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "bar", StatusCode: 200}, nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).NotTo(BeNil())
 		Expect(err.Error()).To(Equal(check.UnexpectedResponseBodyError("bar").Error()))
@@ -110,7 +113,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := curl.GetHttpRequest("http://host/path")
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("bar", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "bar", StatusCode: 200}, nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).To(BeNil())
 	})
@@ -126,7 +129,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := curl.GetHttpRequest("http://host/path")
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("barfoo", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "barfoo", StatusCode: 200}, nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).To(BeNil())
 	})
@@ -140,7 +143,7 @@ var _ = Describe("apply", func() {
 		kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
 		req, err := curl.GetHttpRequest("http://host/path")
 		Expect(err).To(BeNil())
-		runner.EXPECT().Request(req).Return("", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "", StatusCode: 200}, nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).To(BeNil())
 	})
@@ -168,7 +171,7 @@ var _ = Describe("apply", func() {
 			WaitFunc: func() error { return nil },
 		}
 		runner.EXPECT().Stream(cmd.New().Kubectl().With("port-forward", "-n", "ns", "deploy/dep", "1234").Cmd()).Return(handler, nil)
-		runner.EXPECT().Request(req).Return("barfoo", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "barfoo", StatusCode: 200}, nil).Times(1)
 		runner.EXPECT().Kill(process).Return(nil).Times(1)
 		err = curl.Run(ctx, nil)
 		Expect(err).To(BeNil())
@@ -198,9 +201,215 @@ var _ = Describe("apply", func() {
 			WaitFunc: func() error { return nil },
 		}
 		runner.EXPECT().Stream(cmd.New().Kubectl().With("port-forward", "-n", "ns", "deploy/dep", "8080").Cmd()).Return(handler, nil)
-		runner.EXPECT().Request(req).Return("barfoo", 200, nil).Times(1)
+		runner.EXPECT().Request(req).Return(&cmd.HttpResponse{Body: "barfoo", StatusCode: 200}, nil).Times(1)
 		runner.EXPECT().Kill(process).Return(nil).Times(1)
 		err = curl.Run(ctx, values)
 		Expect(err).To(BeNil())
 	})
+
+	Context("response assertions", func() {
+		var (
+			curl     check.Curl
+			response *cmd.HttpResponse
+		)
+
+		BeforeEach(func() {
+			curl = check.Curl{
+				Path:       path,
+				Host:       host,
+				Service:    gatewayProxySvc,
+				StatusCode: 429,
+				Attempts:   1,
+			}
+			response = &cmd.HttpResponse{
+				Body:       `{"error": {"code": 429, "message": "Too many requests"}}`,
+				StatusCode: 429,
+				Headers:    http.Header{"X-Envoy-Ratelimited": []string{"true"}},
+			}
+		})
+
+		value := func(value string) *string {
+			return &value
+		}
+
+		run := func() error {
+			kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
+			req, err := curl.GetHttpRequest("http://host/path")
+			Expect(err).To(BeNil())
+			runner.EXPECT().Request(req).Return(response, nil).Times(1)
+			return curl.Run(ctx, nil)
+		}
+
+		It("checks response headers are present, equal or match a regex", func() {
+			curl.ResponseHeaders = []check.HeaderAssertion{
+				{Name: "x-envoy-ratelimited"},
+				{Name: "x-envoy-ratelimited", ValueMatch: check.ValueMatch{Value: value("true")}},
+				{Name: "x-envoy-ratelimited", ValueMatch: check.ValueMatch{Regex: "^t"}},
+				{Name: "x-request-id", ValueMatch: check.ValueMatch{Absent: true}},
+			}
+			Expect(run()).To(BeNil())
+		})
+
+		It("fails when a response header is missing", func() {
+			curl.ResponseHeaders = []check.HeaderAssertion{{Name: "x-request-id"}}
+			Expect(run()).To(MatchError(check.MissingResponseHeaderError("x-request-id").Error()))
+		})
+
+		It("fails when a response header has the wrong value", func() {
+			curl.ResponseHeaders = []check.HeaderAssertion{{Name: "x-envoy-ratelimited", ValueMatch: check.ValueMatch{Value: value("false")}}}
+			Expect(run()).To(MatchError(check.UnexpectedResponseHeaderError("x-envoy-ratelimited", "true").Error()))
+		})
+
+		It("checks that a response header is empty", func() {
+			response.Headers.Set("x-envoy-upstream", "")
+			curl.ResponseHeaders = []check.HeaderAssertion{{Name: "x-envoy-upstream", ValueMatch: check.ValueMatch{Value: value("")}}}
+			Expect(run()).To(BeNil())
+		})
+
+		It("fails when a response header should be empty", func() {
+			curl.ResponseHeaders = []check.HeaderAssertion{{Name: "x-envoy-ratelimited", ValueMatch: check.ValueMatch{Value: value("")}}}
+			Expect(run()).To(MatchError(check.UnexpectedResponseHeaderError("x-envoy-ratelimited", "true").Error()))
+		})
+
+		It("reads an empty value as a check for an empty value", func() {
+			var assertion check.HeaderAssertion
+			Expect(yaml.Unmarshal([]byte("name: x-envoy-upstream\nvalue: \"\"\n"), &assertion)).To(BeNil())
+			Expect(assertion.Value).To(Equal(value("")))
+		})
+
+		It("fails when a response header should be absent", func() {
+			curl.ResponseHeaders = []check.HeaderAssertion{{Name: "x-envoy-ratelimited", ValueMatch: check.ValueMatch{Absent: true}}}
+			Expect(run()).To(MatchError(check.UnexpectedResponseHeaderError("x-envoy-ratelimited", "true").Error()))
+		})
+
+		It("checks the response body with a regex and negative substring", func() {
+			curl.ResponseBodyRegex = `"code":\s*429`
+			curl.ResponseBodyNotSubstring = "Internal"
+			Expect(run()).To(BeNil())
+		})
+
+		It("fails when the response body contains the negative substring", func() {
+			curl.ResponseBodyNotSubstring = "Too many"
+			Expect(run()).To(MatchError(check.UnexpectedResponseBodyError(response.Body).Error()))
+		})
+
+		It("fails when the response body doesn't match the regex", func() {
+			curl.ResponseBodyRegex = `"code":\s*503`
+			Expect(run()).To(MatchError(check.UnexpectedResponseBodyError(response.Body).Error()))
+		})
+
+		It("checks jsonpath expressions against the response body", func() {
+			curl.JsonPath = []check.JsonPathAssertion{
+				{Path: "{.error.code}", ValueMatch: check.ValueMatch{Value: value("429")}},
+				{Path: ".error.message", ValueMatch: check.ValueMatch{Regex: "(?i)too many"}},
+				{Path: ".error"},
+				{Path: ".data", ValueMatch: check.ValueMatch{Absent: true}},
+			}
+			Expect(run()).To(BeNil())
+		})
+
+		It("fails when a jsonpath expression has the wrong value", func() {
+			curl.JsonPath = []check.JsonPathAssertion{{Path: ".error.code", ValueMatch: check.ValueMatch{Value: value("503")}}}
+			Expect(run()).To(MatchError(check.UnexpectedJsonPathError(".error.code", "429").Error()))
+		})
+
+		It("fails when a jsonpath expression is missing", func() {
+			curl.JsonPath = []check.JsonPathAssertion{{Path: ".data"}}
+			Expect(run()).To(MatchError(check.UnexpectedJsonPathError(".data", "<missing>").Error()))
+		})
+
+		It("fails when the response body isn't JSON", func() {
+			curl.JsonPath = []check.JsonPathAssertion{{Path: ".error.code"}}
+			response.Body = "Too many requests"
+			err := run()
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("Curl got a response body that isn't valid JSON"))
+		})
+
+		It("fails when the response is slower than the max latency", func() {
+			curl.MaxLatency = "1ms"
+			kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(host, nil).Times(1)
+			runner.EXPECT().Request(gomock.Any()).DoAndReturn(func(_ *http.Request) (*cmd.HttpResponse, error) {
+				time.Sleep(10 * time.Millisecond)
+				return response, nil
+			}).Times(1)
+			err := curl.Run(ctx, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("longer than the max latency of 1ms"))
+		})
+
+		It("fails before the first attempt when the response body regex is invalid", func() {
+			curl.Attempts = 3
+			curl.ResponseBodyRegex = "("
+			err := curl.Run(ctx, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("Invalid regex for responseBodyRegex"))
+		})
+
+		It("validates regexes, jsonpath expressions and the max latency", func() {
+			Expect(curl.Validate(ctx, nil)).To(BeNil())
+			invalid := curl
+			invalid.ResponseBodyRegex = "("
+			Expect(invalid.Validate(ctx, nil).Error()).To(ContainSubstring("Invalid regex for responseBodyRegex"))
+			invalid = curl
+			invalid.JsonPath = []check.JsonPathAssertion{{Path: "{.error"}}
+			Expect(invalid.Validate(ctx, nil).Error()).To(ContainSubstring("Invalid jsonpath {.error"))
+			invalid = curl
+			invalid.MaxLatency = "fast"
+			Expect(invalid.Validate(ctx, nil)).To(MatchError(render.InvalidDurationError("maxLatency", "fast").Error()))
+			invalid = curl
+			invalid.RequestTimeout = "slow"
+			Expect(invalid.Validate(ctx, nil)).To(MatchError(render.InvalidDurationError("requestTimeout", "slow").Error()))
+		})
+	})
+
+	Context("request timeout", func() {
+		var (
+			server *httptest.Server
+			curl   check.Curl
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				delay, _ := time.ParseDuration(r.URL.Query().Get("delay"))
+				time.Sleep(delay)
+			}))
+			ctx.Runner = cmd.DefaultCommandRunner()
+			kubeClient.EXPECT().GetIngressAddress(svcName, svcNs, svcPort).Return(strings.TrimPrefix(server.URL, "http://"), nil)
+			curl = check.Curl{
+				Service:    gatewayProxySvc,
+				StatusCode: 200,
+				Method:     check.DefaultMethod,
+				Attempts:   1,
+				Delay:      "1ms",
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("waits for responses up to the max latency", func() {
+			curl.Path = "/?delay=1200ms"
+			curl.MaxLatency = "3s"
+			Expect(curl.Run(ctx, nil)).To(BeNil())
+		})
+
+		It("fails responses that take longer than the request timeout", func() {
+			curl.Path = "/?delay=200ms"
+			curl.RequestTimeout = "20ms"
+			err := curl.Run(ctx, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("Client.Timeout exceeded"))
+		})
+
+		It("fails responses that time out after the max latency as too slow", func() {
+			curl.Path = "/?delay=200ms"
+			curl.MaxLatency = "10ms"
+			curl.RequestTimeout = "20ms"
+			err := curl.Run(ctx, nil)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("longer than the max latency of 10ms"))
+		})
+	})
 })
@@ -2,25 +2,88 @@ package check
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
 	"github.com/solo-io/valet/pkg/render"
 )
 
+const (
+	DefaultWaitForPodsTimeout  = "300s"
+	DefaultWaitForPodsInterval = "500ms"
+)
+
+var (
+	_ api.Step           = new(WaitForPods)
+	_ api.ValidatingStep = new(WaitForPods)
+)
+
 // check.WaitForPods is a workflow step that is used to pause a workflow until
 // the pods in a namespace are ready or completed successfully.
+//
+// If the pods aren't ready before the timeout, the events and last container logs of the
+// pods that aren't ready are logged.
 type WaitForPods struct {
 	// Namespace to check for pods
-	Namespace string `json:"namespace,omitempty"`
+	Namespace string `json:"namespace,omitempty" valet:"template"`
+	// Optional, only wait for pods that match this label selector (i.e. "app=petclinic")
+	Selector string `json:"selector,omitempty" valet:"template"`
+	// Optional, prefixes of the names of pods to ignore (i.e. the pods of a job that is expected to fail)
+	Ignore []string `json:"ignore,omitempty" valet:"template"`
+	// Optional, keep waiting until there are at least this many pods
+	MinPods int `json:"minPods,omitempty"`
+	// Optional, fail as soon as a container can't start (i.e. CrashLoopBackOff or ImagePullBackOff)
+	FailFast bool   `json:"failFast,omitempty"`
+	Timeout  string `json:"timeout,omitempty" valet:"template,default=300s"`
+	// Optional, how often to check the pods
+	Interval string `json:"interval,omitempty" valet:"template,default=500ms"`
 }
 
-func (w *WaitForPods) GetDescription(_ *api.WorkflowContext, _ render.Values) (string, error) {
+func (w *WaitForPods) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if err := values.RenderFields(w, ctx.Runner); err != nil {
+		return "", err
+	}
+	if w.Selector != "" {
+		return fmt.Sprintf("Waiting for pods matching %s in namespace %s", w.Selector, w.Namespace), nil
+	}
 	return fmt.Sprintf("Waiting for pods in namespace %s", w.Namespace), nil
 }
 
-func (w *WaitForPods) Run(ctx *api.WorkflowContext, _ render.Values) error {
-	return ctx.KubeClient.WaitUntilPodsRunning(w.Namespace)
+func (w *WaitForPods) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if err := values.RenderFields(w, ctx.Runner); err != nil {
+		return err
+	}
+	timeout, err := time.ParseDuration(w.Timeout)
+	if err != nil {
+		return err
+	}
+	interval, err := time.ParseDuration(w.Interval)
+	if err != nil {
+		return err
+	}
+	return ctx.KubeClient.WaitForPods(kube.WaitForPodsOptions{
+		Namespace: w.Namespace,
+		Selector:  w.Selector,
+		Timeout:   timeout,
+		Interval:  interval,
+		MinPods:   w.MinPods,
+		FailFast:  w.FailFast,
+		Ignore:    w.Ignore,
+		Context:   ctx.Ctx,
+	})
+}
+
+func (w *WaitForPods) Validate(_ *api.WorkflowContext, _ render.Values) error {
+	if err := render.ValidateDuration("timeout", w.Timeout); err != nil {
+		return err
+	}
+	return render.ValidateDuration("interval", w.Interval)
 }
 
 func (w *WaitForPods) GetDocs(_ *api.WorkflowContext, _ render.Values, _ render.Flags) (string, error) {
+	if w.Selector != "" {
+		return fmt.Sprintf("Wait until the pods matching '%s' in namespace '%s' are ready. Use `kubectl get pods -n %s -l %s` to check the status.", w.Selector, w.Namespace, w.Namespace, w.Selector), nil
+	}
 	return fmt.Sprintf("Wait until the pods in namespace '%s' are ready. Use `kubectl get pods -n %s` to check the status.", w.Namespace, w.Namespace), nil
 }
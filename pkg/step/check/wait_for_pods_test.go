@@ -1,12 +1,16 @@
 package check_test
 
 import (
+	"time"
+
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
 	mockkube "github.com/solo-io/valet/pkg/client/kube/mocks"
 	mockcmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/step/check"
 )
 
@@ -38,10 +42,42 @@ var _ = Describe("wait_for_pods", func() {
 	})
 
 	It("works", func() {
-		kubeClient.EXPECT().WaitUntilPodsRunning(ns).Return(nil).Times(1)
+		kubeClient.EXPECT().WaitForPods(kube.WaitForPodsOptions{Namespace: ns, Timeout: 5 * time.Minute, Interval: 500 * time.Millisecond}).Return(nil).Times(1)
 		step := check.WaitForPods{ Namespace: ns }
 		err := step.Run(ctx, nil)
 		Expect(err).To(BeNil())
 	})
 
+	It("passes the selector, ignore list, minimum pods, fail fast and interval to the kube client", func() {
+		kubeClient.EXPECT().WaitForPods(kube.WaitForPodsOptions{
+			Namespace: ns,
+			Selector:  "app=petclinic",
+			Timeout:   time.Minute,
+			Interval:  time.Second,
+			MinPods:   2,
+			FailFast:  true,
+			Ignore:    []string{"petclinic-db-migrate"},
+		}).Return(kube.TimedOutWaitingForPodsError).Times(1)
+		step := check.WaitForPods{
+			Namespace: ns,
+			Selector:  "app={{ .App }}",
+			Ignore:    []string{"{{ .App }}-db-migrate"},
+			MinPods:   2,
+			FailFast:  true,
+			Timeout:   "1m",
+			Interval:  "{{ .Interval }}",
+		}
+		err := step.Run(ctx, render.Values{"App": "petclinic", "Interval": "1s"})
+		Expect(err).To(Equal(kube.TimedOutWaitingForPodsError))
+	})
+
+	It("validates the timeout and interval", func() {
+		step := check.WaitForPods{ Namespace: ns, Timeout: "soon" }
+		Expect(step.Validate(ctx, nil)).To(HaveOccurred())
+		step = check.WaitForPods{Namespace: ns, Interval: "often"}
+		Expect(step.Validate(ctx, nil)).To(HaveOccurred())
+		step = check.WaitForPods{Namespace: ns, Timeout: "{{ .Timeout }}", Interval: "2s"}
+		Expect(step.Validate(ctx, nil)).To(BeNil())
+	})
+
 })
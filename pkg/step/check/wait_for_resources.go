@@ -0,0 +1,268 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+const (
+	DefaultWaitForResourcesTimeout  = "300s"
+	DefaultWaitForResourcesInterval = "2s"
+)
+
+var (
+	// The kinds that are waited for when resources are selected with a label selector and no kinds are provided
+	DefaultWaitForResourcesKinds = []string{"deployments", "statefulsets", "daemonsets", "jobs", "services"}
+
+	InvalidWaitForResourcesError = errors.Errorf("waitForResources must provide exactly one of path, selector or resources")
+	InvalidResourceError         = func(resource string) error {
+		return errors.Errorf("Invalid resource %s, must be of the form kind/name", resource)
+	}
+	ResourcesNotReadyError = func(notReady int) error {
+		return errors.Errorf("Timed out waiting for %d resource(s) to be ready", notReady)
+	}
+	ResourceFailedError = func(readiness kube.Readiness) error {
+		return errors.Errorf("%s failed: %s", readiness.Resource.String(), readiness.Reason)
+	}
+)
+
+var (
+	_ api.Step           = new(WaitForResources)
+	_ api.ValidatingStep = new(WaitForResources)
+)
+
+// check.WaitForResources is a workflow step that pauses a workflow until resources are ready, using rules for the kind
+// of each resource (i.e. a deployment is ready when its rollout is complete, and a job when it completes). The
+// resources are the objects in a manifest (which can be a template), the objects that match a label selector, or a
+// list of kind/name pairs.
+//
+// The step fails as soon as a resource fails (i.e. a job fails or a pod can't pull its image). If the timeout is
+// reached, the resources that still aren't ready are printed in a table.
+type WaitForResources struct {
+	// The path to a manifest, i.e. one that was applied in an earlier step
+	Path string `json:"path,omitempty" valet:"template"`
+	// Or, a label selector (i.e. "app=petclinic") for resources of the provided kinds
+	Selector string `json:"selector,omitempty" valet:"template"`
+	// Or, a list of resources of the form kind/name (i.e. "deployment/petclinic")
+	Resources []string `json:"resources,omitempty" valet:"template"`
+
+	// Optional, the kinds of resources to select with the selector, defaults to DefaultWaitForResourcesKinds
+	Kinds []string `json:"kinds,omitempty"`
+	// Optional, the namespace of resources that don't set one, defaults to the namespace of the kube context
+	Namespace string `json:"namespace,omitempty" valet:"template"`
+	Timeout   string `json:"timeout,omitempty" valet:"template,default=300s"`
+	Interval  string `json:"interval,omitempty" valet:"template,default=2s"`
+}
+
+func (w *WaitForResources) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if err := values.RenderFields(w, ctx.Runner); err != nil {
+		return "", err
+	}
+	switch {
+	case w.Path != "":
+		return fmt.Sprintf("Waiting for the resources in %s to be ready", w.Path), nil
+	case w.Selector != "":
+		return fmt.Sprintf("Waiting for %s matching %s to be ready", strings.Join(w.getKinds(), ", "), w.Selector), nil
+	}
+	return fmt.Sprintf("Waiting for %s to be ready", strings.Join(w.Resources, ", ")), nil
+}
+
+func (w *WaitForResources) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if err := values.RenderFields(w, ctx.Runner); err != nil {
+		return err
+	}
+	timeoutDuration, err := time.ParseDuration(w.Timeout)
+	if err != nil {
+		return err
+	}
+	interval, err := time.ParseDuration(w.Interval)
+	if err != nil {
+		return err
+	}
+	refs, err := w.getRefs(ctx, values)
+	if err != nil {
+		return err
+	}
+	// The checker is created once, so each check reuses its dynamic client and RESTMapper
+	checker, err := ctx.KubeClient.NewResourceChecker()
+	if err != nil {
+		return err
+	}
+	timeout := time.After(timeoutDuration)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		// Resources selected by label are listed on each check, so resources created while waiting are included
+		if w.Selector != "" {
+			if refs, err = checker.ListResources(w.getKinds(), w.Namespace, w.Selector); err != nil {
+				return err
+			}
+		}
+		readiness, err := checker.GetReadiness(refs)
+		if err != nil {
+			return err
+		}
+		notReady := getNotReady(readiness)
+		for _, r := range notReady {
+			if r.Failed {
+				return ResourceFailedError(r)
+			}
+		}
+		// A selector that doesn't match anything yet waits for resources to be created
+		if len(notReady) == 0 && (w.Selector == "" || len(readiness) > 0) {
+			ctx.GetLogger().Infof("%d resource(s) ready", len(readiness))
+			return nil
+		}
+		select {
+		case <-timeout:
+			if len(readiness) == 0 {
+				ctx.GetLogger().Warnf("No %s match %s", strings.Join(w.getKinds(), ", "), w.Selector)
+				return ResourcesNotReadyError(0)
+			}
+			ctx.GetLogger().Warnf("Resources not ready:\n%s", FormatNotReady(notReady))
+			return ResourcesNotReadyError(len(notReady))
+		case <-ctx.GetContext().Done():
+			return ctx.GetContext().Err()
+		case <-tick.C:
+		}
+	}
+}
+
+func (w *WaitForResources) Validate(ctx *api.WorkflowContext, _ render.Values) error {
+	set := 0
+	for _, provided := range []bool{w.Path != "", w.Selector != "", len(w.Resources) > 0} {
+		if provided {
+			set++
+		}
+	}
+	if set != 1 {
+		return InvalidWaitForResourcesError
+	}
+	for _, resource := range w.Resources {
+		if _, err := parseResource(resource, ""); err != nil && !render.IsTemplate(resource) {
+			return err
+		}
+	}
+	if err := render.ValidateDuration("timeout", w.Timeout); err != nil {
+		return err
+	}
+	if err := render.ValidateDuration("interval", w.Interval); err != nil {
+		return err
+	}
+	if w.Path != "" {
+		return render.ValidateFile(ctx.FileStore, w.Path)
+	}
+	return nil
+}
+
+func (w *WaitForResources) GetDocs(_ *api.WorkflowContext, _ render.Values, _ render.Flags) (string, error) {
+	namespace := ""
+	if w.Namespace != "" {
+		namespace = fmt.Sprintf(" -n %s", w.Namespace)
+	}
+	switch {
+	case w.Path != "":
+		return fmt.Sprintf("Wait until the resources in %s are ready. Use `kubectl get -f %s` to check the status.", w.Path, w.Path), nil
+	case w.Selector != "":
+		return fmt.Sprintf("Wait until the resources matching '%s' are ready. Use `kubectl get %s -l %s%s` to check the status.",
+			w.Selector, strings.Join(w.getKinds(), ","), w.Selector, namespace), nil
+	}
+	return fmt.Sprintf("Wait until %s are ready. Use `kubectl get %s%s` to check the status.",
+		strings.Join(w.Resources, ", "), strings.Join(w.Resources, " "), namespace), nil
+}
+
+func (w *WaitForResources) getKinds() []string {
+	if len(w.Kinds) == 0 {
+		return DefaultWaitForResourcesKinds
+	}
+	return w.Kinds
+}
+
+// Returns the resources to wait for if they are provided by a manifest or a list. Resources selected by label are
+// listed when they are checked.
+func (w *WaitForResources) getRefs(ctx *api.WorkflowContext, values render.Values) ([]kube.ResourceRef, error) {
+	var refs []kube.ResourceRef
+	switch {
+	case w.Path != "":
+		manifests, err := ctx.FileStore.Load(w.Path)
+		if err != nil {
+			return nil, err
+		}
+		if render.IsTemplate(manifests) {
+			if manifests, err = render.LoadTemplate(manifests, values, ctx.Runner); err != nil {
+				return nil, err
+			}
+		}
+		objects, err := kube.ParseManifests(manifests)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			namespace := object.GetNamespace()
+			if namespace == "" {
+				namespace = w.Namespace
+			}
+			refs = append(refs, kube.ResourceRef{
+				APIVersion: object.GetAPIVersion(),
+				Kind:       object.GetKind(),
+				Namespace:  namespace,
+				Name:       object.GetName(),
+			})
+		}
+	case w.Selector == "":
+		for _, resource := range w.Resources {
+			ref, err := parseResource(resource, w.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, *ref)
+		}
+	}
+	return refs, nil
+}
+
+// Parse a resource of the form kind/name, i.e. "deployment/petclinic".
+func parseResource(resource, namespace string) (*kube.ResourceRef, error) {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, InvalidResourceError(resource)
+	}
+	return &kube.ResourceRef{
+		Kind:      parts[0],
+		Namespace: namespace,
+		Name:      parts[1],
+	}, nil
+}
+
+func getNotReady(readiness []kube.Readiness) []kube.Readiness {
+	var notReady []kube.Readiness
+	for _, r := range readiness {
+		if !r.Ready {
+			notReady = append(notReady, r)
+		}
+	}
+	return notReady
+}
+
+// Format resources that aren't ready as a table, i.e.
+//
+//	KIND         NAMESPACE   NAME        REASON
+//	Deployment   petclinic   petclinic   1 of 2 updated replicas available
+func FormatNotReady(notReady []kube.Readiness) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tREASON")
+	for _, r := range notReady {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Resource.Kind, r.Resource.Namespace, r.Resource.Name, r.Reason)
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,124 @@
+package check_test
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+	mockkube "github.com/solo-io/valet/pkg/client/kube/mocks"
+	mockcmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	mock_render "github.com/solo-io/valet/pkg/render/mocks"
+	"github.com/solo-io/valet/pkg/step/check"
+)
+
+var _ = Describe("wait_for_resources", func() {
+
+	const (
+		manifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: petclinic
+  namespace: {{ .Namespace }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: petclinic
+`
+	)
+
+	var (
+		ctrl       *gomock.Controller
+		runner     *mockcmd.MockRunner
+		kubeClient *mockkube.MockClient
+		checker    *mockkube.MockResourceChecker
+		fileStore  *mock_render.MockFileStore
+		ctx        *api.WorkflowContext
+
+		deployment = kube.ResourceRef{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "petclinic", Name: "petclinic"}
+		service    = kube.ResourceRef{APIVersion: "v1", Kind: "Service", Namespace: "default", Name: "petclinic"}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mockcmd.NewMockRunner(ctrl)
+		kubeClient = mockkube.NewMockClient(ctrl)
+		checker = mockkube.NewMockResourceChecker(ctrl)
+		fileStore = mock_render.NewMockFileStore(ctrl)
+		ctx = &api.WorkflowContext{
+			Runner:     runner,
+			KubeClient: kubeClient,
+			FileStore:  fileStore,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("waits for the resources in a manifest", func() {
+		// The checker is created once and reused for each check
+		kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+		fileStore.EXPECT().Load("petclinic.yaml").Return(manifests, nil)
+		refs := []kube.ResourceRef{deployment, service}
+		gomock.InOrder(
+			checker.EXPECT().GetReadiness(refs).Return([]kube.Readiness{
+				{Resource: deployment, Reason: "0 of 1 updated replicas available"},
+				{Resource: service, Ready: true},
+			}, nil),
+			checker.EXPECT().GetReadiness(refs).Return([]kube.Readiness{
+				{Resource: deployment, Ready: true},
+				{Resource: service, Ready: true},
+			}, nil),
+		)
+		step := &check.WaitForResources{Path: "petclinic.yaml", Namespace: "default", Interval: "1ms"}
+		err := step.Run(ctx, map[string]interface{}{"Namespace": "petclinic"})
+		Expect(err).To(BeNil())
+	})
+
+	It("lists resources by label selector", func() {
+		kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+		checker.EXPECT().ListResources([]string{"deployments"}, "petclinic", "app=petclinic").Return([]kube.ResourceRef{deployment}, nil)
+		checker.EXPECT().GetReadiness([]kube.ResourceRef{deployment}).Return([]kube.Readiness{{Resource: deployment, Ready: true}}, nil)
+		step := &check.WaitForResources{Selector: "app=petclinic", Kinds: []string{"deployments"}, Namespace: "petclinic"}
+		err := step.Run(ctx, nil)
+		Expect(err).To(BeNil())
+	})
+
+	It("fails as soon as a resource fails", func() {
+		kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+		job := kube.ResourceRef{Kind: "job", Namespace: "petclinic", Name: "migrate"}
+		checker.EXPECT().GetReadiness([]kube.ResourceRef{job}).Return([]kube.Readiness{
+			{Resource: job, Failed: true, Reason: "BackoffLimitExceeded"},
+		}, nil).Times(1)
+		step := &check.WaitForResources{Resources: []string{"job/migrate"}, Namespace: "petclinic"}
+		err := step.Run(ctx, nil)
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("job petclinic/migrate failed: BackoffLimitExceeded"))
+	})
+
+	It("times out if resources aren't ready", func() {
+		kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+		notReady := []kube.Readiness{{Resource: deployment, Reason: "0 of 1 updated replicas available"}}
+		checker.EXPECT().GetReadiness(gomock.Any()).Return(notReady, nil).MinTimes(1)
+		step := &check.WaitForResources{Resources: []string{"deployment/petclinic"}, Timeout: "10ms", Interval: "1ms"}
+		err := step.Run(ctx, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(check.ResourcesNotReadyError(1).Error()))
+		Expect(check.FormatNotReady(notReady)).To(Equal(
+			"KIND         NAMESPACE   NAME        REASON\n" +
+				"Deployment   petclinic   petclinic   0 of 1 updated replicas available"))
+	})
+
+	It("validates that exactly one source of resources is provided", func() {
+		Expect((&check.WaitForResources{}).Validate(ctx, nil)).To(Equal(check.InvalidWaitForResourcesError))
+		Expect((&check.WaitForResources{Selector: "app=petclinic", Resources: []string{"deploy/petclinic"}}).Validate(ctx, nil)).
+			To(Equal(check.InvalidWaitForResourcesError))
+		err := (&check.WaitForResources{Resources: []string{"petclinic"}}).Validate(ctx, nil)
+		Expect(err.Error()).To(Equal(check.InvalidResourceError("petclinic").Error()))
+		err = (&check.WaitForResources{Selector: "app=petclinic", Timeout: "soon"}).Validate(ctx, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
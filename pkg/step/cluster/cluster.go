@@ -14,6 +14,11 @@ type ClusterResource interface {
 	SetContext(ctx *api.WorkflowContext, values render.Values) error
 }
 
+const (
+	// The output of a destroyCluster step, "true" if the cluster was removed or "false" if it was already gone
+	RemovedOutput = "removed"
+)
+
 var (
 	_ api.Step = new(EnsureCluster)
 
@@ -51,5 +56,5 @@ func (e *EnsureCluster) Run(ctx *api.WorkflowContext, values render.Values) erro
 }
 
 func (e *EnsureCluster) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
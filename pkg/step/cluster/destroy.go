@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"fmt"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ api.Step = new(DestroyCluster)
+
+// cluster.DestroyCluster is a workflow step that tears down a cluster, for instance a throwaway cluster
+// that was created with EnsureCluster earlier in the workflow.
+// Exactly one of the cluster types should be provided.
+//
+// The step publishes a "removed" output, which is recorded in the report: "true" if the cluster was removed, or
+// "false" if it was already gone.
+type DestroyCluster clusterStep
+
+func (d *DestroyCluster) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if d.Minikube != nil {
+		return "Destroying minikube cluster", nil
+	} else if d.GKE != nil {
+		return "Destroying GKE cluster", nil
+	} else if d.EKS != nil {
+		return "Destroying EKS cluster", nil
+	}
+	return "", NoClusterDefinedError
+}
+
+func (d *DestroyCluster) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if d.Minikube != nil {
+		return d.Minikube.Teardown(ctx, values)
+	} else if d.GKE != nil {
+		return d.GKE.Teardown(ctx, values)
+	} else if d.EKS != nil {
+		return d.EKS.Teardown(ctx, values)
+	}
+	return NoClusterDefinedError
+}
+
+func (d *DestroyCluster) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
+	if d.Minikube != nil {
+		return "Delete the minikube cluster with `minikube delete`.", nil
+	} else if d.GKE != nil {
+		if err := values.RenderFields(d.GKE, ctx.Runner); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Delete the GKE cluster `%s` in project `%s` (%s).", d.GKE.Name, d.GKE.Project, d.GKE.Location), nil
+	} else if d.EKS != nil {
+		if err := values.RenderFields(d.EKS, ctx.Runner); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Delete the EKS cluster `%s` in region `%s` with `eksctl delete cluster`.", d.EKS.Name, d.EKS.Region), nil
+	}
+	return "", NoClusterDefinedError
+}
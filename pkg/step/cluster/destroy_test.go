@@ -0,0 +1,95 @@
+package cluster_test
+
+import (
+	"strings"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/step/cluster"
+)
+
+var _ = Describe("destroy", func() {
+
+	var (
+		ctrl   *gomock.Controller
+		runner *mock_cmd.MockRunner
+		ctx    *api.WorkflowContext
+
+		streamHandler = func(waitErr error) *cmd.CommandStreamHandler {
+			return &cmd.CommandStreamHandler{
+				Stdout:   strings.NewReader(""),
+				Stderr:   strings.NewReader(""),
+				WaitFunc: func() error { return waitErr },
+			}
+		}
+		statusCmd   = cmd.New().Minikube().SwallowError().With("status").Cmd()
+		expectedCmd = cmd.New().Minikube().With("delete").Cmd()
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		ctx = &api.WorkflowContext{
+			Runner: runner,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("tears down a minikube cluster", func() {
+		destroy := &cluster.DestroyCluster{Minikube: &cluster.Minikube{}}
+		runner.EXPECT().Output(statusCmd).Return("host: Running", nil).Times(1)
+		runner.EXPECT().Stream(expectedCmd).Return(streamHandler(nil), nil).Times(1)
+		Expect(destroy.Run(ctx, nil)).To(BeNil())
+		Expect(ctx.Outputs).To(HaveKeyWithValue(cluster.RemovedOutput, "true"))
+	})
+
+	It("tears down a stopped minikube cluster", func() {
+		destroy := &cluster.DestroyCluster{Minikube: &cluster.Minikube{}}
+		runner.EXPECT().Output(statusCmd).Return("host: Stopped", errors.Errorf("exit status 7")).Times(1)
+		runner.EXPECT().Stream(expectedCmd).Return(streamHandler(nil), nil).Times(1)
+		Expect(destroy.Run(ctx, nil)).To(BeNil())
+		Expect(ctx.Outputs).To(HaveKeyWithValue(cluster.RemovedOutput, "true"))
+	})
+
+	It("reports that a missing minikube cluster wasn't removed", func() {
+		destroy := &cluster.DestroyCluster{Minikube: &cluster.Minikube{}}
+		runner.EXPECT().Output(statusCmd).Return(`Profile "minikube" not found.`, errors.Errorf("exit status 85")).Times(1)
+		Expect(destroy.Run(ctx, nil)).To(BeNil())
+		Expect(ctx.Outputs).To(HaveKeyWithValue(cluster.RemovedOutput, "false"))
+	})
+
+	It("returns an error if the minikube cluster couldn't be deleted", func() {
+		destroy := &cluster.DestroyCluster{Minikube: &cluster.Minikube{}}
+		runner.EXPECT().Output(statusCmd).Return("host: Running", nil).Times(1)
+		runner.EXPECT().Stream(expectedCmd).Return(streamHandler(errors.Errorf("")), nil).Times(1)
+		Expect(destroy.Run(ctx, nil)).NotTo(BeNil())
+	})
+
+	It("returns docs for the cluster", func() {
+		destroy := &cluster.DestroyCluster{EKS: &cluster.EKS{Name: "valet-test", Region: "us-east-2"}}
+		docs, err := destroy.GetDocs(ctx, nil, render.Flags{})
+		Expect(err).To(BeNil())
+		Expect(docs).To(ContainSubstring("`valet-test` in region `us-east-2`"))
+	})
+
+	It("returns the right description", func() {
+		destroy := &cluster.DestroyCluster{Minikube: &cluster.Minikube{}}
+		desc, err := destroy.GetDescription(ctx, nil)
+		Expect(err).To(BeNil())
+		Expect(desc).To(Equal("Destroying minikube cluster"))
+	})
+
+	It("returns an error if no cluster is defined", func() {
+		destroy := &cluster.DestroyCluster{}
+		Expect(destroy.Run(ctx, nil)).To(Equal(cluster.NoClusterDefinedError))
+	})
+})
@@ -17,7 +17,7 @@ func (e *EKS) Ensure(ctx *api.WorkflowContext, values render.Values) error {
 	if err := values.RenderFields(e, ctx.Runner); err != nil {
 		return err
 	}
-	cmd.Stdout().Println("Ensuring eks cluster %s (region : %s)", e.Name, e.Region)
+	ctx.GetLogger().Infof("Ensuring eks cluster %s (region : %s)", e.Name, e.Region)
 	running, err := cmd.New().EksCtl().IsRunning(e.Name, e.Region, ctx.Runner)
 	if err != nil {
 		return err
@@ -38,15 +38,22 @@ func (e *EKS) Teardown(ctx *api.WorkflowContext, values render.Values) error {
 	if err := values.RenderFields(e, ctx.Runner); err != nil {
 		return err
 	}
-	cmd.Stdout().Println("tearing down eks cluster %s (region : %s)", e.Name, e.Region)
+	ctx.GetLogger().Infof("tearing down eks cluster %s (region : %s)", e.Name, e.Region)
 	running, err := cmd.New().EksCtl().IsRunning(e.Name, e.Region, ctx.Runner)
 	if err != nil {
 		return err
 	}
 	if !running {
+		ctx.GetLogger().Infof("EKS cluster %s (region: %s) was not found, nothing to tear down", e.Name, e.Region)
+		ctx.SetOutput(RemovedOutput, "false")
 		return nil
 	}
-	return cmd.New().EksCtl().DeleteCluster(e.Name, e.Region, ctx.Runner)
+	if err := cmd.New().EksCtl().DeleteCluster(e.Name, e.Region, ctx.Runner); err != nil {
+		return err
+	}
+	ctx.GetLogger().Infof("EKS cluster %s (region: %s) was removed", e.Name, e.Region)
+	ctx.SetOutput(RemovedOutput, "true")
+	return nil
 }
 
 func (e *EKS) SetContext(ctx *api.WorkflowContext, values render.Values) error {
@@ -20,7 +20,7 @@ func (g *GKE) Ensure(ctx *api.WorkflowContext, values render.Values) error {
 	if err := values.RenderFields(g, ctx.Runner); err != nil {
 		return err
 	}
-	cmd.Stdout().Println("Ensuring GKE cluster %s (project: %s, location: %s)", g.Name, g.Project, g.Location)
+	ctx.GetLogger().Infof("Ensuring GKE cluster %s (project: %s, location: %s)", g.Name, g.Project, g.Location)
 	gkeClient, err := gke.NewClient(ctx.Ctx)
 	if err != nil {
 		return err
@@ -48,6 +48,7 @@ func (g *GKE) Teardown(ctx *api.WorkflowContext, values render.Values) error {
 	if err := values.RenderFields(g, ctx.Runner); err != nil {
 		return err
 	}
+	ctx.GetLogger().Infof("Tearing down GKE cluster %s (project: %s, location: %s)", g.Name, g.Project, g.Location)
 	gkeClient, err := gke.NewClient(ctx.Ctx)
 	if err != nil {
 		return err
@@ -56,7 +57,14 @@ func (g *GKE) Teardown(ctx *api.WorkflowContext, values render.Values) error {
 	if err != nil {
 		return err
 	} else if !running {
+		ctx.GetLogger().Infof("GKE cluster %s was not found, nothing to tear down", g.Name)
+		ctx.SetOutput(RemovedOutput, "false")
 		return nil
 	}
-	return gkeClient.Destroy(ctx.Ctx, g.Name, g.Project, g.Location)
+	if err := gkeClient.Destroy(ctx.Ctx, g.Name, g.Project, g.Location); err != nil {
+		return err
+	}
+	ctx.GetLogger().Infof("GKE cluster %s was removed", g.Name)
+	ctx.SetOutput(RemovedOutput, "true")
+	return nil
 }
@@ -1,6 +1,8 @@
 package cluster
 
 import (
+	"strings"
+
 	"github.com/solo-io/valet/pkg/api"
 	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
@@ -24,7 +26,7 @@ func (m *Minikube) Ensure(ctx *api.WorkflowContext, values render.Values) error
 	if err := values.RenderFields(m, ctx.Runner); err != nil {
 		return err
 	}
-	cmd.Stdout().Println("Ensuring minikube cluster (cpus: %d, memory: %d, version: %s, driver: %s, featureGates: %v)",
+	ctx.GetLogger().Infof("Ensuring minikube cluster (cpus: %d, memory: %d, version: %s, driver: %s, featureGates: %v)",
 		m.Cpus, m.Memory, m.KubeVersion, m.VmDriver, m.FeatureGates)
 	// If minikube status seems healthy, just set context and return
 	if err := ctx.Runner.Run(cmd.New().Minikube().Status().SwallowError().Cmd()); err == nil {
@@ -47,6 +49,23 @@ func (m *Minikube) Teardown(ctx *api.WorkflowContext, values render.Values) erro
 	if err := values.RenderFields(m, ctx.Runner); err != nil {
 		return err
 	}
-	cmd.Stdout().Println("Tearing down minikube cluster")
-	return cmd.New().Minikube().SwallowError().Delete(ctx.Runner)
+	ctx.GetLogger().Infof("Tearing down minikube cluster")
+	// Status fails for a stopped cluster too, which still needs to be deleted, so only a missing cluster is skipped
+	status, err := ctx.Runner.Output(cmd.New().Minikube().Status().SwallowError().Cmd())
+	if err != nil && minikubeNotFound(status) {
+		ctx.GetLogger().Infof("Minikube cluster was not found, nothing to tear down")
+		ctx.SetOutput(RemovedOutput, "false")
+		return nil
+	}
+	if err := cmd.New().Minikube().Delete(ctx.Runner); err != nil {
+		return err
+	}
+	ctx.GetLogger().Infof("Minikube cluster was removed")
+	ctx.SetOutput(RemovedOutput, "true")
+	return nil
+}
+
+// Returns true if the output of minikube status says there is no cluster.
+func minikubeNotFound(status string) bool {
+	return strings.Contains(status, "not found") || strings.Contains(status, "There is no local cluster")
 }
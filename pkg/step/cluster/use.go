@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	_ api.Step       = new(UseCluster)
+	_ api.DryRunStep = new(UseCluster)
+)
+
+// cluster.UseCluster is a workflow step that points the following steps at a cluster, so that
+// the same workflow can be run against several clusters.
+//
+// If one of minikube, gke or eks is provided, the credentials for that cluster are set up and its
+// kube context is used. Otherwise, kubeContext (and optionally kubeconfig) must be provided.
+//
+// Kubectl commands, helm installs and Kubernetes client calls from later steps target the selected context,
+// instead of the current context in the user's kubeconfig. Setting up the credentials of a cluster can change the
+// current context (i.e. gcloud get-credentials), so it is restored afterwards.
+type UseCluster struct {
+	Minikube    *Minikube `json:"minikube,omitempty"`
+	GKE         *GKE      `json:"gke,omitempty"`
+	EKS         *EKS      `json:"eks,omitempty"`
+	KubeContext string    `json:"kubeContext,omitempty" valet:"template"`
+	Kubeconfig  string    `json:"kubeconfig,omitempty" valet:"template"`
+}
+
+func (u *UseCluster) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if err := values.RenderFields(u, ctx.Runner); err != nil {
+		return "", err
+	}
+	if u.Minikube != nil {
+		return "Using minikube cluster", nil
+	} else if u.GKE != nil {
+		return fmt.Sprintf("Using GKE cluster %s", u.GKE.Name), nil
+	} else if u.EKS != nil {
+		return fmt.Sprintf("Using EKS cluster %s", u.EKS.Name), nil
+	} else if u.KubeContext != "" {
+		return fmt.Sprintf("Using kube context %s", u.KubeContext), nil
+	} else if u.Kubeconfig != "" {
+		return fmt.Sprintf("Using kubeconfig %s", u.Kubeconfig), nil
+	}
+	return "", NoClusterDefinedError
+}
+
+func (u *UseCluster) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if err := values.RenderFields(u, ctx.Runner); err != nil {
+		return err
+	}
+	kubeContext := u.KubeContext
+	if resource := u.getClusterResource(); resource != nil {
+		clusterContext, err := u.getClusterContext(ctx, values, resource)
+		if err != nil {
+			return err
+		}
+		if kubeContext == "" {
+			kubeContext = clusterContext
+		}
+	} else if kubeContext == "" && u.Kubeconfig == "" {
+		return NoClusterDefinedError
+	}
+	ctx.UseContext(u.Kubeconfig, kubeContext)
+	ctx.GetLogger().Infof("Using kube context %s", kubeContext)
+	return nil
+}
+
+// Cluster credentials aren't set up during a dry run, but an explicit kube context is still used by later steps,
+// so they are validated against the right cluster.
+func (u *UseCluster) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	if err := values.RenderFields(u, ctx.Runner); err != nil {
+		return err
+	}
+	if u.KubeContext == "" && u.Kubeconfig == "" {
+		return nil
+	}
+	ctx.UseContext(u.Kubeconfig, u.KubeContext)
+	return nil
+}
+
+func (u *UseCluster) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
+	if err := values.RenderFields(u, ctx.Runner); err != nil {
+		return "", err
+	}
+	if u.KubeContext != "" {
+		return fmt.Sprintf("Point the following steps at kube context `%s`. Use `kubectl --context %s` to run commands against the same cluster.", u.KubeContext, u.KubeContext), nil
+	}
+	description, err := u.GetDescription(ctx, values)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s for the following steps. The current context of your kubeconfig isn't changed.", description), nil
+}
+
+func (u *UseCluster) getClusterResource() ClusterResource {
+	if u.Minikube != nil {
+		return u.Minikube
+	} else if u.GKE != nil {
+		return u.GKE
+	} else if u.EKS != nil {
+		return u.EKS
+	}
+	return nil
+}
+
+// Set up the credentials of the cluster and return its kube context. Setting up credentials makes the cluster the
+// current context, so the previous current context is restored.
+func (u *UseCluster) getClusterContext(ctx *api.WorkflowContext, values render.Values, resource ClusterResource) (string, error) {
+	// There may not be a current context yet, in which case it is unset again
+	previousContext, _ := u.getCurrentContext(ctx, true)
+	if err := resource.SetContext(ctx, values); err != nil {
+		return "", err
+	}
+	clusterContext, err := u.getCurrentContext(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	if previousContext == clusterContext {
+		return clusterContext, nil
+	}
+	restore := u.kubectl().UseContext(previousContext)
+	if previousContext == "" {
+		restore = u.kubectl().With("config", "unset", "current-context")
+	}
+	if err := ctx.Runner.Run(restore.Cmd()); err != nil {
+		return "", err
+	}
+	return clusterContext, nil
+}
+
+func (u *UseCluster) getCurrentContext(ctx *api.WorkflowContext, swallowErrorLog bool) (string, error) {
+	out, err := ctx.Runner.Output(u.kubectl().CurrentContext().SwallowErrorLog(swallowErrorLog).Cmd())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (u *UseCluster) kubectl() *cmd.Kubectl {
+	kubectl := cmd.New().Kubectl()
+	if u.Kubeconfig != "" {
+		kubectl = kubectl.Kubeconfig(u.Kubeconfig)
+	}
+	return kubectl
+}
@@ -0,0 +1,93 @@
+package cluster_test
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	mock_helm "github.com/solo-io/valet/pkg/client/helm/mocks"
+	mock_kube "github.com/solo-io/valet/pkg/client/kube/mocks"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/step/cluster"
+)
+
+var _ = Describe("use", func() {
+
+	var (
+		ctrl       *gomock.Controller
+		runner     *mock_cmd.MockRunner
+		kubeClient *mock_kube.MockClient
+		helmClient *mock_helm.MockClient
+		ctx        *api.WorkflowContext
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		kubeClient = mock_kube.NewMockClient(ctrl)
+		helmClient = mock_helm.NewMockClient(ctrl)
+		ctx = &api.WorkflowContext{
+			Runner:     runner,
+			KubeClient: kubeClient,
+			HelmClient: helmClient,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("uses an explicit kube context", func() {
+		use := &cluster.UseCluster{KubeContext: "other", Kubeconfig: "/tmp/kubeconfig"}
+		kubeClient.EXPECT().UseContext("/tmp/kubeconfig", "other").Times(1)
+		helmClient.EXPECT().UseContext("/tmp/kubeconfig", "other").Times(1)
+		Expect(use.Run(ctx, nil)).To(BeNil())
+		Expect(ctx.KubeContext).To(Equal("other"))
+		Expect(ctx.Kubeconfig).To(Equal("/tmp/kubeconfig"))
+		Expect(ctx.Kubectl().ApplyFile("foo.yaml").Cmd()).To(Equal(
+			cmd.New().Kubectl().With("--kubeconfig", "/tmp/kubeconfig", "--context", "other", "apply", "-f", "foo.yaml").Cmd()))
+	})
+
+	It("sets the context for a cluster and restores the previous current context", func() {
+		use := &cluster.UseCluster{Minikube: &cluster.Minikube{}}
+		currentContext := cmd.New().Kubectl().CurrentContext().Cmd()
+		gomock.InOrder(
+			runner.EXPECT().Output(cmd.New().Kubectl().CurrentContext().SwallowErrorLog(true).Cmd()).Return("gke_demo\n", nil),
+			runner.EXPECT().Run(cmd.New().Kubectl().UseContext(cluster.MinikubeContext).Cmd()).Return(nil),
+			runner.EXPECT().Output(currentContext).Return("minikube\n", nil),
+			runner.EXPECT().Run(cmd.New().Kubectl().UseContext("gke_demo").Cmd()).Return(nil),
+		)
+		kubeClient.EXPECT().UseContext("", "minikube").Times(1)
+		helmClient.EXPECT().UseContext("", "minikube").Times(1)
+		Expect(use.Run(ctx, nil)).To(BeNil())
+		Expect(ctx.KubeContext).To(Equal("minikube"))
+	})
+
+	It("unsets the current context if there wasn't one", func() {
+		use := &cluster.UseCluster{Minikube: &cluster.Minikube{}}
+		gomock.InOrder(
+			runner.EXPECT().Output(cmd.New().Kubectl().CurrentContext().SwallowErrorLog(true).Cmd()).Return("", errors.Errorf("current-context is not set")),
+			runner.EXPECT().Run(cmd.New().Kubectl().UseContext(cluster.MinikubeContext).Cmd()).Return(nil),
+			runner.EXPECT().Output(cmd.New().Kubectl().CurrentContext().Cmd()).Return("minikube\n", nil),
+			runner.EXPECT().Run(cmd.New().Kubectl().With("config", "unset", "current-context").Cmd()).Return(nil),
+		)
+		kubeClient.EXPECT().UseContext("", "minikube").Times(1)
+		helmClient.EXPECT().UseContext("", "minikube").Times(1)
+		Expect(use.Run(ctx, nil)).To(BeNil())
+	})
+
+	It("returns docs for the kube context", func() {
+		use := &cluster.UseCluster{KubeContext: "other"}
+		docs, err := use.GetDocs(ctx, nil, render.Flags{})
+		Expect(err).To(BeNil())
+		Expect(docs).To(ContainSubstring("kubectl --context other"))
+	})
+
+	It("returns an error if no cluster is defined", func() {
+		use := &cluster.UseCluster{}
+		Expect(use.Run(ctx, nil)).To(Equal(cluster.NoClusterDefinedError))
+	})
+})
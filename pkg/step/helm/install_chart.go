@@ -6,9 +6,13 @@ import (
 	"github.com/solo-io/go-utils/installutils/helminstall"
 	"github.com/solo-io/valet/pkg/api"
 	"github.com/solo-io/valet/pkg/render"
+	"strconv"
 )
 
-var _ api.Step = new(InstallHelmChart)
+var (
+	_ api.Step           = new(InstallHelmChart)
+	_ api.ValidatingStep = new(InstallHelmChart)
+)
 
 type InstallHelmChart struct {
 	ReleaseName string   `json:"releaseName,omitempty"`
@@ -53,21 +57,49 @@ func (i *InstallHelmChart) Run(ctx *api.WorkflowContext, values render.Values) e
 		CreateNamespace:  true,
 		InstallNamespace: i.Namespace,
 		ReleaseName:      i.ReleaseName,
-		ReleaseUri:       i.ReleaseUri,
-		ValuesFiles:      i.ValuesFiles,
+		ReleaseUri:       ctx.ResolvePath(i.ReleaseUri),
+		ValuesFiles:      resolvePaths(ctx, i.ValuesFiles),
 		ExtraValues:      extraVals,
+		// Install into the cluster selected by a useCluster step, if any
+		KubeConfig:  ctx.Kubeconfig,
+		KubeContext: ctx.KubeContext,
 	}
 	if err := ctx.HelmClient.Install(&conf); err != nil {
 		if !eris.Is(err, helminstall.ReleaseAlreadyInstalledErr(i.ReleaseName, i.Namespace)) {
 			return err
 		}
 	}
+	if rel, err := ctx.HelmClient.GetRelease(i.ReleaseName, i.Namespace); err != nil {
+		ctx.GetLogger().Warnf("Unable to get revision of release %s: %v", i.ReleaseName, err)
+	} else if rel != nil {
+		ctx.SetOutput("revision", strconv.Itoa(rel.Version))
+	}
 	if !i.WaitForPods {
 		return nil
 	}
 	return ctx.KubeClient.WaitUntilPodsRunning(i.Namespace)
 }
 
+func (i *InstallHelmChart) Validate(ctx *api.WorkflowContext, _ render.Values) error {
+	for _, valuesFile := range i.ValuesFiles {
+		if err := render.ValidateFile(ctx.FileStore, valuesFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolvePaths(ctx *api.WorkflowContext, paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	resolved := make([]string, 0, len(paths))
+	for _, path := range paths {
+		resolved = append(resolved, ctx.ResolvePath(path))
+	}
+	return resolved
+}
+
 func (i *InstallHelmChart) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
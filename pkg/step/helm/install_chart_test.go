@@ -1,6 +1,8 @@
 package helm_test
 
 import (
+	"os"
+
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -9,7 +11,9 @@ import (
 	mock_helm "github.com/solo-io/valet/pkg/client/helm/mocks"
 	mock_kube "github.com/solo-io/valet/pkg/client/kube/mocks"
 	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/step/helm"
+	helmrelease "helm.sh/helm/v3/pkg/release"
 )
 
 var _ = Describe("install_chart", func() {
@@ -64,8 +68,21 @@ var _ = Describe("install_chart", func() {
 		It("runs", func() {
 			conf := getInstallerConfig()
 			helmClient.EXPECT().Install(conf).Return(nil).Times(1)
+			helmClient.EXPECT().GetRelease(release, ns).Return(&helmrelease.Release{Version: 2}, nil).Times(1)
 			err := getInstallChartStep().Run(ctx, nil)
 			Expect(err).To(BeNil())
+			Expect(ctx.Outputs).To(HaveKeyWithValue("revision", "2"))
+		})
+
+		It("installs into the kube context of the workflow", func() {
+			ctx.Kubeconfig = "/tmp/kubeconfig"
+			ctx.KubeContext = "other"
+			conf := getInstallerConfig()
+			conf.KubeConfig = "/tmp/kubeconfig"
+			conf.KubeContext = "other"
+			helmClient.EXPECT().Install(conf).Return(nil).Times(1)
+			helmClient.EXPECT().GetRelease(release, ns).Return(nil, nil).Times(1)
+			Expect(getInstallChartStep().Run(ctx, nil)).To(BeNil())
 		})
 
 		It("has the right description", func() {
@@ -73,10 +90,39 @@ var _ = Describe("install_chart", func() {
 		})
 	})
 
+	Context("set", func() {
+		It("passes typed values to the chart and resolves their prefixes", func() {
+			os.Setenv("VALET_HELM_TEST_LICENSE", "abc123")
+			defer os.Unsetenv("VALET_HELM_TEST_LICENSE")
+			conf := getInstallerConfig()
+			conf.ExtraValues = map[string]interface{}{
+				"license_key": "abc123",
+				"gateway": map[string]interface{}{
+					"enabled":  true,
+					"replicas": 2,
+					"ports":    []interface{}{"http", "https"},
+				},
+			}
+			helmClient.EXPECT().Install(conf).Return(nil).Times(1)
+			helmClient.EXPECT().GetRelease(release, ns).Return(nil, nil).Times(1)
+			installChartStep := getInstallChartStep()
+			installChartStep.Set = render.Values{
+				"license_key": "env:VALET_HELM_TEST_LICENSE",
+				"gateway": map[string]interface{}{
+					"enabled":  true,
+					"replicas": 2,
+					"ports":    []string{"http", "https"},
+				},
+			}
+			Expect(installChartStep.Run(ctx, nil)).To(BeNil())
+		})
+	})
+
 	Context("waiting for pods", func() {
 		It("works", func() {
 			conf := getInstallerConfig()
 			helmClient.EXPECT().Install(conf).Return(nil).Times(1)
+			helmClient.EXPECT().GetRelease(release, ns).Return(nil, nil).Times(1)
 			kubeClient.EXPECT().WaitUntilPodsRunning(ns).Return(nil).Times(1)
 			installChartStep := getInstallChartStep()
 			installChartStep.WaitForPods = true
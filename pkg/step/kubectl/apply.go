@@ -11,23 +11,50 @@ const (
 	DocsFlagYamlOnly = "YamlOnly"
 )
 
-var _ api.Step = new(Apply)
+var (
+	_ api.Step           = new(Apply)
+	_ api.ValidatingStep = new(Apply)
+	_ api.ManifestStep   = new(Apply)
+)
 
 type Apply struct {
 	Path string `json:"path,omitempty"`
+	// Optional, with native apply, take ownership of fields that other managers (i.e. controllers or helm) own,
+	// rather than failing with a conflict
+	Force bool `json:"force,omitempty"`
 }
 
-func (a *Apply) GetDescription(_ *api.WorkflowContext, _ render.Values) (string, error) {
-	stringCmd := a.GetCmd().ToString()
+func (a *Apply) GetDescription(ctx *api.WorkflowContext, _ render.Values) (string, error) {
+	if ctx != nil && ctx.NativeApply {
+		return fmt.Sprintf("Applying %s", a.Path), nil
+	}
+	stringCmd := a.GetCmd(ctx).ToString()
 	return fmt.Sprintf("Running command: %s", stringCmd), nil
 }
 
-func (a *Apply) GetCmd() *cmd.Command {
-	return cmd.New().Kubectl().ApplyFile(a.Path).Cmd()
+func (a *Apply) GetCmd(ctx *api.WorkflowContext) *cmd.Command {
+	return ctx.Kubectl().ApplyFile(ctx.ResolvePath(a.Path)).Cmd()
 }
 
 func (a *Apply) Run(ctx *api.WorkflowContext, values render.Values) error {
-	return ctx.Runner.Run(a.GetCmd())
+	if ctx.NativeApply {
+		manifests, err := ctx.FileStore.Load(a.Path)
+		if err != nil {
+			return err
+		}
+		ctx.TrackManifests(manifests)
+		return applyNative(ctx, manifests, a.Force)
+	}
+	trackFile(ctx, a.Path)
+	return ctx.Runner.Run(a.GetCmd(ctx))
+}
+
+func (a *Apply) GetManifests(ctx *api.WorkflowContext, _ render.Values) (string, error) {
+	return ctx.FileStore.Load(a.Path)
+}
+
+func (a *Apply) Validate(ctx *api.WorkflowContext, _ render.Values) error {
+	return render.ValidateFile(ctx.FileStore, a.Path)
 }
 
 func (a *Apply) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
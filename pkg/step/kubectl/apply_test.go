@@ -39,7 +39,7 @@ var _ = Describe("apply", func() {
 	})
 
 	It("runs", func() {
-		actualCmd := apply.GetCmd()
+		actualCmd := apply.GetCmd(ctx)
 		Expect(expectedCmd).To(Equal(actualCmd))
 		runner.EXPECT().Run(actualCmd).Return(nil).Times(1)
 		err := apply.Run(ctx, nil)
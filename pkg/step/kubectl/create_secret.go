@@ -21,10 +21,13 @@ import (
 
 const (
 	encryptedSuffix = ".enc"
+	redactedValue   = "<redacted>"
 )
 
 var (
-	_ api.Step = new(CreateSecret)
+	_ api.Step           = new(CreateSecret)
+	_ api.DryRunStep     = new(CreateSecret)
+	_ api.ValidatingStep = new(CreateSecret)
 
 	InvalidCiphertextFilenameError = errors.Errorf("Ciphertext files must end with '%s'.", encryptedSuffix)
 	UnableToDecryptFileError       = func(err error) error {
@@ -37,10 +40,13 @@ var (
 
 type CreateSecret struct {
 	// Currently, secrets cannot consist of values from multiple registries
-	Name         string                 `json:"name,omitempty"`
-	Namespace    string                 `json:"namespace,omitempty" valet:"key=Namespace"`
-	Type         string                 `json:"typ,omitemptye" valet:"default=Opaque"`
-	Entries      map[string]SecretValue `json:"entries,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Namespace string                 `json:"namespace,omitempty" valet:"key=Namespace"`
+	Type      string                 `json:"typ,omitemptye" valet:"default=Opaque"`
+	Entries   map[string]SecretValue `json:"entries,omitempty"`
+	// Optional, with native apply, take ownership of fields that other managers (i.e. controllers or helm) own,
+	// rather than failing with a conflict
+	Force bool `json:"force,omitempty"`
 }
 
 type SecretValue struct {
@@ -57,11 +63,34 @@ type GcloudKmsEncryptedFile struct {
 }
 
 func (s *CreateSecret) Run(ctx *api.WorkflowContext, values render.Values) error {
-	if err := values.RenderFields(s, ctx.Runner); err != nil {
+	manifests, err := s.renderManifests(ctx, values, false)
+	if err != nil {
 		return err
+	}
+	if ctx.NativeApply {
+		return applyNative(ctx, manifests, s.Force)
+	}
+	kubectlCmd := ctx.Kubectl().ApplyStdIn(manifests).Cmd()
+	return ctx.Runner.Run(kubectlCmd)
+}
 
+// Shows the secret with the value of each entry redacted. The values are never read, so encrypted files
+// aren't decrypted during a dry run.
+func (s *CreateSecret) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	manifests, err := s.renderManifests(ctx, values, true)
+	if err != nil {
+		return err
 	}
-	cmd.Stdout().Println("Rendering secret %s.%s with type %s and %d entries", s.Namespace, s.Name, s.Type, len(s.Entries))
+	ctx.GetLogger().Infof("Manifests:\n%s", manifests)
+	validateWithServer(ctx, ctx.Kubectl().ApplyStdIn(manifests))
+	return nil
+}
+
+func (s *CreateSecret) renderManifests(ctx *api.WorkflowContext, values render.Values, redact bool) (string, error) {
+	if err := values.RenderFields(s, ctx.Runner); err != nil {
+		return "", err
+	}
+	ctx.GetLogger().Infof("Rendering secret %s.%s with type %s and %d entries", s.Namespace, s.Name, s.Type, len(s.Entries))
 	secret := v1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Secret",
@@ -72,46 +101,49 @@ func (s *CreateSecret) Run(ctx *api.WorkflowContext, values render.Values) error
 			Name:      s.Name,
 			Namespace: s.Namespace,
 		},
-		Data: make(map[string][]byte),
+		Data:       make(map[string][]byte),
+		StringData: make(map[string]string),
 	}
 	for k, v := range s.Entries {
 		k, err := render.LoadTemplate(k, values, ctx.Runner)
 		if err != nil {
-			return err
+			return "", err
 		}
-		if v.File != "" {
+		if redact {
+			secret.StringData[k] = redactedValue
+		} else if v.File != "" {
 			contents, err := ctx.FileStore.Load(v.File)
 			if err != nil {
-				return err
+				return "", err
 			}
 			secret.Data[k] = []byte(contents)
 		} else if v.EnvVar != "" {
 			val := os.Getenv(v.EnvVar)
 			if val == "" {
-				return MissingEnvVarError(v.EnvVar)
+				return "", MissingEnvVarError(v.EnvVar)
 			}
 			secret.Data[k] = []byte(val)
 		} else if v.GcloudKmsEncryptedFile != nil {
 			if !strings.HasSuffix(v.GcloudKmsEncryptedFile.CiphertextFile, encryptedSuffix) {
-				return InvalidCiphertextFilenameError
+				return "", InvalidCiphertextFilenameError
 			}
 			encContents, err := ctx.FileStore.Load(v.GcloudKmsEncryptedFile.CiphertextFile)
 			if err != nil {
-				return err
+				return "", err
 			}
 			encrypted, err := ioutil.TempFile("", "valet-test-secret-enc-")
 			if err != nil {
-				return err
+				return "", err
 			}
-			defer cleanupFile(encrypted.Name())
+			defer cleanupFile(ctx, encrypted.Name())
 			if err := ioutil.WriteFile(encrypted.Name(), []byte(encContents), os.ModePerm); err != nil {
-				return err
+				return "", err
 			}
 			unencrypted, err := ioutil.TempFile("", "valet-test-secret-")
 			if err != nil {
-				return err
+				return "", err
 			}
-			defer cleanupFile(unencrypted.Name())
+			defer cleanupFile(ctx, unencrypted.Name())
 			command := cmd.New().Gcloud().DecryptFile(
 				encrypted.Name(),
 				unencrypted.Name(),
@@ -120,31 +152,55 @@ func (s *CreateSecret) Run(ctx *api.WorkflowContext, values render.Values) error
 				v.GcloudKmsEncryptedFile.Key).Cmd()
 			err = ctx.Runner.Run(command)
 			if err != nil {
-				return UnableToDecryptFileError(err)
+				return "", UnableToDecryptFileError(err)
 			}
 			osClient := osutils.NewOsClient()
 			contents, err := osClient.ReadFile(unencrypted.Name())
 			if err != nil {
-				return err
+				return "", err
 			}
 			secret.Data[k] = contents
 		}
+		// The manifest is passed to kubectl on stdin, which is logged if the command fails
+		if data, ok := secret.Data[k]; ok {
+			ctx.GetRedactor().AddSuspected(string(data))
+		}
 	}
 	resource, err := kuberesource.ConvertToUnstructured(&secret)
 	if err != nil {
-		return err
+		return "", err
 	}
 	manifests, err := helmchart.ManifestsFromResources(kuberesource.UnstructuredResources{resource})
 	if err != nil {
-		return err
+		return "", err
 	}
-	kubectlCmd := cmd.New().Kubectl().ApplyStdIn(manifests.CombinedString()).Cmd()
-	return ctx.Runner.Run(kubectlCmd)
+	return manifests.CombinedString(), nil
+}
+
+func (s *CreateSecret) Validate(ctx *api.WorkflowContext, values render.Values) error {
+	for k, v := range s.Entries {
+		if err := values.ValidateTemplate(k); err != nil {
+			return err
+		}
+		if v.File != "" {
+			if err := render.ValidateFile(ctx.FileStore, v.File); err != nil {
+				return err
+			}
+		} else if v.GcloudKmsEncryptedFile != nil {
+			if !strings.HasSuffix(v.GcloudKmsEncryptedFile.CiphertextFile, encryptedSuffix) {
+				return InvalidCiphertextFilenameError
+			}
+			if err := render.ValidateFile(ctx.FileStore, v.GcloudKmsEncryptedFile.CiphertextFile); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func cleanupFile(name string) {
+func cleanupFile(ctx *api.WorkflowContext, name string) {
 	if err := os.Remove(name); err != nil {
-		cmd.Stderr().Println("Error cleaning up file %s: %s", name, err.Error())
+		ctx.GetLogger().Warnf("Error cleaning up file %s: %s", name, err.Error())
 	}
 }
 
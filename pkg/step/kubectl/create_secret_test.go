@@ -0,0 +1,64 @@
+package kubectl_test
+
+import (
+	"encoding/base64"
+	"os"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/step/kubectl"
+)
+
+var _ = Describe("create secret", func() {
+
+	const (
+		envVar = "VALET_TEST_SECRET"
+		value  = "hunter2"
+	)
+
+	var (
+		ctrl   *gomock.Controller
+		runner *mock_cmd.MockRunner
+		ctx    *api.WorkflowContext
+		secret = kubectl.CreateSecret{
+			Name:      "license",
+			Namespace: "gloo-system",
+			Entries: map[string]kubectl.SecretValue{
+				"key": {EnvVar: envVar},
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		ctx = &api.WorkflowContext{
+			Runner:   runner,
+			Redactor: render.NewRedactor(),
+		}
+		os.Setenv(envVar, value)
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+		os.Unsetenv(envVar)
+	})
+
+	It("marks the entries as sensitive, so the manifest is redacted", func() {
+		var stdIn string
+		runner.EXPECT().Run(gomock.Any()).DoAndReturn(func(c *cmd.Command) error {
+			stdIn = c.StdIn
+			return nil
+		})
+		Expect(secret.Run(ctx, render.Values{})).To(BeNil())
+		encoded := base64.StdEncoding.EncodeToString([]byte(value))
+		Expect(stdIn).To(ContainSubstring(encoded))
+		Expect(ctx.GetRedactor().Redact(stdIn)).NotTo(ContainSubstring(encoded))
+		Expect(ctx.Kubectl().ApplyStdIn(stdIn).With("--token", value).Cmd().ToString()).NotTo(ContainSubstring(value))
+	})
+})
@@ -7,23 +7,49 @@ import (
 	"github.com/solo-io/valet/pkg/render"
 )
 
-var _ api.Step = new(Delete)
+var (
+	_ api.Step           = new(Delete)
+	_ api.ValidatingStep = new(Delete)
+	_ api.ManifestStep   = new(Delete)
+)
 
 type Delete struct {
 	Path string `json:"path,omitempty"`
 }
 
-func (a *Delete) GetDescription(_ *api.WorkflowContext, _ render.Values) (string, error) {
-	stringCmd := a.GetCmd().ToString()
+func (a *Delete) GetDescription(ctx *api.WorkflowContext, _ render.Values) (string, error) {
+	if ctx != nil && ctx.NativeApply {
+		return fmt.Sprintf("Deleting %s", a.Path), nil
+	}
+	stringCmd := a.GetCmd(ctx).ToString()
 	return fmt.Sprintf("Running command: %s", stringCmd), nil
 }
 
-func (a *Delete) GetCmd() *cmd.Command {
-	return cmd.New().Kubectl().DeleteFile(a.Path).Cmd()
+func (a *Delete) GetCmd(ctx *api.WorkflowContext) *cmd.Command {
+	return ctx.Kubectl().DeleteFile(ctx.ResolvePath(a.Path)).Cmd()
 }
 
 func (a *Delete) Run(ctx *api.WorkflowContext, values render.Values) error {
-	return ctx.Runner.Run(a.GetCmd())
+	if ctx.NativeApply {
+		manifests, err := ctx.FileStore.Load(a.Path)
+		if err != nil {
+			return err
+		}
+		ctx.TrackManifests(manifests)
+		results, err := ctx.KubeClient.DeleteManifests(manifests)
+		logResults(ctx, results)
+		return err
+	}
+	trackFile(ctx, a.Path)
+	return ctx.Runner.Run(a.GetCmd(ctx))
+}
+
+func (a *Delete) GetManifests(ctx *api.WorkflowContext, _ render.Values) (string, error) {
+	return ctx.FileStore.Load(a.Path)
+}
+
+func (a *Delete) Validate(ctx *api.WorkflowContext, _ render.Values) error {
+	return render.ValidateFile(ctx.FileStore, a.Path)
 }
 
 func (a *Delete) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
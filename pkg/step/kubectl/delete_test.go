@@ -39,7 +39,7 @@ var _ = Describe("delete", func() {
 	})
 
 	It("runs", func() {
-		actualCmd := del.GetCmd()
+		actualCmd := del.GetCmd(ctx)
 		Expect(expectedCmd).To(Equal(actualCmd))
 		runner.EXPECT().Run(actualCmd).Return(nil).Times(1)
 		err := del.Run(ctx, nil)
@@ -0,0 +1,19 @@
+package kubectl
+
+import (
+	"strings"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+)
+
+// Validate a kubectl command with a server-side dry run. The cluster may not exist yet when a workflow
+// is dry run, so validation is best effort and failures are reported as warnings.
+func validateWithServer(ctx *api.WorkflowContext, kubectl *cmd.Kubectl) {
+	out, err := ctx.Runner.Output(kubectl.ServerDryRun().SwallowErrorLog(true).Cmd())
+	if err != nil {
+		ctx.GetLogger().Warnf("Unable to validate with a server-side dry run: %s %s", err.Error(), strings.TrimSpace(out))
+		return
+	}
+	ctx.GetLogger().Infof("Validated with a server-side dry run: %s", strings.TrimSpace(out))
+}
@@ -0,0 +1,83 @@
+package kubectl_test
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/log"
+	"github.com/solo-io/valet/pkg/render"
+	mock_render "github.com/solo-io/valet/pkg/render/mocks"
+	"github.com/solo-io/valet/pkg/step/kubectl"
+)
+
+var _ = Describe("dry run", func() {
+
+	var (
+		ctrl      *gomock.Controller
+		runner    *mock_cmd.MockRunner
+		fileStore *mock_render.MockFileStore
+		ctx       *api.WorkflowContext
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		fileStore = mock_render.NewMockFileStore(ctrl)
+		ctx = &api.WorkflowContext{
+			Logger:    log.Discard(),
+			Runner:    runner,
+			FileStore: fileStore,
+			DryRun:    true,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("validates the rendered template with a server-side dry run", func() {
+		applyTemplate := &kubectl.ApplyTemplate{Path: "template.yaml"}
+		expected := cmd.New().Kubectl().ApplyStdIn("kind: Namespace").ServerDryRun().SwallowErrorLog(true).Cmd()
+		fileStore.EXPECT().Load("template.yaml").Return("kind: {{ .Kind }}", nil)
+		runner.EXPECT().Output(expected).Return("namespace/foo created (server dry run)", nil)
+		Expect(applyTemplate.DryRun(ctx, render.Values{"Kind": "Namespace"})).To(BeNil())
+	})
+
+	It("validates the patch with a server-side dry run", func() {
+		patch := &kubectl.Patch{Name: "name", Namespace: "ns", Path: "patch.yaml", PatchType: "merge", KubeType: "deployment"}
+		expected := cmd.New().Kubectl().
+			With("patch", "deployment", "name", "-n", "ns", "--type", "merge", "--patch", "blah").
+			ServerDryRun().SwallowErrorLog(true).Cmd()
+		fileStore.EXPECT().Load("patch.yaml").Return("blah", nil)
+		runner.EXPECT().Output(expected).Return("", nil)
+		Expect(patch.DryRun(ctx, nil)).To(BeNil())
+	})
+
+	It("doesn't fail when the server-side dry run fails", func() {
+		applyTemplate := &kubectl.ApplyTemplate{Path: "template.yaml"}
+		fileStore.EXPECT().Load("template.yaml").Return("kind: Namespace", nil)
+		runner.EXPECT().Output(gomock.Any()).Return("connection refused", errors.Errorf("exit status 1"))
+		Expect(applyTemplate.DryRun(ctx, nil)).To(BeNil())
+	})
+
+	It("redacts secret values", func() {
+		secret := &kubectl.CreateSecret{
+			Name:      "secret",
+			Namespace: "ns",
+			Type:      "Opaque",
+			Entries: map[string]kubectl.SecretValue{
+				"token": {File: "token.txt"},
+			},
+		}
+		runner.EXPECT().Output(gomock.Any()).DoAndReturn(func(c *cmd.Command) (string, error) {
+			Expect(c.StdIn).To(ContainSubstring("token: <redacted>"))
+			Expect(c.Args).To(ContainElement("--dry-run=server"))
+			return "", nil
+		})
+		Expect(secret.DryRun(ctx, nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,32 @@
+package kubectl
+
+import (
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+)
+
+// Log the result of each object that the kube client applied, deleted or patched, like kubectl does.
+func logResults(ctx *api.WorkflowContext, results kube.ObjectResults) {
+	for _, result := range results {
+		ctx.GetLogger().Infof("%s", result.String())
+	}
+}
+
+// Record the namespaces of the objects in a file that kubectl applies or deletes (see
+// api.WorkflowContext.TrackManifests). The file is only loaded when diagnostics are collected.
+func trackFile(ctx *api.WorkflowContext, path string) {
+	if ctx.Diagnostics == nil {
+		return
+	}
+	if manifests, err := ctx.FileStore.Load(path); err == nil {
+		ctx.TrackManifests(manifests)
+	}
+}
+
+// Apply manifests with the kube client, rather than with kubectl (see api.WorkflowContext.NativeApply). Unless force
+// is true, fields that other managers own aren't taken over, and applying them fails with a conflict.
+func applyNative(ctx *api.WorkflowContext, manifests string, force bool) error {
+	results, err := ctx.KubeClient.ApplyManifests(manifests, force)
+	logResults(ctx, results)
+	return err
+}
@@ -0,0 +1,78 @@
+package kubectl_test
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+	mock_kube "github.com/solo-io/valet/pkg/client/kube/mocks"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/log"
+	"github.com/solo-io/valet/pkg/render"
+	mock_render "github.com/solo-io/valet/pkg/render/mocks"
+	"github.com/solo-io/valet/pkg/step/kubectl"
+)
+
+var _ = Describe("native apply", func() {
+
+	const (
+		manifests = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Name }}\n"
+	)
+
+	var (
+		ctrl       *gomock.Controller
+		runner     *mock_cmd.MockRunner
+		fileStore  *mock_render.MockFileStore
+		kubeClient *mock_kube.MockClient
+		ctx        *api.WorkflowContext
+		results    = kube.ObjectResults{{Kind: "ConfigMap", Namespace: "default", Name: "settings", Result: kube.ObjectCreated}}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		fileStore = mock_render.NewMockFileStore(ctrl)
+		kubeClient = mock_kube.NewMockClient(ctrl)
+		ctx = &api.WorkflowContext{
+			Logger:      log.Discard(),
+			Runner:      runner,
+			FileStore:   fileStore,
+			KubeClient:  kubeClient,
+			NativeApply: true,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("applies and deletes files with the kube client instead of kubectl", func() {
+		fileStore.EXPECT().Load("settings.yaml").Return(manifests, nil).Times(2)
+		kubeClient.EXPECT().ApplyManifests(manifests, false).Return(results, nil)
+		kubeClient.EXPECT().DeleteManifests(manifests).Return(results, nil)
+		Expect((&kubectl.Apply{Path: "settings.yaml"}).Run(ctx, nil)).To(BeNil())
+		Expect((&kubectl.Delete{Path: "settings.yaml"}).Run(ctx, nil)).To(BeNil())
+		Expect((&kubectl.Apply{Path: "settings.yaml"}).GetDescription(ctx, nil)).To(Equal("Applying settings.yaml"))
+	})
+
+	It("applies rendered templates with the kube client", func() {
+		fileStore.EXPECT().Load("settings.yaml").Return(manifests, nil)
+		kubeClient.EXPECT().ApplyManifests("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n", true).Return(results, nil)
+		Expect((&kubectl.ApplyTemplate{Path: "settings.yaml", Force: true}).Run(ctx, render.Values{"Name": "settings"})).To(BeNil())
+	})
+
+	It("patches with the kube client", func() {
+		patch := &kubectl.Patch{
+			Path:      "patch.yaml",
+			PatchType: kube.MergePatchType,
+			Name:      "{{ .Name }}",
+			Namespace: "default",
+			KubeType:  "configmap",
+		}
+		fileStore.EXPECT().Load("patch.yaml").Return("data:\n  debug: \"true\"\n", nil)
+		kubeClient.EXPECT().PatchObject("configmap", "default", "settings", kube.MergePatchType, "data:\n  debug: \"true\"\n").
+			Return(&results[0], nil)
+		Expect(patch.Run(ctx, render.Values{"Name": "settings"})).To(BeNil())
+	})
+})
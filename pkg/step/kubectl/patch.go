@@ -4,11 +4,16 @@ import (
 	"fmt"
 	errors "github.com/rotisserie/eris"
 	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
 	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
 )
 
-var _ api.Step = new(Patch)
+var (
+	_ api.Step       = new(Patch)
+	_ api.DryRunStep     = new(Patch)
+	_ api.ValidatingStep = new(Patch)
+)
 
 var (
 	UnableToLoadPatchError = func(err error) error {
@@ -25,27 +30,41 @@ type Patch struct {
 }
 
 func (p *Patch) GetCmd(ctx *api.WorkflowContext, values render.Values) (*cmd.Command, error) {
-	if err := values.RenderFields(p, ctx.Runner); err != nil {
+	kubectl, _, err := p.getKubectl(ctx, values)
+	if err != nil {
 		return nil, err
 	}
+	return kubectl.Cmd(), nil
+}
+
+// Returns the kubectl command and the rendered patch
+func (p *Patch) getKubectl(ctx *api.WorkflowContext, values render.Values) (*cmd.Kubectl, string, error) {
+	if err := values.RenderFields(p, ctx.Runner); err != nil {
+		return nil, "", err
+	}
 	patchTemplate, err := ctx.FileStore.Load(p.Path)
 	if err != nil {
-		return nil, UnableToLoadPatchError(err)
+		return nil, "", UnableToLoadPatchError(err)
 	}
 	patchString, err := render.LoadTemplate(patchTemplate, values, ctx.Runner)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	kubectl := cmd.New().Kubectl().
+	kubectl := ctx.Kubectl().
 		With("patch", p.KubeType, p.Name).
 		Namespace(p.Namespace).
 		With("--type", p.PatchType).
-		With("--patch", patchString).
-		Cmd()
-	return kubectl, nil
+		With("--patch", patchString)
+	return kubectl, patchString, nil
 }
 
 func (p *Patch) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if ctx.NativeApply {
+		if err := values.RenderFields(p, ctx.Runner); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Patching %s %s.%s with %s", p.KubeType, p.Namespace, p.Name, p.Path), nil
+	}
 	kubectl, err := p.GetCmd(ctx, values)
 	if err != nil {
 		return "", err
@@ -54,6 +73,18 @@ func (p *Patch) GetDescription(ctx *api.WorkflowContext, values render.Values) (
 }
 
 func (p *Patch) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if ctx.NativeApply {
+		_, patchString, err := p.getKubectl(ctx, values)
+		if err != nil {
+			return err
+		}
+		result, err := ctx.KubeClient.PatchObject(p.KubeType, p.Namespace, p.Name, p.PatchType, patchString)
+		if err != nil {
+			return err
+		}
+		logResults(ctx, kube.ObjectResults{*result})
+		return nil
+	}
 	kubectl, err := p.GetCmd(ctx, values)
 	if err != nil {
 		return err
@@ -61,7 +92,21 @@ func (p *Patch) Run(ctx *api.WorkflowContext, values render.Values) error {
 	return ctx.Runner.Run(kubectl)
 }
 
+func (p *Patch) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	kubectl, patchString, err := p.getKubectl(ctx, values)
+	if err != nil {
+		return err
+	}
+	ctx.GetLogger().Infof("Patch for %s %s.%s:\n%s", p.KubeType, p.Namespace, p.Name, patchString)
+	validateWithServer(ctx, kubectl)
+	return nil
+}
+
+func (p *Patch) Validate(ctx *api.WorkflowContext, values render.Values) error {
+	return validateTemplateFile(ctx, values, p.Path)
+}
+
 func (p *Patch) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
 
@@ -7,13 +7,27 @@ import (
 	"github.com/solo-io/valet/pkg/render"
 )
 
-var _ api.Step = new(ApplyTemplate)
+var (
+	_ api.Step           = new(ApplyTemplate)
+	_ api.DryRunStep     = new(ApplyTemplate)
+	_ api.ValidatingStep = new(ApplyTemplate)
+	_ api.ManifestStep   = new(ApplyTemplate)
+)
 
 type ApplyTemplate struct {
-	Path string `json:"path,omitempty"`
+	Path string `json:"path,omitempty" valet:"template"`
+	// Optional, with native apply, take ownership of fields that other managers (i.e. controllers or helm) own,
+	// rather than failing with a conflict
+	Force bool `json:"force,omitempty"`
 }
 
 func (a *ApplyTemplate) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if ctx.NativeApply {
+		if err := values.RenderFields(a, ctx.Runner); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Applying template %s", a.Path), nil
+	}
 	command, err := a.GetCmd(ctx, values)
 	if err != nil {
 		return "", err
@@ -22,23 +36,52 @@ func (a *ApplyTemplate) GetDescription(ctx *api.WorkflowContext, values render.V
 }
 
 func (a *ApplyTemplate) GetCmd(ctx *api.WorkflowContext, values render.Values) (*cmd.Command, error) {
-	tmpl, err := ctx.FileStore.Load(a.Path)
+	manifests, err := a.loadManifests(ctx, values)
 	if err != nil {
 		return nil, err
 	}
-	loaded, err := render.LoadTemplate(tmpl, values, ctx.Runner)
+	return ctx.Kubectl().ApplyStdIn(manifests).Cmd(), nil
+}
+
+func (a *ApplyTemplate) GetManifests(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	return a.loadManifests(ctx, values)
+}
+
+func (a *ApplyTemplate) loadManifests(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if err := values.RenderFields(a, ctx.Runner); err != nil {
+		return "", err
+	}
+	tmpl, err := ctx.FileStore.Load(a.Path)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return cmd.New().Kubectl().ApplyStdIn(loaded).Cmd(), nil
+	return render.LoadTemplate(tmpl, values, ctx.Runner)
 }
 
 func (a *ApplyTemplate) Run(ctx *api.WorkflowContext, values render.Values) error {
-	command, err := a.GetCmd(ctx, values)
+	manifests, err := a.loadManifests(ctx, values)
 	if err != nil {
 		return err
 	}
-	return ctx.Runner.Run(command)
+	ctx.TrackManifests(manifests)
+	if ctx.NativeApply {
+		return applyNative(ctx, manifests, a.Force)
+	}
+	return ctx.Runner.Run(ctx.Kubectl().ApplyStdIn(manifests).Cmd())
+}
+
+func (a *ApplyTemplate) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	manifests, err := a.loadManifests(ctx, values)
+	if err != nil {
+		return err
+	}
+	ctx.GetLogger().Infof("Manifests:\n%s", manifests)
+	validateWithServer(ctx, ctx.Kubectl().ApplyStdIn(manifests))
+	return nil
+}
+
+func (a *ApplyTemplate) Validate(ctx *api.WorkflowContext, values render.Values) error {
+	return validateTemplateFile(ctx, values, a.Path)
 }
 
 func (a *ApplyTemplate) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
@@ -49,5 +92,5 @@ func (a *ApplyTemplate) GetDocs(ctx *api.WorkflowContext, values render.Values,
 		}
 		return fmt.Sprintf("```yaml\n%s\n```", contents), nil
 	}
-	panic("not implemented")
+	return fmt.Sprintf("Render the template %s with the workflow values, then apply it:\n```\nkubectl apply -f -\n```", a.Path), nil
 }
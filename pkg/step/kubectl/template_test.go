@@ -0,0 +1,47 @@
+package kubectl_test
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+	mock_render "github.com/solo-io/valet/pkg/render/mocks"
+	"github.com/solo-io/valet/pkg/step/kubectl"
+)
+
+var _ = Describe("applyTemplate", func() {
+
+	var (
+		ctrl      *gomock.Controller
+		fileStore *mock_render.MockFileStore
+		ctx       *api.WorkflowContext
+		step      = &kubectl.ApplyTemplate{Path: "petclinic.yaml"}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		fileStore = mock_render.NewMockFileStore(ctrl)
+		ctx = &api.WorkflowContext{
+			FileStore: fileStore,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("returns docs without any flags", func() {
+		docs, err := step.GetDocs(ctx, nil, nil)
+		Expect(err).To(BeNil())
+		Expect(docs).To(ContainSubstring("petclinic.yaml"))
+		Expect(docs).To(ContainSubstring("kubectl apply -f -"))
+	})
+
+	It("returns the template with the yaml only flag", func() {
+		fileStore.EXPECT().Load("petclinic.yaml").Return("kind: Namespace", nil)
+		docs, err := step.GetDocs(ctx, nil, render.Flags{kubectl.DocsFlagYamlOnly})
+		Expect(err).To(BeNil())
+		Expect(docs).To(Equal("```yaml\nkind: Namespace\n```"))
+	})
+})
@@ -0,0 +1,21 @@
+package kubectl
+
+import (
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+// Checks that a template file exists, parses, and only references values that are provided.
+func validateTemplateFile(ctx *api.WorkflowContext, values render.Values, path string) error {
+	if render.IsTemplate(path) {
+		return nil
+	}
+	if err := render.ValidateFile(ctx.FileStore, path); err != nil {
+		return err
+	}
+	tmpl, err := ctx.FileStore.Load(path)
+	if err != nil {
+		return err
+	}
+	return values.ValidateTemplate(tmpl)
+}
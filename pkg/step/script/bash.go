@@ -6,6 +6,12 @@ import (
 	"github.com/solo-io/valet/pkg/api"
 	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
+	"strings"
+)
+
+var (
+	_ api.Step           = new(Bash)
+	_ api.ValidatingStep = new(Bash)
 )
 
 type Bash struct {
@@ -17,7 +23,7 @@ func (b *Bash) GetDescription(ctx *api.WorkflowContext, values render.Values) (s
 	if b.Inline != "" {
 		return fmt.Sprintf("Running command: bash -c '%s'", b.Inline), nil
 	} else if b.Path != "" {
-		return fmt.Sprintf("Running command: bash %s", b.Path), nil
+		return fmt.Sprintf("Running command: bash %s", ctx.ResolvePath(b.Path)), nil
 	}
 	return "", errors.Errorf("Unknown script")
 }
@@ -27,16 +33,29 @@ func (b *Bash) Run(ctx *api.WorkflowContext, values render.Values) error {
 	if b.Inline != "" {
 		args = append(args, "-c", b.Inline)
 	} else if b.Path != "" {
-		args = append(args, b.Path)
+		args = append(args, ctx.ResolvePath(b.Path))
 	}
 	command := cmd.Command{
-		Name:            "bash",
-		Args:            args,
+		Name:       "bash",
+		Args:       args,
+		StdOutOnly: true,
+	}
+	out, err := ctx.Runner.Output(&command)
+	if err != nil {
+		return err
+	}
+	ctx.SetOutput("output", strings.TrimSpace(out))
+	return nil
+}
+
+func (b *Bash) Validate(ctx *api.WorkflowContext, _ render.Values) error {
+	if b.Path == "" {
+		return nil
 	}
-	return ctx.Runner.Run(&command)
+	return render.ValidateFile(ctx.FileStore, b.Path)
 }
 
 func (b *Bash) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
-	panic("implement me")
+	return "", nil
 }
 
@@ -0,0 +1,204 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	StepNotFoundError = func(name string) error {
+		return errors.Errorf("No step found with id %s", name)
+	}
+	InvalidStepRangeError = func(from, until string) error {
+		return errors.Errorf("Step %s comes after step %s", from, until)
+	}
+	WorkflowAlreadyFinishedError = func(lastStep string) error {
+		return errors.Errorf("Nothing to resume, the last step %s already succeeded", lastStep)
+	}
+)
+
+// A Checkpoint records the last step of a workflow that succeeded, so a failed workflow can be resumed
+// from the following step. Checkpoints are only saved after the setup steps succeeded, so setup is skipped on resume.
+type Checkpoint struct {
+	// The id of the last step that succeeded, or its position if it has no id (i.e. "steps[3]"). Empty if only the
+	// setup steps succeeded.
+	LastStep string    `json:"lastStep"`
+	Time     time.Time `json:"time"`
+	// Values captured from step outputs, which are restored when the workflow is resumed. Values that contain a
+	// sensitive value (i.e. a captured token) are left out, so they aren't written to disk.
+	Values render.Values `json:"values,omitempty"`
+}
+
+// Returns the path of the checkpoint file for a workflow file, under ~/.valet/checkpoints. The directory is created
+// when the first checkpoint is saved.
+func GetDefaultCheckpointPath(workflowFile string) (string, error) {
+	valetDir, err := GetValetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(workflowFile)
+	if err != nil {
+		return "", err
+	}
+	checkpointDir := filepath.Join(valetDir, "checkpoints")
+	name := strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath))
+	hash := sha256.Sum256([]byte(absPath))
+	return filepath.Join(checkpointDir, fmt.Sprintf("%s-%x.yaml", name, hash[:4])), nil
+}
+
+// Returns the path of the checkpoint file for one combination of a matrix, next to the checkpoint file of the workflow,
+// so each combination is resumed from its own checkpoint.
+func GetCombinationCheckpointPath(checkpointFile string, combination render.Values) string {
+	hash := sha256.Sum256([]byte(FormatCombination(combination)))
+	ext := filepath.Ext(checkpointFile)
+	return fmt.Sprintf("%s-%x%s", strings.TrimSuffix(checkpointFile, ext), hash[:4], ext)
+}
+
+func LoadCheckpoint(path string, store render.FileStore) (*Checkpoint, error) {
+	var c Checkpoint
+	if exists, err := store.Exists(path); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+	if err := store.LoadYaml(path, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Set up the workflow to continue after the last step in the checkpoint, with the values it captured. Setup is
+// skipped, since it succeeded before the checkpoint was saved.
+func (w *Workflow) ResumeFrom(ctx *api.WorkflowContext, checkpoint *Checkpoint) error {
+	if checkpoint.LastStep == "" {
+		w.setupComplete = true
+		ctx.SharedState = ctx.SharedState.MergeValues(checkpoint.Values)
+		ctx.GetLogger().Infof("Resuming workflow after setup")
+		return nil
+	}
+	index, err := w.findStep(checkpoint.LastStep)
+	if err != nil {
+		return err
+	}
+	if w.Finished(checkpoint) {
+		return WorkflowAlreadyFinishedError(checkpoint.LastStep)
+	}
+	w.From = w.Steps[index+1].GetName(StepsPhase, index+1)
+	w.setupComplete = true
+	ctx.SharedState = ctx.SharedState.MergeValues(checkpoint.Values)
+	ctx.GetLogger().Infof("Resuming workflow after step %s", checkpoint.LastStep)
+	return nil
+}
+
+// Returns true if the last step that succeeded in the checkpoint is the last step of the workflow, so there is
+// nothing to resume.
+func (w *Workflow) Finished(checkpoint *Checkpoint) bool {
+	index, err := w.findStep(checkpoint.LastStep)
+	return err == nil && index == len(w.Steps)-1
+}
+
+// A run that starts from the first step replaces the checkpoint of an earlier run.
+func (w *Workflow) clearCheckpoint(ctx *api.WorkflowContext) {
+	if w.CheckpointFile == "" || ctx.DryRun {
+		return
+	}
+	if err := os.Remove(w.CheckpointFile); err != nil && !os.IsNotExist(err) {
+		ctx.GetLogger().Warnf("Unable to remove checkpoint: %s", err.Error())
+	}
+}
+
+// Save a checkpoint after the last step that succeeded, or after setup if lastStep is empty.
+func (w *Workflow) saveCheckpoint(ctx *api.WorkflowContext, lastStep string) {
+	if w.CheckpointFile == "" || ctx.DryRun {
+		return
+	}
+	if w.stepFailed {
+		ctx.GetLogger().Debugf("Not saving a checkpoint after %s, an earlier step failed", lastStep)
+		return
+	}
+	checkpoint := &Checkpoint{
+		LastStep: lastStep,
+		Time:     time.Now(),
+		Values:   getCheckpointValues(ctx),
+	}
+	if err := os.MkdirAll(filepath.Dir(w.CheckpointFile), os.ModePerm); err != nil {
+		ctx.GetLogger().Warnf("Unable to save checkpoint: %s", err.Error())
+		return
+	}
+	if err := ctx.FileStore.SaveYaml(w.CheckpointFile, checkpoint); err != nil {
+		ctx.GetLogger().Warnf("Unable to save checkpoint: %s", err.Error())
+	}
+}
+
+// Returns the shared state without the values that contain a sensitive value, which aren't restored on resume.
+func getCheckpointValues(ctx *api.WorkflowContext) render.Values {
+	var values render.Values
+	for key, value := range ctx.SharedState {
+		if str, ok := value.(string); ok && ctx.GetRedactor().Redact(str) != str {
+			ctx.GetLogger().Debugf("Value %s contains a secret, leaving it out of the checkpoint", key)
+			continue
+		}
+		if values == nil {
+			values = make(render.Values)
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// Returns the range of steps to run, based on the from and until steps. The end is exclusive.
+func (w *Workflow) getStepRange() (int, int, error) {
+	start, end := 0, len(w.Steps)
+	if w.From != "" {
+		index, err := w.findStep(w.From)
+		if err != nil {
+			return 0, 0, err
+		}
+		start = index
+	}
+	if w.Until != "" {
+		index, err := w.findStep(w.Until)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = index + 1
+	}
+	if (w.From != "" || w.Until != "") && start >= end {
+		return 0, 0, InvalidStepRangeError(w.From, w.Until)
+	}
+	return start, end, nil
+}
+
+// Find a step by id, or by its position (i.e. "steps[3]").
+func (w *Workflow) findStep(name string) (int, error) {
+	for i, step := range w.Steps {
+		if step.GetName(StepsPhase, i) == name || fmt.Sprintf("%s[%d]", StepsPhase, i) == name {
+			return i, nil
+		}
+	}
+	return 0, StepNotFoundError(name)
+}
+
+// Ids identify steps in docs refs, reports and checkpoints, so they should be unique.
+func (w *Workflow) warnDuplicateIds(ctx *api.WorkflowContext) {
+	seen := make(map[string]bool)
+	for _, steps := range [][]*Step{w.SetupSteps, w.Steps, w.CleanupSteps} {
+		for _, step := range steps {
+			if step.Id == "" {
+				continue
+			}
+			if seen[step.Id] {
+				ctx.GetLogger().Warnf("Step id %s is used more than once", step.Id)
+			}
+			seen[step.Id] = true
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+	"github.com/solo-io/valet/pkg/diagnostics"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/step/helm"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// The number of lines of logs collected for each container
+	DiagnosticsLogLines = 200
+)
+
+var (
+	// The kinds described in the diagnostics of each namespace
+	describedKinds = "pods,deployments,statefulsets,daemonsets,jobs,services"
+
+	diagnosticsNameReplacer = strings.NewReplacer("[", "-", "]", "", "/", "-", " ", "-")
+)
+
+// Record the namespaces and helm release that a step touched, so they are included in the diagnostics if it (or a later
+// step) fails. Namespaces come from the namespace field of the step here. Steps that apply or delete manifests record
+// the namespaces of their objects when they render them, so the manifests aren't rendered again.
+func trackDiagnostics(ctx *api.WorkflowContext, knownStep api.Step) {
+	if install, ok := knownStep.(*helm.InstallHelmChart); ok && install.ReleaseName != "" {
+		ctx.Diagnostics.AddRelease(install.ReleaseName, install.Namespace)
+	}
+	if stepValue := reflect.ValueOf(knownStep).Elem(); stepValue.Kind() == reflect.Struct {
+		// Fields are rendered when the step runs, so a namespace that is still a template wasn't used
+		field := stepValue.FieldByName("Namespace")
+		if field.IsValid() && field.Kind() == reflect.String && !render.IsTemplate(field.String()) {
+			ctx.Diagnostics.AddNamespace(field.String())
+		}
+	}
+}
+
+// Collect the error and rendered manifests of a step that failed into the diagnostics bundle, with the pods, events,
+// container logs and describe output of the namespaces the workflow touched, and the status of its helm releases.
+// Collection is best effort: problems are logged, and don't change the result of the step.
+func collectDiagnostics(ctx *api.WorkflowContext, name string, knownStep api.Step, values render.Values, stepErr error) {
+	switch knownStep.(type) {
+	case *IncludedWorkflow, *Parallel:
+		// The step that failed inside collects its own diagnostics
+		return
+	}
+	ctx.GetLogger().Infof("Collecting diagnostics for the failed step")
+	files := map[string]string{
+		"error.txt": stepErr.Error() + "\n",
+	}
+	if manifestStep, ok := knownStep.(api.ManifestStep); ok {
+		if manifests, err := manifestStep.GetManifests(ctx, values); err != nil {
+			ctx.GetLogger().Warnf("Unable to get the manifests of the failed step: %s", err.Error())
+		} else {
+			files["manifests.yaml"] = manifests
+		}
+	}
+	for _, namespace := range ctx.Diagnostics.GetNamespaces() {
+		collectNamespaceDiagnostics(ctx, namespace, files)
+	}
+	for _, release := range ctx.Diagnostics.GetReleases() {
+		collectReleaseDiagnostics(ctx, release, files)
+	}
+	// Logs, events and manifests can include the values of secrets
+	redactor := ctx.GetRedactor()
+	for file, contents := range files {
+		files[file] = redactor.Redact(contents)
+	}
+	dir, err := ctx.Diagnostics.Write(diagnosticsNameReplacer.Replace(name), files)
+	if err != nil {
+		ctx.GetLogger().Warnf("Unable to save diagnostics: %s", err.Error())
+		return
+	}
+	if ctx.Diagnostics.IsTarball() {
+		ctx.GetLogger().Infof("Collected diagnostics for the failed step into %s, which is written to %s when the workflow finishes", dir, ctx.Diagnostics.Path)
+		return
+	}
+	ctx.GetLogger().Infof("Saved diagnostics for the failed step to %s in %s", dir, ctx.Diagnostics.Path)
+}
+
+func collectNamespaceDiagnostics(ctx *api.WorkflowContext, namespace string, files map[string]string) {
+	dir := path.Join("namespaces", namespace)
+	describe := ctx.Kubectl().With("describe", describedKinds).Namespace(namespace).SwallowErrorLog(true).Cmd()
+	if out, err := ctx.Runner.Output(describe); err != nil {
+		files[path.Join(dir, "describe.txt")] = fmt.Sprintf("%s\n%s", err.Error(), out)
+	} else {
+		files[path.Join(dir, "describe.txt")] = out
+	}
+	nsDiagnostics, err := ctx.KubeClient.GetNamespaceDiagnostics(namespace, DiagnosticsLogLines)
+	if err != nil {
+		ctx.GetLogger().Warnf("Unable to collect diagnostics for namespace %s: %s", namespace, err.Error())
+		files[path.Join(dir, "error.txt")] = err.Error() + "\n"
+		return
+	}
+	var pods []v1.Pod
+	for _, pod := range nsDiagnostics.Pods {
+		pods = append(pods, pod.Pod)
+		for container, logs := range pod.Logs {
+			files[path.Join(dir, "logs", pod.Pod.Name, container+".log")] = logs
+		}
+	}
+	files[path.Join(dir, "pods.txt")] = formatPods(nsDiagnostics.Pods)
+	files[path.Join(dir, "events.txt")] = formatEvents(nsDiagnostics.Events)
+	if podsYaml, err := yaml.Marshal(pods); err == nil {
+		files[path.Join(dir, "pods.yaml")] = string(podsYaml)
+	}
+}
+
+func collectReleaseDiagnostics(ctx *api.WorkflowContext, release diagnostics.Release, files map[string]string) {
+	file := path.Join("helm", release.Namespace, release.Name)
+	rel, err := ctx.HelmClient.GetRelease(release.Name, release.Namespace)
+	if err != nil {
+		files[file+".txt"] = err.Error() + "\n"
+		return
+	}
+	status := map[string]interface{}{
+		"name":      rel.Name,
+		"namespace": rel.Namespace,
+		"revision":  rel.Version,
+	}
+	if rel.Info != nil {
+		status["status"] = rel.Info.Status.String()
+		status["description"] = rel.Info.Description
+		status["lastDeployed"] = rel.Info.LastDeployed.String()
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		status["chart"] = fmt.Sprintf("%s-%s", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+		status["appVersion"] = rel.Chart.Metadata.AppVersion
+	}
+	if statusYaml, err := yaml.Marshal(status); err == nil {
+		files[file+".yaml"] = string(statusYaml)
+	}
+	if rel.Manifest != "" {
+		files[file+"-manifest.yaml"] = rel.Manifest
+	}
+}
+
+// Format pods like kubectl get pods, i.e.
+//
+//	NAME          READY   STATUS             RESTARTS
+//	petclinic-1   0/1     CrashLoopBackOff   4
+func formatPods(pods []kube.PodDiagnostics) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREADY\tSTATUS\tRESTARTS")
+	for _, pod := range pods {
+		ready, restarts := 0, int32(0)
+		status := string(pod.Pod.Status.Phase)
+		for _, container := range pod.Pod.Status.ContainerStatuses {
+			if container.Ready {
+				ready++
+			}
+			restarts += container.RestartCount
+			if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
+				status = container.State.Waiting.Reason
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d/%d\t%s\t%d\n", pod.Pod.Name, ready, len(pod.Pod.Status.ContainerStatuses), status, restarts)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// Format events like kubectl get events, i.e.
+//
+//	LAST SEEN              TYPE      REASON    OBJECT            MESSAGE
+//	2020-06-01T12:00:00Z   Warning   BackOff   pod/petclinic-1   Back-off restarting failed container
+func formatEvents(events []v1.Event) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, event := range events {
+		object := fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name)
+		lastSeen := event.LastTimestamp.UTC().Format("2006-01-02T15:04:05Z")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", lastSeen, event.Type, event.Reason, object, strings.TrimSpace(event.Message))
+	}
+	w.Flush()
+	return buf.String()
+}
@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+// Show the values of the step and what it would do, without running it. Steps that don't implement
+// api.DryRunStep are skipped. Captured values are replaced with placeholders, so later steps can still render.
+func (s *Step) dryRun(ctx *api.WorkflowContext, knownStep api.Step, values render.Values) error {
+	if len(values) > 0 {
+		ctx.GetLogger().Infof("Values:\n%s", formatValues(values))
+	}
+	if dryRunStep, ok := knownStep.(api.DryRunStep); ok {
+		if err := dryRunStep.DryRun(ctx, values); err != nil {
+			return err
+		}
+	} else {
+		ctx.GetLogger().Infof("Skipping %s step in dry run", s.GetType())
+	}
+	for key, output := range s.Capture {
+		if ctx.SharedState == nil {
+			ctx.SharedState = make(render.Values)
+		}
+		ctx.SharedState[key] = render.RawPrefix + fmt.Sprintf("<%s of %s step>", output, s.GetType())
+	}
+	return nil
+}
+
+func formatValues(values render.Values) string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %v", key, values[key]))
+	}
+	return strings.Join(lines, "\n")
+}
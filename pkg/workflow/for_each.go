@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/report"
+)
+
+const (
+	DefaultItemValue = "Item"
+)
+
+var (
+	InvalidForEachError = errors.Errorf("forEach must provide exactly one of items or value")
+	ItemFailedError     = func(name string, item interface{}, err error) error {
+		return errors.Wrapf(err, "Step failed for %s=%v", name, item)
+	}
+)
+
+// Repeat a step once for each item of a list, i.e. to apply a manifest into several namespaces. Each item is bound
+// to a value (Item by default), which is available to the step like any other value.
+//
+// The step is reported as a unit. It stops at the first item that fails, and is skipped if it is skipped
+// (i.e. by a when condition) for every item.
+type ForEach struct {
+	// The items to repeat the step for
+	Items []string `json:"items,omitempty"`
+	// Or, the name of a value that holds a list of items, or a string of items separated by commas or newlines
+	// (i.e. the output of a cmd: value)
+	Value string `json:"value,omitempty"`
+	// Optional, the name of the value each item is bound to
+	As string `json:"as,omitempty"`
+}
+
+// Return the name of the value each item is bound to.
+func (f *ForEach) GetAs() string {
+	if f.As == "" {
+		return DefaultItemValue
+	}
+	return f.As
+}
+
+// Return the items to repeat the step for. Items of a list value keep their type, i.e. a map, so their fields can be
+// used in templates.
+func (f *ForEach) GetItems(values render.Values, runner cmd.Runner) ([]interface{}, error) {
+	if (len(f.Items) == 0) == (f.Value == "") {
+		return nil, InvalidForEachError
+	}
+	var items []interface{}
+	if len(f.Items) > 0 {
+		for _, item := range f.Items {
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	list, err := values.Get(f.Value, runner)
+	if err != nil {
+		return nil, err
+	}
+	if listItems, ok := list.([]interface{}); ok {
+		return listItems, nil
+	}
+	formatted, err := render.FormatValue(list)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range strings.FieldsFunc(formatted, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (f *ForEach) validate(values render.Values) error {
+	if (len(f.Items) == 0) == (f.Value == "") {
+		return InvalidForEachError
+	}
+	if f.Value != "" && !values.ContainsKey(f.Value) {
+		return render.MissingValueError(f.Value)
+	}
+	return nil
+}
+
+func (w *Workflow) runForEach(ctx *api.WorkflowContext, phase string, index int, step *Step, values render.Values, result *report.StepResult) error {
+	items, err := step.ForEach.GetItems(values, ctx.Runner)
+	if err != nil {
+		return err
+	}
+	as := step.ForEach.GetAs()
+	logger := ctx.Logger
+	defer func() {
+		ctx.Logger = logger
+	}()
+	ran := false
+	for _, item := range items {
+		// Steps render their fields in place, so each item runs against a fresh copy of the step
+		iteration, err := step.deepCopy()
+		if err != nil {
+			return err
+		}
+		ctx.Logger = logger.With(as, item)
+		err = w.runOnce(ctx, phase, index, iteration, values.MergeValues(render.Values{as: item}), result)
+		if err == stepSkippedError {
+			continue
+		} else if err != nil {
+			return ItemFailedError(as, item, err)
+		}
+		ran = true
+	}
+	if !ran {
+		return stepSkippedError
+	}
+	return nil
+}
+
+func (s *Step) deepCopy() (*Step, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var copied Step
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
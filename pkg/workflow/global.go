@@ -51,3 +51,11 @@ func LoadDefaultGlobalConfig(store render.FileStore) (*api.ValetGlobalConfig, er
 	}
 	return LoadGlobalConfig(globalConfigPath, store)
 }
+
+// Load the global config from path, or from the default location if path is empty.
+func LoadGlobalConfigOrDefault(path string, store render.FileStore) (*api.ValetGlobalConfig, error) {
+	if path == "" {
+		return LoadDefaultGlobalConfig(store)
+	}
+	return LoadGlobalConfig(path, store)
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	_ api.Step           = new(IncludedWorkflow)
+	_ api.DryRunStep     = new(IncludedWorkflow)
+	_ api.ValidatingStep = new(IncludedWorkflow)
+)
+
+// workflow.IncludedWorkflow is a step that runs the setup and steps of another workflow file (local or a url)
+// as a unit, so common steps can be shared between workflows.
+//
+// The values of the step (including the workflow values) override the values of the included workflow.
+// Relative paths in the included workflow are resolved against its own directory.
+type IncludedWorkflow struct {
+	Path string `json:"path,omitempty" valet:"template"`
+}
+
+func (i *IncludedWorkflow) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	if err := values.RenderFields(i, ctx.Runner); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Running workflow %s", i.Path), nil
+}
+
+func (i *IncludedWorkflow) Run(ctx *api.WorkflowContext, values render.Values) error {
+	included, err := i.load(ctx, values)
+	if err != nil {
+		return err
+	}
+	return i.inScope(ctx, func() error {
+		if err := included.SetupOrCleanup(ctx); err != nil {
+			return err
+		}
+		return included.Run(ctx)
+	})
+}
+
+// The included workflow runs in dry run mode too.
+func (i *IncludedWorkflow) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	return i.Run(ctx, values)
+}
+
+// A templated path can't be checked until the step runs, since it may use values captured from earlier steps, so the
+// included workflow is only validated when its path is known.
+func (i *IncludedWorkflow) Validate(ctx *api.WorkflowContext, values render.Values) error {
+	if render.IsTemplate(i.Path) {
+		return nil
+	}
+	if err := render.ValidateFile(ctx.FileStore, i.Path); err != nil {
+		return err
+	}
+	included, err := i.load(ctx, values)
+	if err != nil {
+		return err
+	}
+	return i.inScope(ctx, func() error {
+		return included.Validate(ctx)
+	})
+}
+
+func (i *IncludedWorkflow) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
+	if err := values.RenderFields(i, ctx.Runner); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Run the setup, steps and cleanup of the workflow in `%s`.", i.Path), nil
+}
+
+func (i *IncludedWorkflow) load(ctx *api.WorkflowContext, values render.Values) (*Workflow, error) {
+	if err := values.RenderFields(i, ctx.Runner); err != nil {
+		return nil, err
+	}
+	included := &Workflow{}
+	if err := ctx.FileStore.LoadYaml(i.Path, included); err != nil {
+		return nil, err
+	}
+	included.Values = included.Values.MergeValues(values)
+	return included, nil
+}
+
+// Run f with relative paths resolved against the directory of the included workflow. The steps of the included
+// workflow aren't added to the report, so the step is reported as a unit.
+func (i *IncludedWorkflow) inScope(ctx *api.WorkflowContext, f func() error) error {
+	dir := render.Dir(ctx.ResolvePath(i.Path))
+	logger, fileStore, previousDir, previousReport := ctx.Logger, ctx.FileStore, ctx.Dir, ctx.Report
+	ctx.Logger = ctx.GetLogger().With("workflow", i.Path)
+	ctx.FileStore = render.NewRelativeFileStore(fileStore, dir)
+	ctx.Dir = dir
+	ctx.Report = nil
+	defer func() {
+		ctx.Logger, ctx.FileStore, ctx.Dir, ctx.Report = logger, fileStore, previousDir, previousReport
+		// The outputs of the last included step aren't outputs of this step
+		ctx.Outputs = nil
+	}()
+	return f()
+}
@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"fmt"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	AbortedByPresenterError = errors.Errorf("Workflow aborted by the presenter")
+
+	// Returned when the presenter skips a step, or its when condition is false, so it is reported as skipped
+	// instead of failed
+	stepSkippedError = errors.Errorf("Step skipped")
+)
+
+// Wait for the presenter before running the step. Returns an error if they skip the step or abort the workflow.
+func promptBeforeStep(ctx *api.WorkflowContext, knownStep api.Step, values render.Values) error {
+	if ctx.ShowDocs {
+		if docs := getDocs(ctx, knownStep, values); docs != "" {
+			ctx.GetLogger().Infof("\n%s", docs)
+		}
+	}
+	choice, err := ctx.Prompter.Prompt("Run this step?", cmd.ContinueChoice, cmd.SkipChoice, cmd.AbortChoice)
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case cmd.SkipChoice:
+		return stepSkippedError
+	case cmd.AbortChoice:
+		return AbortedByPresenterError
+	}
+	return nil
+}
+
+// Let the presenter decide what to do with a failed step. Returns nil if the step should be retried, otherwise
+// an error to stop running the step.
+func promptAfterFailure(ctx *api.WorkflowContext, stepErr error) error {
+	choice, err := ctx.Prompter.Prompt(fmt.Sprintf("Step failed: %s. Retry?", ctx.GetRedactor().Redact(stepErr.Error())), cmd.RetryChoice, cmd.SkipChoice, cmd.AbortChoice)
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case cmd.SkipChoice:
+		return stepSkippedError
+	case cmd.AbortChoice:
+		return stepErr
+	}
+	return nil
+}
+
+// Steps without docs return an empty string.
+func getDocs(ctx *api.WorkflowContext, knownStep api.Step, values render.Values) string {
+	docs, err := knownStep.GetDocs(ctx, values, nil)
+	if err != nil {
+		ctx.GetLogger().Warnf("Unable to get docs for step: %s", err.Error())
+		return ""
+	}
+	return docs
+}
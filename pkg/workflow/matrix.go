@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	CombinationFailedError = func(combination string, err error) error {
+		return errors.Wrapf(err, "Workflow failed for %s", combination)
+	}
+)
+
+// The errors from every combination of the matrix that failed. Each combination runs even if an earlier one failed,
+// so there may be more than one.
+type MatrixErrors []error
+
+func (m MatrixErrors) Error() string {
+	var messages []string
+	for _, err := range m {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d matrix combination(s) failed: [%s]", len(m), strings.Join(messages, "; "))
+}
+
+// Return every combination of the values in the matrix, ordered by the value names. The values of the last name
+// vary fastest. If the workflow has no matrix, there are no combinations.
+func (w *Workflow) GetCombinations() []render.Values {
+	if len(w.Matrix) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range w.Matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	combinations := []render.Values{{}}
+	for _, name := range names {
+		var expanded []render.Values
+		for _, combination := range combinations {
+			for _, value := range w.Matrix[name] {
+				expanded = append(expanded, combination.MergeValues(render.Values{name: value}))
+			}
+		}
+		combinations = expanded
+	}
+	return combinations
+}
+
+// Return a copy of the workflow to run for one combination of the matrix, with the combination's values overriding
+// the values of the workflow.
+func (w *Workflow) ForCombination(combination render.Values) (*Workflow, error) {
+	b, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	copied := &Workflow{}
+	if err := json.Unmarshal(b, copied); err != nil {
+		return nil, err
+	}
+	copied.Matrix = nil
+	copied.Values = copied.Values.MergeValues(combination)
+	copied.From = w.From
+	copied.Until = w.Until
+	if w.CheckpointFile != "" {
+		copied.CheckpointFile = GetCombinationCheckpointPath(w.CheckpointFile, combination)
+	}
+	return copied, nil
+}
+
+// Format a combination for logs and reports, i.e. "ClusterType=kind, GlooVersion=1.3.17".
+func FormatCombination(combination render.Values) string {
+	var entries []string
+	for name, value := range combination {
+		entries = append(entries, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ", ")
+}
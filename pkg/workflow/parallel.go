@@ -0,0 +1,191 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+const (
+	ParallelPhase = "parallel"
+
+	// Stop waiting for the other branches as soon as one fails (default)
+	FailFastMode = "failFast"
+	// Wait for every branch to finish, and report all of the failures
+	WaitAllMode = "waitAll"
+)
+
+var (
+	_ api.Step           = new(Parallel)
+	_ api.DryRunStep     = new(Parallel)
+	_ api.ValidatingStep = new(Parallel)
+
+	UnknownFailureModeError = func(mode string) error {
+		return errors.Errorf("Unknown failure mode %s, must be one of [%s, %s]", mode, FailFastMode, WaitAllMode)
+	}
+	BranchFailedError = func(name string, err error) error {
+		return errors.Wrapf(err, "Parallel step %s failed", name)
+	}
+)
+
+// The errors from every branch of a parallel step that failed, in waitAll mode.
+type ParallelErrors []error
+
+func (p ParallelErrors) Error() string {
+	var messages []string
+	for _, err := range p {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d parallel step(s) failed: [%s]", len(p), strings.Join(messages, "; "))
+}
+
+// workflow.Parallel is a step that runs its steps concurrently, i.e. to install several independent helm charts.
+// To run a sequence of steps in one branch, use a workflow step.
+//
+// Each branch gets its own copy of the workflow context. The output of each branch (including the output of its
+// commands) is held until it finishes, so output from different branches isn't interleaved. Each branch is recorded
+// in the report under the parallel step, i.e. "steps[2].parallel[0]". Values captured by a branch are available after
+// the parallel step.
+//
+// In failFast mode, once one branch fails, the context of the other branches is cancelled, which stops their commands
+// and requests. The parallel step still waits for them to stop, and prints their output, before it fails. In waitAll
+// mode, every branch runs to completion and all failures are returned.
+type Parallel struct {
+	Steps       []*Step `json:"steps,omitempty"`
+	FailureMode string  `json:"failureMode,omitempty" valet:"default=failFast"`
+
+	// The phase the branches are run in, set by the workflow from the position of the parallel step
+	phase string
+}
+
+type branchResult struct {
+	index int
+	ctx   *api.WorkflowContext
+	err   error
+}
+
+func (p *Parallel) GetDescription(ctx *api.WorkflowContext, values render.Values) (string, error) {
+	var types []string
+	for _, step := range p.Steps {
+		types = append(types, step.GetType())
+	}
+	return fmt.Sprintf("Running %d steps in parallel: %s", len(p.Steps), strings.Join(types, ", ")), nil
+}
+
+func (p *Parallel) Run(ctx *api.WorkflowContext, values render.Values) error {
+	if err := values.RenderFields(p, ctx.Runner); err != nil {
+		return err
+	}
+	if p.FailureMode != FailFastMode && p.FailureMode != WaitAllMode {
+		return UnknownFailureModeError(p.FailureMode)
+	}
+	branchesCtx, cancel := context.WithCancel(ctx.GetContext())
+	defer cancel()
+	phase := p.getPhase()
+
+	// Only the workflow values are needed to run a branch; its own values are merged in by runStep
+	branchWorkflow := &Workflow{Values: values}
+	results := make(chan branchResult, len(p.Steps))
+	// Each branch prints its output once it finishes, without interleaving it with the output of another branch
+	var flushLock sync.Mutex
+	for i, step := range p.Steps {
+		logger := log.NewBuffered(ctx.GetLogger())
+		branchCtx := *ctx
+		branchCtx.ScopeLogger(logger)
+		branchCtx.Ctx = branchesCtx
+		branchCtx.Runner = cmd.RunnerWithContext(branchCtx.Runner, branchesCtx)
+		branchCtx.SharedState = ctx.SharedState.DeepCopy()
+		branchCtx.Outputs = nil
+		// The parallel step is prompted for as a unit
+		branchCtx.Prompter = nil
+		go func(i int, step *Step, branchCtx *api.WorkflowContext) {
+			err := branchWorkflow.runStep(branchCtx, phase, i, step)
+			flushLock.Lock()
+			logger.Flush()
+			flushLock.Unlock()
+			results <- branchResult{index: i, ctx: branchCtx, err: err}
+		}(i, step, &branchCtx)
+	}
+
+	// Every branch is waited for, even after a failure, so none of them are still running after the parallel step
+	var errs ParallelErrors
+	for range p.Steps {
+		result := <-results
+		if result.err != nil {
+			errs = append(errs, BranchFailedError(p.Steps[result.index].GetName(phase, result.index), result.err))
+			if p.FailureMode == FailFastMode && len(errs) == 1 {
+				cancel()
+			}
+			continue
+		}
+		ctx.SharedState = ctx.SharedState.MergeValues(result.ctx.SharedState)
+	}
+	if len(errs) > 0 && p.FailureMode == FailFastMode {
+		return errs[0]
+	} else if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *Parallel) getPhase() string {
+	if p.phase == "" {
+		return ParallelPhase
+	}
+	return p.phase
+}
+
+// Each branch runs in dry run mode.
+func (p *Parallel) DryRun(ctx *api.WorkflowContext, values render.Values) error {
+	return p.Run(ctx, values)
+}
+
+func (p *Parallel) Validate(ctx *api.WorkflowContext, values render.Values) error {
+	var errs ValidationErrors
+	if p.FailureMode != "" && p.FailureMode != FailFastMode && p.FailureMode != WaitAllMode {
+		errs = append(errs, UnknownFailureModeError(p.FailureMode))
+	}
+	for i, step := range p.Steps {
+		for _, err := range step.validate(ctx, values.MergeValues(step.Values)) {
+			errs = append(errs, InvalidStepError(step.GetName(ParallelPhase, i), err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Returns the values captured by the steps of each branch.
+func (p *Parallel) capturedKeys() []string {
+	var keys []string
+	for _, step := range p.Steps {
+		for key := range step.Capture {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Returns the docs of each branch, in order.
+func (p *Parallel) GetDocs(ctx *api.WorkflowContext, values render.Values, flags render.Flags) (string, error) {
+	docs := []string{fmt.Sprintf("Run these %d steps at the same time:", len(p.Steps))}
+	for i, step := range p.Steps {
+		stepDocs, err := step.Get().GetDocs(ctx, values.MergeValues(step.Values), flags)
+		if err != nil {
+			return "", err
+		}
+		if stepDocs == "" {
+			stepDocs = fmt.Sprintf("Run the %s step.", step.GetType())
+		}
+		docs = append(docs, fmt.Sprintf("%d. %s", i+1, stepDocs))
+	}
+	return strings.Join(docs, "\n"), nil
+}
@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"github.com/avast/retry-go"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+const (
+	DefaultRetryAttempts = 3
+)
+
+var (
+	InvalidRetryAttemptsError = func(attempts int) error {
+		return errors.Errorf("Retry attempts must be at least 1, got %d", attempts)
+	}
+	StepTimedOutError = func(timeout time.Duration) error {
+		return errors.Errorf("Step did not finish within %s", timeout.String())
+	}
+	OutputNotFoundError = func(output, stepType string) error {
+		return errors.Errorf("Output %s was not published by %s step", output, stepType)
+	}
+)
+
+// Retry a workflow step if it fails. By default, the step is tried 3 times, with a delay of 1 second between attempts.
+type Retry struct {
+	// Optional, the number of times to try the step, at least 1. Use GetAttempts to access.
+	Attempts *int   `json:"attempts,omitempty"`
+	Delay    string `json:"delay,omitempty" valet:"template,default=1s"`
+}
+
+// Returns the number of times to try the step, or DefaultRetryAttempts if it isn't set.
+func (r *Retry) GetAttempts() int {
+	if r.Attempts == nil {
+		return DefaultRetryAttempts
+	}
+	return *r.Attempts
+}
+
+func (r *Retry) validate() error {
+	if attempts := r.GetAttempts(); attempts < 1 {
+		return InvalidRetryAttemptsError(attempts)
+	}
+	return render.ValidateDuration("retry delay", r.Delay)
+}
+
+// Run the step, enforcing the retry and timeout policies of the workflow.Step.
+func (s *Step) runWithPolicy(ctx *api.WorkflowContext, knownStep api.Step, values render.Values) error {
+	if s.Retry == nil {
+		return s.runWithTimeout(ctx, knownStep, values)
+	}
+	retryPolicy := *s.Retry
+	if err := values.RenderFields(&retryPolicy, ctx.Runner); err != nil {
+		return err
+	}
+	attempts := retryPolicy.GetAttempts()
+	if attempts < 1 {
+		return InvalidRetryAttemptsError(attempts)
+	}
+	delay, err := time.ParseDuration(retryPolicy.Delay)
+	if err != nil {
+		return err
+	}
+	return retry.Do(func() error {
+		return s.runWithTimeout(ctx, knownStep, values)
+	}, retry.Delay(delay), retry.Attempts(uint(attempts)), retry.DelayType(retry.FixedDelay), retry.LastErrorOnly(true),
+		// Don't retry once the workflow has been cancelled
+		retry.RetryIf(func(error) bool {
+			return ctx.GetContext().Err() == nil
+		}),
+		retry.OnRetry(func(n uint, err error) {
+			ctx.GetLogger().Warnf("Attempt %d of %d failed: %s", n+1, attempts, err.Error())
+		}))
+}
+
+// If the step times out, its context is cancelled, which stops the commands and requests of its runner. The error is
+// returned once the step has stopped, so a timed out attempt never overlaps with a retry or later steps. Helm calls, and
+// kube client calls that don't take a context (i.e. applying manifests natively), aren't cancelled, so a step that is
+// blocked in one of them only stops (and the workflow only moves on) once the call returns.
+func (s *Step) runWithTimeout(ctx *api.WorkflowContext, knownStep api.Step, values render.Values) error {
+	if s.Timeout == "" {
+		return knownStep.Run(ctx, values)
+	}
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil {
+		return err
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx.GetContext(), timeout)
+	defer cancel()
+	parentCtx, parentRunner := ctx.Ctx, ctx.Runner
+	ctx.Ctx, ctx.Runner = attemptCtx, cmd.RunnerWithContext(ctx.Runner, attemptCtx)
+	defer func() {
+		ctx.Ctx, ctx.Runner = parentCtx, parentRunner
+	}()
+	result := make(chan error, 1)
+	go func() {
+		result <- knownStep.Run(ctx, values)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-attemptCtx.Done():
+		ctx.GetLogger().Warnf("Step did not finish within %s, waiting for it to stop", timeout.String())
+		<-result
+		if attemptCtx.Err() == context.DeadlineExceeded {
+			return StepTimedOutError(timeout)
+		}
+		return attemptCtx.Err()
+	}
+}
+
+// Capture the requested outputs of the step that just ran into the shared state of the workflow.
+func (s *Step) captureOutputs(ctx *api.WorkflowContext) error {
+	for key, output := range s.Capture {
+		value, ok := ctx.Outputs[output]
+		if !ok {
+			return OutputNotFoundError(output, s.GetType())
+		}
+		if ctx.SharedState == nil {
+			ctx.SharedState = make(render.Values)
+		}
+		// Outputs are stored raw, so content like "cmd:..." in a response body is never evaluated
+		ctx.SharedState[key] = render.RawPrefix + value
+		ctx.GetLogger().Debugf("Captured output %s into value %s", output, key)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/solo-io/valet/pkg/client/kube"
+	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var _ render.SecretSource = new(KubeSecretSource)
+
+// Reads a key of a secret from the cluster, i.e. "secret:gloo-system/license#license-key". The secret is read from
+// the kube context that the client targets (see useCluster).
+type KubeSecretSource struct {
+	Client kube.Client
+}
+
+func NewKubeSecretSource(client kube.Client) *KubeSecretSource {
+	return &KubeSecretSource{
+		Client: client,
+	}
+}
+
+func (k *KubeSecretSource) GetSecret(ref string, runner cmd.Runner) (string, error) {
+	path, key := render.SplitSecretRef(ref)
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || key == "" {
+		return "", render.InvalidSecretRefError(ref, "namespace/name#key")
+	}
+	return k.Client.GetSecretValue(parts[0], parts[1], key)
+}
@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"fmt"
 	"github.com/solo-io/valet/pkg/api"
 	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/step/aws"
@@ -10,48 +11,111 @@ import (
 	"github.com/solo-io/valet/pkg/step/kubectl"
 	"github.com/solo-io/valet/pkg/step/script"
 	"reflect"
+	"strings"
 )
 
 // A workflow.Step is a container for an api.Step implementation.
 // Exactly one of the member pointers should be non-nil.
 // This makes it easy to serialize and deserialize a workflow as yaml
 type Step struct {
-	DnsEntry         *aws.DnsEntry          `json:"dnsEntry,omitempty"`
-	Condition        *check.Condition       `json:"condition,omitempty"`
-	Curl             *check.Curl            `json:"curl,omitempty"`
-	WaitForPods      *check.WaitForPods     `json:"waitForPods,omitempty"`
-	EnsureCluster    *cluster.EnsureCluster `json:"ensureCluster,omitempty"`
-	Apply            *kubectl.Apply         `json:"apply,omitempty"`
-	ApplyTemplate    *kubectl.ApplyTemplate `json:"applyTemplate,omitempty"`
-	CreateSecret     *kubectl.CreateSecret  `json:"createSecret,omitempty"`
-	Delete           *kubectl.Delete        `json:"delete,omitempty"`
-	Patch            *kubectl.Patch         `json:"patch,omitempty"`
-	InstallHelmChart *helm.InstallHelmChart `json:"installHelmChart,omitempty"`
-	Bash             *script.Bash           `json:"bash,omitempty"`
+	DnsEntry         *aws.DnsEntry           `json:"dnsEntry,omitempty"`
+	Condition        *check.Condition        `json:"condition,omitempty"`
+	Curl             *check.Curl             `json:"curl,omitempty"`
+	WaitForPods      *check.WaitForPods      `json:"waitForPods,omitempty"`
+	WaitForResources *check.WaitForResources `json:"waitForResources,omitempty"`
+	EnsureCluster    *cluster.EnsureCluster  `json:"ensureCluster,omitempty"`
+	DestroyCluster   *cluster.DestroyCluster `json:"destroyCluster,omitempty"`
+	UseCluster       *cluster.UseCluster     `json:"useCluster,omitempty"`
+	Apply            *kubectl.Apply          `json:"apply,omitempty"`
+	ApplyTemplate    *kubectl.ApplyTemplate  `json:"applyTemplate,omitempty"`
+	CreateSecret     *kubectl.CreateSecret   `json:"createSecret,omitempty"`
+	Delete           *kubectl.Delete         `json:"delete,omitempty"`
+	Patch            *kubectl.Patch          `json:"patch,omitempty"`
+	InstallHelmChart *helm.InstallHelmChart  `json:"installHelmChart,omitempty"`
+	Bash             *script.Bash            `json:"bash,omitempty"`
+	Workflow         *IncludedWorkflow       `json:"workflow,omitempty"`
+	Parallel         *Parallel               `json:"parallel,omitempty"`
 
 	Values render.Values `json:"values,omitempty"`
 	// Optional, used for identifying a specific step in a docs ref
 	Id string `json:"id,omitempty"`
+
+	// Optional, a go template evaluated against the values (i.e. "{{ eq .Edition \"enterprise\" }}"). The step
+	// only runs if it renders to something other than an empty string or false, otherwise it is skipped.
+	When string `json:"when,omitempty"`
+
+	// Optional, repeat the step once for each item of a list
+	ForEach *ForEach `json:"forEach,omitempty"`
+
+	// Optional, retry the step if it fails
+	Retry *Retry `json:"retry,omitempty"`
+	// Optional, fail the step if it doesn't finish within this duration (i.e. "30s"). Commands and requests are
+	// cancelled when it times out, but helm calls and native kube calls run until they return.
+	Timeout string `json:"timeout,omitempty"`
+	// Optional, report the error and continue the workflow if the step fails
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+	// Optional, map of value names to step outputs (i.e. "Token: body"). After the step runs, each output
+	// is captured into the value, which is available to the following steps.
+	Capture map[string]string `json:"capture,omitempty"`
+}
+
+// Return the type of the step, which is the yaml key of the api.Step implementation (i.e. "apply").
+func (s *Step) GetType() string {
+	types := s.GetTypes()
+	if len(types) == 0 {
+		return ""
+	}
+	return types[0]
+}
+
+// Return the types of all of the api.Step implementations that are set. A valid step has exactly one.
+func (s *Step) GetTypes() []string {
+	var types []string
+	structVal := reflect.ValueOf(s).Elem()
+	structType := reflect.TypeOf(s).Elem()
+	for i := 0; i < structType.NumField(); i++ {
+		fieldValue := structVal.Field(i)
+		if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			if _, ok := fieldValue.Interface().(api.Step); ok {
+				types = append(types, strings.Split(structType.Field(i).Tag.Get("json"), ",")[0])
+			}
+		}
+	}
+	return types
+}
+
+// Return the name used to identify the step in output: the id if it was provided, otherwise
+// the phase and index of the step in the workflow (i.e. "steps[3]").
+func (s *Step) GetName(phase string, index int) string {
+	if s.Id != "" {
+		return s.Id
+	}
+	return fmt.Sprintf("%s[%d]", phase, index)
+}
+
+// The phase of the branches of a parallel step, i.e. "steps[2].parallel", or "steps[2].install-charts.parallel"
+// when the parallel step has an id.
+func (s *Step) branchPhase(phase string, index int) string {
+	prefix := fmt.Sprintf("%s[%d]", phase, index)
+	if s.Id != "" {
+		prefix = fmt.Sprintf("%s.%s", prefix, s.Id)
+	}
+	return fmt.Sprintf("%s.%s", prefix, ParallelPhase)
 }
 
 // Return the actual pointer to an api.Step implementation.
 func (s *Step) Get() api.Step {
-	var val interface{}
 	structVal := reflect.ValueOf(s).Elem()
 	structType := reflect.TypeOf(s).Elem()
 	for i := 0; i < structType.NumField(); i++ {
 		fieldValue := structVal.Field(i)
-		if fieldValue.Kind() == reflect.Ptr {
-			if !fieldValue.IsNil() {
-				val = fieldValue.Interface()
-				break
+		if fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+			if step, ok := fieldValue.Interface().(api.Step); ok {
+				return step
 			}
 		}
 	}
-	if val == nil {
-		return nil
-	}
-	return val.(api.Step)
+	return nil
 }
 
 func (s *Step) WithId(id string) *Step {
@@ -59,10 +123,22 @@ func (s *Step) WithId(id string) *Step {
 	return s
 }
 
+func (s *Step) WithWhen(when string) *Step {
+	s.When = when
+	return s
+}
+
+func (s *Step) WithForEach(as string, items ...string) *Step {
+	s.ForEach = &ForEach{
+		Items: items,
+		As:    as,
+	}
+	return s
+}
 
-func (s *Step) WithValue(k, v string) *Step {
+func (s *Step) WithValue(k string, v interface{}) *Step {
 	if s.Values == nil {
-		s.Values = make(map[string]string)
+		s.Values = make(render.Values)
 	}
 	s.Values[k] = v
 	return s
@@ -100,3 +176,26 @@ func WaitForPods(namespace string) *Step {
 	}
 }
 
+func WaitForResources(resources ...string) *Step {
+	return &Step{
+		WaitForResources: &check.WaitForResources{
+			Resources: resources,
+		},
+	}
+}
+
+func IncludeWorkflow(path string) *Step {
+	return &Step{
+		Workflow: &IncludedWorkflow{
+			Path: path,
+		},
+	}
+}
+
+func RunInParallel(steps ...*Step) *Step {
+	return &Step{
+		Parallel: &Parallel{
+			Steps: steps,
+		},
+	}
+}
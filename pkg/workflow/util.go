@@ -6,17 +6,32 @@ import (
 	"github.com/solo-io/valet/pkg/client/helm"
 	"github.com/solo-io/valet/pkg/client/kube"
 	"github.com/solo-io/valet/pkg/cmd"
+	"github.com/solo-io/valet/pkg/log"
 	"github.com/solo-io/valet/pkg/render"
 	"os"
 )
 
 func DefaultContext(ctx context.Context) *api.WorkflowContext {
+	return DefaultContextWithLogger(ctx, log.Default())
+}
+
+// Create a default context where the steps, runner and clients all write to the provided logger. While a step runs,
+// the runner and clients are scoped to the logger of the step (see api.WorkflowContext.ScopeLogger). Sensitive values
+// (i.e. from a vault: value) are redacted from the logs and commands, and secret: values are read with the kube client.
+func DefaultContextWithLogger(ctx context.Context, logger log.Logger) *api.WorkflowContext {
+	redactor := render.NewRedactor()
+	logger = log.NewRedacting(logger, redactor.Redact)
+	kubeClient := kube.NewClient().WithLogger(logger)
+	secretSources := render.NewSecretSources(redactor)
+	secretSources.Register(render.SecretPrefix, NewKubeSecretSource(kubeClient))
 	return &api.WorkflowContext{
 		Ctx:        ctx,
-		Runner:     cmd.DefaultCommandRunner(),
-		FileStore:  render.NewFileStore(),
-		HelmClient: helm.NewClient(),
-		KubeClient: kube.NewClient(),
+		Logger:     logger,
+		Runner:     render.WithSecretSources(cmd.NewRedactingCommandRunner(logger, redactor), secretSources),
+		FileStore:  render.NewFileStore().WithLogger(logger),
+		HelmClient: helm.NewClient().WithLogger(logger),
+		KubeClient: kubeClient,
+		Redactor:   redactor,
 	}
 }
 
@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	NoStepDefinedError        = errors.Errorf("No step defined")
+	MultipleStepsDefinedError = func(types []string) error {
+		return errors.Errorf("Only one step should be defined, found %s", strings.Join(types, ", "))
+	}
+	InvalidStepError = func(name string, err error) error {
+		return errors.Wrapf(err, "Invalid step %s", name)
+	}
+)
+
+type ValidationErrors []error
+
+func (v ValidationErrors) Error() string {
+	var messages []string
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d problem(s) found in workflow: [%s]", len(v), strings.Join(messages, "; "))
+}
+
+// Check the workflow for problems without running anything. Each step must define exactly one type of step, and
+// the values it references must be provided by the workflow, the step, or a value captured by an earlier step.
+// Steps that implement api.ValidatingStep run their own checks, such as for missing files.
+func (w *Workflow) Validate(ctx *api.WorkflowContext) error {
+	w.warnDuplicateIds(ctx)
+	var errs ValidationErrors
+	values := w.Values.DeepCopy()
+	for name := range w.Matrix {
+		values[name] = ""
+	}
+	phases := []struct {
+		name  string
+		steps []*Step
+	}{
+		{SetupPhase, w.SetupSteps},
+		{StepsPhase, w.Steps},
+		{CleanupPhase, w.CleanupSteps},
+	}
+	for _, phase := range phases {
+		for i, step := range phase.steps {
+			for _, err := range step.validate(ctx, values.MergeValues(step.Values)) {
+				errs = append(errs, InvalidStepError(step.GetName(phase.name, i), err))
+			}
+			for key := range step.Capture {
+				values[key] = render.RawPrefix
+			}
+			if step.Parallel != nil {
+				for _, key := range step.Parallel.capturedKeys() {
+					values[key] = render.RawPrefix
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *Step) validate(ctx *api.WorkflowContext, values render.Values) []error {
+	types := s.GetTypes()
+	if len(types) == 0 {
+		return []error{NoStepDefinedError}
+	} else if len(types) > 1 {
+		return []error{MultipleStepsDefinedError(types)}
+	}
+	var errs []error
+	if s.ForEach != nil {
+		if err := s.ForEach.validate(values); err != nil {
+			errs = append(errs, err)
+		}
+		values = values.MergeValues(render.Values{s.ForEach.GetAs(): ""})
+	}
+	if s.When != "" {
+		if err := values.ValidateTemplate(s.When); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := render.ValidateDuration("timeout", s.Timeout); err != nil {
+		errs = append(errs, err)
+	}
+	if s.Retry != nil {
+		if err := s.Retry.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	knownStep := s.Get()
+	errs = append(errs, values.ValidateFields(knownStep)...)
+	if validatingStep, ok := knownStep.(api.ValidatingStep); ok {
+		if err := validatingStep.Validate(ctx, values); err != nil {
+			// Steps that contain other steps report a problem for each of them
+			if validationErrs, ok := err.(ValidationErrors); ok {
+				errs = append(errs, validationErrs...)
+			} else {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,90 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+const (
+	GlobalConfigSource = "global config"
+	WorkflowSource     = "workflow"
+	CliSource          = "cli"
+)
+
+var (
+	ValuesFileSource = func(path string) string {
+		return fmt.Sprintf("values file %s", path)
+	}
+	StepSource = func(name string) string {
+		return fmt.Sprintf("step %s", name)
+	}
+)
+
+// A named set of values, i.e. the values of a values file.
+type ValueSource struct {
+	Name   string
+	Values render.Values
+}
+
+// Sets of values in increasing order of precedence. Values from later sources override values from earlier ones.
+type ValueSources []ValueSource
+
+// Returns the values of every source merged in order of precedence.
+func (s ValueSources) Merge() render.Values {
+	merged := make(render.Values)
+	for _, source := range s {
+		merged = merged.MergeValues(source.Values)
+	}
+	return merged
+}
+
+// Returns the name of the source that each merged value came from, which is the last source that provides it.
+func (s ValueSources) Origins() map[string]string {
+	origins := make(map[string]string)
+	for _, source := range s {
+		for key := range source.Values {
+			origins[key] = source.Name
+		}
+	}
+	return origins
+}
+
+// Load a values file (local or a url), which is a yaml map of values.
+func LoadValuesFile(store render.FileStore, path string) (render.Values, error) {
+	values := make(render.Values)
+	if err := store.LoadYaml(path, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Returns the sources of the values for the workflow, in order of precedence: the global config, the workflow,
+// each values file (in the order they are provided, so an environment overlay such as values-gke.yaml can follow
+// a base values file) and values from the command line. The values of a step override all of these when it runs.
+func (w *Workflow) GetValueSources(store render.FileStore, globalConfig *api.ValetGlobalConfig, valuesFiles []string, cliValues render.Values) (ValueSources, error) {
+	var sources ValueSources
+	if globalConfig != nil {
+		sources = append(sources, ValueSource{Name: GlobalConfigSource, Values: globalConfig.Values})
+	}
+	sources = append(sources, ValueSource{Name: WorkflowSource, Values: w.Values})
+	for _, path := range valuesFiles {
+		values, err := LoadValuesFile(store, path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, ValueSource{Name: ValuesFileSource(path), Values: values})
+	}
+	sources = append(sources, ValueSource{Name: CliSource, Values: cliValues})
+	return sources, nil
+}
+
+// Find a step by id, or by its position (i.e. "steps[3]").
+func (w *Workflow) GetStep(name string) (*Step, error) {
+	index, err := w.findStep(name)
+	if err != nil {
+		return nil, err
+	}
+	return w.Steps[index], nil
+}
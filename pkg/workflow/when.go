@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"strconv"
+	"strings"
+
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/render"
+)
+
+var (
+	InvalidWhenError = func(when string, err error) error {
+		return errors.Wrapf(err, "Unable to evaluate when condition %s", when)
+	}
+)
+
+// Evaluate the when condition of the step against the values. The condition is a go template (with sprig functions)
+// and is true unless it renders to an empty string or a false boolean (i.e. "false" or "0").
+func (s *Step) shouldRun(ctx *api.WorkflowContext, values render.Values) (bool, error) {
+	if s.When == "" {
+		return true, nil
+	}
+	rendered, err := render.LoadTemplate(s.When, values, ctx.Runner)
+	if err != nil {
+		return false, InvalidWhenError(s.When, err)
+	}
+	rendered = strings.TrimSpace(rendered)
+	if rendered == "" {
+		return false, nil
+	}
+	if result, err := strconv.ParseBool(rendered); err == nil {
+		return result, nil
+	}
+	return true, nil
+}
@@ -1,57 +1,263 @@
 package workflow
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/solo-io/valet/pkg/api"
-	"github.com/solo-io/valet/pkg/cmd"
 	"github.com/solo-io/valet/pkg/render"
+	"github.com/solo-io/valet/pkg/report"
+)
+
+const (
+	SetupPhase   = "setup"
+	StepsPhase   = "steps"
+	CleanupPhase = "cleanup"
 )
 
 type Workflow struct {
-	SetupSteps []*Step       `json:"setup,omitempty"`
-	Steps      []*Step       `json:"steps,omitempty"`
-	Values     render.Values `json:"values,omitempty"`
+	SetupSteps []*Step `json:"setup,omitempty"`
+	Steps      []*Step `json:"steps,omitempty"`
+	// Cleanup steps always run after the steps, even if one of the steps failed
+	CleanupSteps []*Step       `json:"cleanup,omitempty"`
+	Values       render.Values `json:"values,omitempty"`
+	// Optional, lists of values to run the whole workflow with (i.e. GlooVersion and ClusterType). The workflow runs
+	// once for each combination of the values, see GetCombinations. Values keep their yaml type, like other values.
+	Matrix map[string][]interface{} `json:"matrix,omitempty"`
+
+	// Optional, the id of the first step to run (or its position, i.e. "steps[3]"). Earlier steps are skipped.
+	From string `json:"-"`
+	// Optional, the id of the last step to run. If the workflow stops before its last step, cleanup is skipped
+	// so the workflow can be continued with From.
+	Until string `json:"-"`
+	// Optional, path to save a Checkpoint to after setup and after each step succeeds
+	CheckpointFile string `json:"-"`
+
+	// Set once the setup steps succeeded, in this run or before the checkpoint the workflow is resumed from
+	setupComplete bool
+	// Set once a step fails under continueOnError. Later checkpoints would skip the failed step on resume, so none
+	// are saved.
+	stepFailed bool
+}
+
+// The errors from every cleanup step that failed. Cleanup continues past a failed step,
+// so there may be more than one.
+type CleanupErrors []error
+
+func (c CleanupErrors) Error() string {
+	var messages []string
+	for _, err := range c {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d cleanup step(s) failed: [%s]", len(c), strings.Join(messages, "; "))
 }
 
+// The error of a setup that failed, with the errors of the cleanup steps that ran after it.
+type SetupErrors struct {
+	SetupErr   error
+	CleanupErr error
+}
+
+func (s *SetupErrors) Error() string {
+	return fmt.Sprintf("setup failed: %s; cleanup after failed setup: %s", s.SetupErr.Error(), s.CleanupErr.Error())
+}
+
+// Returns the error of the setup, so errors.Is and errors.As find it.
+func (s *SetupErrors) Unwrap() error {
+	return s.SetupErr
+}
+
+// Run the setup steps, unless the workflow is resumed from a checkpoint that was saved after they succeeded.
 func (w *Workflow) Setup(ctx *api.WorkflowContext) error {
-	cmd.Stdout().Println("Setting up workflow")
-	for _, step := range w.SetupSteps {
-		knownStep := step.Get()
-		values := w.Values
-		if values == nil && step.Values != nil {
-			values = make(map[string]string)
-		}
-		values = values.MergeValues(step.Values)
-		description, err := knownStep.GetDescription(ctx, values)
-		if err != nil {
-			return err
+	if w.setupComplete {
+		ctx.GetLogger().Infof("Skipping setup, it succeeded before the checkpoint")
+		return nil
+	}
+	ctx.GetLogger().Infof("Setting up workflow")
+	if err := w.runSteps(ctx, SetupPhase, w.SetupSteps, 0); err != nil {
+		return err
+	}
+	w.setupComplete = true
+	w.saveCheckpoint(ctx, "")
+	ctx.GetLogger().Infof("Workflow setup successfully")
+	return nil
+}
+
+// Run the setup steps, and if one fails, the cleanup steps, since setup can leave helm releases and resources behind.
+// If cleanup also fails, both errors are returned as SetupErrors.
+func (w *Workflow) SetupOrCleanup(ctx *api.WorkflowContext) error {
+	setupErr := w.Setup(ctx)
+	if setupErr == nil {
+		return nil
+	}
+	if cleanupErr := w.Cleanup(ctx); cleanupErr != nil {
+		return &SetupErrors{SetupErr: setupErr, CleanupErr: cleanupErr}
+	}
+	return setupErr
+}
+
+// Run the steps of the workflow, followed by the cleanup steps.
+// If a step fails, its error is returned even if cleanup also fails; cleanup errors are reported separately.
+func (w *Workflow) Run(ctx *api.WorkflowContext) error {
+	ctx.GetLogger().Infof("Running workflow")
+	w.warnDuplicateIds(ctx)
+	start, end, err := w.getStepRange()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < start; i++ {
+		ctx.Report.SkipStep(StepsPhase, i, w.Steps[i].Id, w.Steps[i].GetType())
+	}
+	if start > 0 {
+		ctx.GetLogger().Infof("Skipping %d step(s), starting from %s", start, w.From)
+	} else if !w.setupComplete {
+		// Setup replaces the checkpoint of an earlier run when it succeeds, so only clear it if setup didn't run
+		w.clearCheckpoint(ctx)
+	}
+	runErr := w.runSteps(ctx, StepsPhase, w.Steps[:end], start)
+	for i := end; i < len(w.Steps); i++ {
+		ctx.Report.SkipStep(StepsPhase, i, w.Steps[i].Id, w.Steps[i].GetType())
+	}
+	if runErr == nil && end < len(w.Steps) {
+		ctx.GetLogger().Infof("Stopped after %s, skipping cleanup", w.Until)
+		return nil
+	}
+	cleanupErr := w.Cleanup(ctx)
+	if runErr != nil {
+		if cleanupErr != nil {
+			ctx.GetLogger().Errorf("Error cleaning up after failed workflow: %s", cleanupErr.Error())
 		}
-		cmd.Stdout().Println(description)
-		if err := knownStep.Run(ctx, values); err != nil {
-			return err
+		return runErr
+	}
+	if cleanupErr != nil {
+		return cleanupErr
+	}
+	ctx.GetLogger().Infof("Workflow finished successfully")
+	return nil
+}
+
+// Run every cleanup step, even if a previous one failed, and return the aggregated errors.
+func (w *Workflow) Cleanup(ctx *api.WorkflowContext) error {
+	if len(w.CleanupSteps) == 0 {
+		return nil
+	}
+	ctx.GetLogger().Infof("Cleaning up workflow")
+	var errs CleanupErrors
+	for i, step := range w.CleanupSteps {
+		if err := w.runStep(ctx, CleanupPhase, i, step); err != nil {
+			ctx.GetLogger().Errorf("Cleanup step failed: %s", err.Error())
+			errs = append(errs, err)
 		}
 	}
-	cmd.Stdout().Println("Workflow setup successfully")
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-func (w *Workflow) Run(ctx *api.WorkflowContext) error {
-	cmd.Stdout().Println("Running workflow")
-	for _, step := range w.Steps {
-		knownStep := step.Get()
-		values := w.Values
-		if values == nil && step.Values != nil {
-			values = make(map[string]string)
-		}
-		values = values.MergeValues(step.Values)
-		description, err := knownStep.GetDescription(ctx, values)
-		if err != nil {
+// Run the steps starting at the provided index. If a step fails, the rest are skipped.
+func (w *Workflow) runSteps(ctx *api.WorkflowContext, phase string, steps []*Step, start int) error {
+	for i := start; i < len(steps); i++ {
+		if err := w.runStep(ctx, phase, i, steps[i]); err != nil {
+			for j := i + 1; j < len(steps); j++ {
+				ctx.Report.SkipStep(phase, j, steps[j].Id, steps[j].GetType())
+			}
 			return err
 		}
-		cmd.Stdout().Println(description)
-		if err := knownStep.Run(ctx, values); err != nil {
-			return err
+		if phase == StepsPhase {
+			w.saveCheckpoint(ctx, steps[i].GetName(phase, i))
 		}
 	}
-	cmd.Stdout().Println("Workflow finished successfully")
 	return nil
 }
+
+func (w *Workflow) runStep(ctx *api.WorkflowContext, phase string, index int, step *Step) error {
+	// Scope the logger, and the output of the runner and clients, to the step while it runs
+	restore := ctx.ScopeLogger(ctx.GetLogger().With("step", step.GetName(phase, index)))
+	defer restore()
+	result := ctx.Report.StartStep(phase, index, step.Id, step.GetType())
+	err := w.doRunStep(ctx, phase, index, step, result)
+	if err == stepSkippedError {
+		result.Skip()
+		return nil
+	}
+	result.Finish(ctx.GetRedactor().RedactError(err))
+	if err != nil && step.ContinueOnError {
+		ctx.GetLogger().Warnf("Step failed, continuing workflow: %s", err.Error())
+		w.stepFailed = true
+		return nil
+	}
+	return err
+}
+
+func (w *Workflow) doRunStep(ctx *api.WorkflowContext, phase string, index int, step *Step, result *report.StepResult) error {
+	if step.Get() == nil {
+		return NoStepDefinedError
+	}
+	values := w.Values
+	if values == nil && (step.Values != nil || ctx.SharedState != nil) {
+		values = make(render.Values)
+	}
+	values = values.MergeValues(ctx.SharedState).MergeValues(step.Values)
+	if step.ForEach != nil {
+		return w.runForEach(ctx, phase, index, step, values, result)
+	}
+	return w.runOnce(ctx, phase, index, step, values, result)
+}
+
+// Run the step with the provided values, i.e. once for each item of a forEach step.
+func (w *Workflow) runOnce(ctx *api.WorkflowContext, phase string, index int, step *Step, values render.Values, result *report.StepResult) error {
+	knownStep := step.Get()
+	if step.Parallel != nil {
+		// The branches are named after the parallel step, so the branches of two parallel steps don't collide
+		step.Parallel.phase = step.branchPhase(phase, index)
+	}
+	if run, err := step.shouldRun(ctx, values); err != nil {
+		return err
+	} else if !run {
+		ctx.GetLogger().Infof("Skipping %s step, the condition %s is false", step.GetType(), step.When)
+		return stepSkippedError
+	}
+	description, err := knownStep.GetDescription(ctx, values)
+	if err != nil {
+		return err
+	}
+	// Descriptions are shown in logs, prompts and reports, so they shouldn't include secrets
+	description = ctx.GetRedactor().Redact(description)
+	result.SetDescription(description)
+	ctx.GetLogger().Infof("%s", description)
+	if ctx.Prompter != nil {
+		if err := promptBeforeStep(ctx, knownStep, values); err != nil {
+			return err
+		}
+	}
+	ctx.Outputs = nil
+	if ctx.DryRun {
+		return step.dryRun(ctx, knownStep, values)
+	}
+	for {
+		err = step.runWithPolicy(ctx, knownStep, values)
+		if err == nil || ctx.Prompter == nil {
+			break
+		}
+		// Run the step again unless the presenter skips or aborts it
+		if err = promptAfterFailure(ctx, err); err != nil {
+			break
+		}
+	}
+	if ctx.Diagnostics != nil {
+		trackDiagnostics(ctx, knownStep)
+		if err != nil && err != stepSkippedError {
+			collectDiagnostics(ctx, step.GetName(phase, index), knownStep, values, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	outputs := make(map[string]string)
+	for name, value := range ctx.Outputs {
+		outputs[name] = ctx.GetRedactor().Redact(value)
+	}
+	result.SetOutputs(outputs)
+	return step.captureOutputs(ctx)
+}
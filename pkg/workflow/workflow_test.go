@@ -0,0 +1,1260 @@
+package workflow_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	errors "github.com/rotisserie/eris"
+	"github.com/solo-io/valet/pkg/api"
+	"github.com/solo-io/valet/pkg/client/kube"
+	mock_kube "github.com/solo-io/valet/pkg/client/kube/mocks"
+	"github.com/solo-io/valet/pkg/cmd"
+	mock_cmd "github.com/solo-io/valet/pkg/cmd/mocks"
+	"github.com/solo-io/valet/pkg/diagnostics"
+	"github.com/solo-io/valet/pkg/log"
+	"github.com/solo-io/valet/pkg/render"
+	mock_render "github.com/solo-io/valet/pkg/render/mocks"
+	"github.com/solo-io/valet/pkg/report"
+	"github.com/solo-io/valet/pkg/step/check"
+	"github.com/solo-io/valet/pkg/step/kubectl"
+	"github.com/solo-io/valet/pkg/step/script"
+	"github.com/solo-io/valet/pkg/workflow"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("workflow", func() {
+
+	var (
+		ctrl   *gomock.Controller
+		runner *mock_cmd.MockRunner
+		ctx    *api.WorkflowContext
+
+		stepErr    = errors.Errorf("step failed")
+		cleanupErr = errors.Errorf("cleanup failed")
+
+		bash = func(inline string) *workflow.Step {
+			return &workflow.Step{
+				Bash: &script.Bash{
+					Inline: inline,
+				},
+			}
+		}
+		bashCmd = func(inline string) *cmd.Command {
+			return &cmd.Command{
+				Name:       "bash",
+				Args:       []string{"-c", inline},
+				StdOutOnly: true,
+			}
+		}
+	)
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(T)
+		runner = mock_cmd.NewMockRunner(ctrl)
+		ctx = &api.WorkflowContext{
+			Runner: runner,
+		}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("cleanup", func() {
+		var (
+			toRun *workflow.Workflow
+		)
+
+		BeforeEach(func() {
+			toRun = &workflow.Workflow{
+				Steps:        []*workflow.Step{bash("step-1"), bash("step-2")},
+				CleanupSteps: []*workflow.Step{bash("cleanup-1"), bash("cleanup-2")},
+			}
+		})
+
+		It("runs cleanup after the steps", func() {
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("runs cleanup when a step fails and returns the step error", func() {
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+		})
+
+		It("doesn't hide the step error when cleanup also fails", func() {
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", cleanupErr),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+		})
+
+		It("runs every cleanup step and aggregates the errors", func() {
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", cleanupErr),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", cleanupErr),
+			)
+			err := toRun.Run(ctx)
+			Expect(err).To(Equal(workflow.CleanupErrors{cleanupErr, cleanupErr}))
+		})
+
+		It("runs cleanup when setup fails", func() {
+			toRun.SetupSteps = []*workflow.Step{bash("setup-1"), bash("setup-2")}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("setup-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", nil),
+			)
+			Expect(toRun.SetupOrCleanup(ctx)).To(Equal(stepErr))
+		})
+
+		It("aggregates the setup error with the cleanup errors", func() {
+			toRun.SetupSteps = []*workflow.Step{bash("setup-1")}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("setup-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", cleanupErr),
+				runner.EXPECT().Output(bashCmd("cleanup-2")).Return("", nil),
+			)
+			err := toRun.SetupOrCleanup(ctx)
+			Expect(err).To(Equal(&workflow.SetupErrors{SetupErr: stepErr, CleanupErr: workflow.CleanupErrors{cleanupErr}}))
+			Expect(err.Error()).To(ContainSubstring("step failed"))
+			Expect(err.Error()).To(ContainSubstring("cleanup failed"))
+		})
+
+		It("doesn't run cleanup when setup succeeds", func() {
+			toRun.SetupSteps = []*workflow.Step{bash("setup-1")}
+			runner.EXPECT().Output(bashCmd("setup-1")).Return("", nil)
+			Expect(toRun.SetupOrCleanup(ctx)).To(BeNil())
+		})
+	})
+
+	Context("report", func() {
+
+		It("records the result of each step", func() {
+			ctx.Report = report.New("workflow.yaml")
+			toRun := &workflow.Workflow{
+				Steps:        []*workflow.Step{bash("step-1").WithId("first"), bash("step-2"), bash("step-3")},
+				CleanupSteps: []*workflow.Step{bash("cleanup-1")},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+			steps := ctx.Report.Steps
+			Expect(steps).To(HaveLen(4))
+			Expect(steps[0].Name()).To(Equal("steps[0] first (bash)"))
+			Expect(steps[0].Status).To(Equal(report.StatusPassed))
+			Expect(steps[0].Description).To(Equal("Running command: bash -c 'step-1'"))
+			Expect(steps[1].Status).To(Equal(report.StatusFailed))
+			Expect(steps[1].Error).To(Equal(stepErr.Error()))
+			Expect(steps[2].Name()).To(Equal("steps[2] (bash)"))
+			Expect(steps[2].Status).To(Equal(report.StatusSkipped))
+			Expect(steps[3].Phase).To(Equal(workflow.CleanupPhase))
+			Expect(steps[3].Status).To(Equal(report.StatusPassed))
+		})
+
+		It("records the outputs of each step, with secrets redacted", func() {
+			ctx.Report = report.New("workflow.yaml")
+			ctx.Redactor = render.NewRedactor()
+			ctx.Redactor.Add("hunter2")
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("login")}}
+			runner.EXPECT().Output(bashCmd("login")).Return("token hunter2", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps[0].Outputs).To(Equal(map[string]string{"output": "token " + cmd.Redacted}))
+		})
+	})
+
+	Context("secrets", func() {
+		var (
+			kubeClient *mock_kube.MockClient
+		)
+
+		BeforeEach(func() {
+			kubeClient = mock_kube.NewMockClient(ctrl)
+			ctx.Redactor = render.NewRedactor()
+			secretSources := render.NewSecretSources(ctx.Redactor)
+			secretSources.Register(render.SecretPrefix, workflow.NewKubeSecretSource(kubeClient))
+			ctx.Runner = render.WithSecretSources(runner, secretSources)
+		})
+
+		It("reads secret values from the cluster and redacts them from descriptions", func() {
+			ctx.Report = report.New("workflow.yaml")
+			kubeClient.EXPECT().GetSecretValue("gloo-system", "license", "key").Return("gloo-license-key", nil).AnyTimes()
+			toRun := &workflow.Workflow{
+				Steps:  []*workflow.Step{{Condition: &check.Condition{Type: "pod", Name: "{{ .License }}"}}},
+				Values: render.Values{"License": "secret:gloo-system/license#key"},
+			}
+			runner.EXPECT().Output((&check.Condition{Type: "pod", Name: "gloo-license-key"}).GetCmd(ctx)).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps[0].Description).NotTo(ContainSubstring("gloo-license-key"))
+			Expect(ctx.Report.Steps[0].Description).To(ContainSubstring(cmd.Redacted))
+		})
+
+		It("redacts secrets from errors in the report", func() {
+			ctx.Report = report.New("workflow.yaml")
+			ctx.Redactor = render.NewRedactor()
+			ctx.Redactor.Add("hunter2")
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("login")}}
+			runner.EXPECT().Output(bashCmd("login")).Return("", errors.Errorf("bad password hunter2"))
+			Expect(toRun.Run(ctx)).To(HaveOccurred())
+			Expect(ctx.Report.Steps[0].Error).To(Equal("bad password " + cmd.Redacted))
+		})
+
+		It("keeps the secrets of each context separate", func() {
+			first := workflow.DefaultContext(context.Background())
+			second := workflow.DefaultContext(context.Background())
+			first.GetRedactor().Add("hunter2")
+			Expect(first.GetRedactor().Redact("hunter2")).To(Equal(cmd.Redacted))
+			Expect(second.GetRedactor().Redact("hunter2")).To(Equal("hunter2"))
+		})
+
+		It("requires a namespace, name and key", func() {
+			source := workflow.NewKubeSecretSource(kubeClient)
+			_, err := source.GetSecret("license#key", runner)
+			Expect(err).To(MatchError(render.InvalidSecretRefError("license#key", "namespace/name#key").Error()))
+		})
+	})
+
+	Context("logging", func() {
+
+		It("scopes the logger to each step", func() {
+			out := &bytes.Buffer{}
+			ctx.Logger = log.New(log.Options{Out: out, Err: out})
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{bash("step-1").WithId("first"), bash("step-2")},
+			}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", nil)
+			runner.EXPECT().Output(bashCmd("step-2")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(out.String()).To(ContainSubstring("Running command: bash -c 'step-1' step=first\n"))
+			Expect(out.String()).To(ContainSubstring("Running command: bash -c 'step-2' step=steps[1]\n"))
+			Expect(out.String()).To(ContainSubstring("Workflow finished successfully\n"))
+		})
+
+		It("scopes the output of the runner to each step", func() {
+			out := &bytes.Buffer{}
+			ctx := workflow.DefaultContextWithLogger(context.TODO(), log.New(log.Options{Out: out, Err: out}))
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{bash("echo failed >&2; exit 3").WithId("failing")},
+			}
+			Expect(toRun.Run(ctx)).NotTo(BeNil())
+			Expect(out.String()).To(ContainSubstring("Error running command: exit status 3 step=failing\n"))
+			Expect(out.String()).To(ContainSubstring("failed\n step=failing\n"))
+		})
+	})
+
+	Context("step policies", func() {
+
+		attempts := func(attempts int) *int {
+			return &attempts
+		}
+
+		It("retries a failed step", func() {
+			step := bash("step-1")
+			step.Retry = &workflow.Retry{Attempts: attempts(3), Delay: "1ms"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr).Times(2),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("returns the last error when retries are exhausted", func() {
+			step := bash("step-1")
+			step.Retry = &workflow.Retry{Attempts: attempts(2), Delay: "1ms"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step, bash("step-2")}}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr).Times(2)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+		})
+
+		It("fails a step that times out", func() {
+			step := bash("step-1")
+			step.Timeout = "10ms"
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			runner.EXPECT().Output(bashCmd("step-1")).DoAndReturn(func(_ *cmd.Command) (string, error) {
+				time.Sleep(100 * time.Millisecond)
+				return "", nil
+			})
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(workflow.StepTimedOutError(10 * time.Millisecond).Error()))
+		})
+
+		It("waits for a timed out attempt to stop before retrying", func() {
+			step := bash("step-1")
+			step.Timeout = "10ms"
+			step.Retry = &workflow.Retry{Attempts: attempts(2), Delay: "1ms"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			var running, overlapping int32
+			runner.EXPECT().Output(bashCmd("step-1")).DoAndReturn(func(_ *cmd.Command) (string, error) {
+				if atomic.AddInt32(&running, 1) > 1 {
+					atomic.StoreInt32(&overlapping, 1)
+				}
+				time.Sleep(50 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return "", nil
+			}).Times(2)
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(workflow.StepTimedOutError(10 * time.Millisecond).Error()))
+			Expect(atomic.LoadInt32(&overlapping)).To(Equal(int32(0)))
+			Expect(atomic.LoadInt32(&running)).To(Equal(int32(0)))
+		})
+
+		It("cancels the context of a step that times out", func() {
+			kubeClient := mock_kube.NewMockClient(ctrl)
+			ctx.KubeClient = kubeClient
+			step := &workflow.Step{WaitForResources: &check.WaitForResources{
+				Resources: []string{"deployment/petclinic"},
+				Namespace: "default",
+				Timeout:   "1m",
+				Interval:  "1ms",
+			}, Timeout: "10ms"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			checker := mock_kube.NewMockResourceChecker(ctrl)
+			kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+			checker.EXPECT().GetReadiness(gomock.Any()).Return([]kube.Readiness{{Resource: kube.ResourceRef{Kind: "Deployment", Name: "petclinic"}}}, nil).AnyTimes()
+			start := time.Now()
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(workflow.StepTimedOutError(10 * time.Millisecond).Error()))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+
+		It("continues the workflow when a step fails and continueOnError is set", func() {
+			step := bash("step-1")
+			step.ContinueOnError = true
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step, bash("step-2")}}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+	})
+
+	Context("dry run", func() {
+		It("skips steps that don't support a dry run and uses placeholders for captured values", func() {
+			ctx.DryRun = true
+			step := bash("step-1")
+			step.Capture = map[string]string{"TOKEN": "output"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step, bash("step-2")}, CleanupSteps: []*workflow.Step{bash("cleanup-1")}}
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.SharedState.GetValue("TOKEN", runner)).To(Equal("<output of bash step>"))
+		})
+	})
+
+	Context("validate", func() {
+		It("flags steps that define zero or multiple types of step", func() {
+			multiple := bash("step-1")
+			multiple.Curl = &check.Curl{}
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{multiple, {}}}
+			err := toValidate.Validate(ctx)
+			Expect(err).To(BeAssignableToTypeOf(workflow.ValidationErrors{}))
+			errs := err.(workflow.ValidationErrors)
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Error()).To(ContainSubstring(workflow.MultipleStepsDefinedError([]string{"curl", "bash"}).Error()))
+			Expect(errs[1].Error()).To(ContainSubstring(workflow.NoStepDefinedError.Error()))
+		})
+
+		It("accepts values from the workflow, the step and earlier captures", func() {
+			capturing := bash("step-1")
+			capturing.Capture = map[string]string{"Captured": "output"}
+			condition := &workflow.Step{Condition: &check.Condition{Name: "{{ .Workflow }}-{{ .Step }}-{{ .Captured }}"}}
+			condition = condition.WithValue("Step", "step")
+			toValidate := &workflow.Workflow{
+				Steps:  []*workflow.Step{capturing, condition},
+				Values: render.Values{"Workflow": "workflow"},
+			}
+			Expect(toValidate.Validate(ctx)).To(BeNil())
+		})
+
+		It("flags retry policies with fewer than 1 attempt", func() {
+			for _, attempts := range []int{0, -1} {
+				step := bash("step-1")
+				step.Retry = &workflow.Retry{Attempts: &attempts}
+				errs := (&workflow.Workflow{Steps: []*workflow.Step{step}}).Validate(ctx).(workflow.ValidationErrors)
+				Expect(errs).To(HaveLen(1))
+				Expect(errs[0].Error()).To(ContainSubstring(workflow.InvalidRetryAttemptsError(attempts).Error()))
+			}
+			// Without attempts, the step is tried the default number of times
+			step := bash("step-1")
+			step.Retry = &workflow.Retry{}
+			Expect((&workflow.Workflow{Steps: []*workflow.Step{step}}).Validate(ctx)).To(BeNil())
+			Expect(step.Retry.GetAttempts()).To(Equal(workflow.DefaultRetryAttempts))
+		})
+
+		It("flags missing values and invalid durations", func() {
+			condition := &workflow.Step{Condition: &check.Condition{Name: "{{ .Missing }}", Interval: "often"}}
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{condition}}
+			errs := toValidate.Validate(ctx).(workflow.ValidationErrors)
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Error()).To(ContainSubstring(render.MissingValueError("Missing").Error()))
+			Expect(errs[1].Error()).To(ContainSubstring(render.InvalidDurationError("interval", "often").Error()))
+		})
+	})
+
+	Context("interactive", func() {
+		var (
+			prompter     *mock_cmd.MockPrompter
+			beforeStep   = []cmd.PromptChoice{cmd.ContinueChoice, cmd.SkipChoice, cmd.AbortChoice}
+			afterFailure = []cmd.PromptChoice{cmd.RetryChoice, cmd.SkipChoice, cmd.AbortChoice}
+		)
+
+		BeforeEach(func() {
+			prompter = mock_cmd.NewMockPrompter(ctrl)
+			ctx.Prompter = prompter
+			ctx.Report = report.New("workflow.yaml")
+		})
+
+		It("waits for the presenter before each step and skips steps", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1"), bash("step-2")}}
+			gomock.InOrder(
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.SkipChoice, nil),
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.ContinueChoice, nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps[0].Status).To(Equal(report.StatusSkipped))
+			Expect(ctx.Report.Steps[1].Status).To(Equal(report.StatusPassed))
+		})
+
+		It("shows the docs of steps that have them", func() {
+			out := &bytes.Buffer{}
+			ctx.Logger = log.New(log.Options{Out: out, Err: out})
+			ctx.ShowDocs = true
+			kubeClient := mock_kube.NewMockClient(ctrl)
+			ctx.KubeClient = kubeClient
+			waitStep := &workflow.Step{WaitForPods: &check.WaitForPods{Namespace: "petclinic"}}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1"), waitStep}}
+			gomock.InOrder(
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.ContinueChoice, nil),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.ContinueChoice, nil),
+				kubeClient.EXPECT().WaitForPods(gomock.Any()).Return(nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(strings.Count(out.String(), "Use `kubectl get pods -n petclinic`")).To(Equal(1))
+		})
+
+		It("retries a failed step", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1")}}
+			gomock.InOrder(
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.ContinueChoice, nil),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				prompter.EXPECT().Prompt(gomock.Any(), afterFailure).Return(cmd.RetryChoice, nil),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("returns the step error when the presenter aborts after a failure", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1"), bash("step-2")}}
+			gomock.InOrder(
+				prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.ContinueChoice, nil),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				prompter.EXPECT().Prompt(gomock.Any(), afterFailure).Return(cmd.AbortChoice, nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+			Expect(ctx.Report.Steps[1].Status).To(Equal(report.StatusSkipped))
+		})
+
+		It("aborts the workflow before a step", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1")}}
+			prompter.EXPECT().Prompt(gomock.Any(), beforeStep).Return(cmd.AbortChoice, nil)
+			Expect(toRun.Run(ctx)).To(Equal(workflow.AbortedByPresenterError))
+		})
+	})
+
+	Context("from and until", func() {
+		var (
+			toRun *workflow.Workflow
+		)
+
+		BeforeEach(func() {
+			toRun = &workflow.Workflow{
+				Steps:        []*workflow.Step{bash("step-1").WithId("first"), bash("step-2").WithId("second"), bash("step-3")},
+				CleanupSteps: []*workflow.Step{bash("cleanup-1")},
+			}
+			ctx.Report = report.New("workflow.yaml")
+		})
+
+		It("starts from a step id", func() {
+			toRun.From = "second"
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-3")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps[0].Status).To(Equal(report.StatusSkipped))
+		})
+
+		It("stops after a step and skips cleanup", func() {
+			toRun.Until = "second"
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("finds steps without an id by position", func() {
+			toRun.From = "steps[2]"
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-3")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("errors for an unknown step or an empty range", func() {
+			toRun.From = "unknown"
+			Expect(toRun.Run(ctx)).To(MatchError(workflow.StepNotFoundError("unknown").Error()))
+			toRun.From, toRun.Until = "second", "first"
+			Expect(toRun.Run(ctx)).To(MatchError(workflow.InvalidStepRangeError("second", "first").Error()))
+		})
+
+		It("saves a checkpoint after each step and resumes from it", func() {
+			dir, err := ioutil.TempDir("", "valet-checkpoint-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			toRun.CheckpointFile = filepath.Join(dir, "checkpoint.yaml")
+			toRun.Steps[0].Capture = map[string]string{"One": "output"}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("one", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+
+			checkpoint, err := workflow.LoadCheckpoint(toRun.CheckpointFile, ctx.FileStore)
+			Expect(err).To(BeNil())
+			Expect(checkpoint.LastStep).To(Equal("first"))
+
+			ctx.SharedState = nil
+			Expect(toRun.ResumeFrom(ctx, checkpoint)).To(BeNil())
+			Expect(toRun.From).To(Equal("second"))
+			Expect(ctx.SharedState.GetValue("One", runner)).To(Equal("one"))
+		})
+
+		It("doesn't save checkpoints after a step that failed under continueOnError", func() {
+			dir, err := ioutil.TempDir("", "valet-checkpoint-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			toRun.CheckpointFile = filepath.Join(dir, "checkpoint.yaml")
+			toRun.Steps[0].ContinueOnError = true
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+			Expect(toRun.CheckpointFile).NotTo(BeAnExistingFile())
+		})
+
+		It("saves a checkpoint after setup and skips setup when resuming from it", func() {
+			dir, err := ioutil.TempDir("", "valet-checkpoint-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			toRun.CheckpointFile = filepath.Join(dir, "checkpoint.yaml")
+			toRun.SetupSteps = []*workflow.Step{bash("setup-1")}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("setup-1")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.SetupOrCleanup(ctx)).To(BeNil())
+			Expect(toRun.Run(ctx)).To(Equal(stepErr))
+
+			checkpoint, err := workflow.LoadCheckpoint(toRun.CheckpointFile, ctx.FileStore)
+			Expect(err).To(BeNil())
+			Expect(checkpoint.LastStep).To(BeEmpty())
+			resumed := &workflow.Workflow{SetupSteps: toRun.SetupSteps, Steps: toRun.Steps}
+			Expect(resumed.ResumeFrom(ctx, checkpoint)).To(BeNil())
+			Expect(resumed.From).To(BeEmpty())
+			// Setup succeeded before the checkpoint, so it isn't run again
+			Expect(resumed.Setup(ctx)).To(BeNil())
+		})
+
+		It("leaves values that contain secrets out of the checkpoint", func() {
+			dir, err := ioutil.TempDir("", "valet-checkpoint-")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(dir)
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			ctx.Redactor = render.NewRedactor()
+			ctx.Redactor.Add("hunter2")
+			toRun.CheckpointFile = filepath.Join(dir, "checkpoints", "checkpoint.yaml")
+			toRun.Steps[0].Capture = map[string]string{"One": "output", "Password": "output"}
+			toRun.Steps = toRun.Steps[:1]
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("hunter2", nil),
+				runner.EXPECT().Output(bashCmd("cleanup-1")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+
+			contents, err := ioutil.ReadFile(toRun.CheckpointFile)
+			Expect(err).To(BeNil())
+			Expect(string(contents)).NotTo(ContainSubstring("hunter2"))
+			checkpoint, err := workflow.LoadCheckpoint(toRun.CheckpointFile, ctx.FileStore)
+			Expect(err).To(BeNil())
+			Expect(checkpoint.Values).To(BeEmpty())
+		})
+	})
+
+	Context("included workflows", func() {
+		var (
+			dir string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "valet-include-")
+			Expect(err).To(BeNil())
+			Expect(os.MkdirAll(filepath.Join(dir, "common"), os.ModePerm)).To(BeNil())
+			included := "values:\n  Name: default\nsetup:\n- bash:\n    path: setup.sh\nsteps:\n- bash:\n    inline: echo {{ .Name }}\n"
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common", "included.yaml"), []byte(included), os.ModePerm)).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common", "setup.sh"), []byte("echo setup"), os.ModePerm)).To(BeNil())
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			ctx.Report = report.New("workflow.yaml")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("runs the setup and steps of the included workflow with paths relative to it", func() {
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{workflow.IncludeWorkflow(filepath.Join(dir, "common", "included.yaml"))},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(&cmd.Command{Name: "bash", Args: []string{filepath.Join(dir, "common", "setup.sh")}, StdOutOnly: true}).Return("", nil),
+				runner.EXPECT().Output(bashCmd("echo {{ .Name }}")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps).To(HaveLen(1))
+			Expect(ctx.Report.Steps[0].Type).To(Equal("workflow"))
+		})
+
+		It("runs the cleanup of the included workflow", func() {
+			included := "steps:\n- bash:\n    inline: echo step\ncleanup:\n- bash:\n    inline: echo cleanup\n"
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common", "cleanup.yaml"), []byte(included), os.ModePerm)).To(BeNil())
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{workflow.IncludeWorkflow(filepath.Join(dir, "common", "cleanup.yaml"))},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("echo step")).Return("", nil),
+				runner.EXPECT().Output(bashCmd("echo cleanup")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("validates the included workflow with the values passed to it", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common", "template.yaml"), []byte("{{ .Name }}-{{ .Other }}"), os.ModePerm)).To(BeNil())
+			included := "steps:\n- applyTemplate:\n    path: template.yaml\n"
+			Expect(ioutil.WriteFile(filepath.Join(dir, "common", "template-workflow.yaml"), []byte(included), os.ModePerm)).To(BeNil())
+			step := workflow.IncludeWorkflow(filepath.Join(dir, "common", "template-workflow.yaml")).WithValue("Name", "name")
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			err := toValidate.Validate(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(render.MissingValueError("Other").Error()))
+			step.WithValue("Other", "other")
+			Expect(toValidate.Validate(ctx)).To(BeNil())
+		})
+
+		It("doesn't check an included workflow with a templated path until it runs", func() {
+			capture := bash("step-1")
+			capture.Capture = map[string]string{"Dir": "output"}
+			step := workflow.IncludeWorkflow(filepath.Join("{{ .Dir }}", "common", "included.yaml"))
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{capture, step}}
+			Expect(toValidate.Validate(ctx)).To(BeNil())
+		})
+
+		It("returns docs for the included workflow", func() {
+			path := filepath.Join(dir, "common", "included.yaml")
+			docs, err := workflow.IncludeWorkflow(path).Workflow.GetDocs(ctx, nil, render.Flags{})
+			Expect(err).To(BeNil())
+			Expect(docs).To(ContainSubstring(path))
+			Expect(docs).To(ContainSubstring("cleanup"))
+			Expect(docs).NotTo(ContainSubstring("aren't run"))
+		})
+	})
+
+	Context("when", func() {
+		It("skips steps whose condition is false and reports them as skipped", func() {
+			ctx.Report = report.New("workflow.yaml")
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{
+					bash("open-source").WithWhen(`{{ eq .Edition "oss" }}`),
+					bash("enterprise").WithWhen(`{{ eq .Edition "enterprise" }}`),
+				},
+				Values: render.Values{"Edition": "enterprise"},
+			}
+			runner.EXPECT().Output(bashCmd("enterprise")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps[0].Status).To(Equal(report.StatusSkipped))
+			Expect(ctx.Report.Steps[1].Status).To(Equal(report.StatusPassed))
+		})
+
+		It("treats an empty result as false and any other result as true", func() {
+			os.Setenv("VALET_WHEN_TEST", "abc123")
+			defer os.Unsetenv("VALET_WHEN_TEST")
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{
+					bash("licensed").WithWhen("{{ .LicenseKey }}"),
+					bash("unlicensed").WithWhen("{{ .Unset }}"),
+				},
+				Values: render.Values{"LicenseKey": "env:VALET_WHEN_TEST", "Unset": "env:VALET_WHEN_TEST_UNSET"},
+			}
+			runner.EXPECT().Output(bashCmd("licensed")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("fails the step when the condition references a missing value", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1").WithWhen("{{ .Missing }}")}}
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("Unable to evaluate when condition {{ .Missing }}"))
+		})
+
+		It("validates the values referenced by the condition", func() {
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1").WithWhen("{{ .Missing }}")}}
+			errs := toValidate.Validate(ctx).(workflow.ValidationErrors)
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Error()).To(ContainSubstring(render.MissingValueError("Missing").Error()))
+		})
+	})
+
+	Context("forEach", func() {
+		var (
+			condition = func(name string) *workflow.Step {
+				return &workflow.Step{Condition: &check.Condition{Type: "pod", Name: name}}
+			}
+			conditionCmd = func(name string) *cmd.Command {
+				return (&check.Condition{Type: "pod", Name: name}).GetCmd(ctx)
+			}
+		)
+
+		It("runs the step once for each item, rendering the step with each item", func() {
+			ctx.Report = report.New("workflow.yaml")
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{condition("petstore-{{ .Route }}").WithForEach("Route", "1", "2", "3")},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(conditionCmd("petstore-1")).Return("", nil),
+				runner.EXPECT().Output(conditionCmd("petstore-2")).Return("", nil),
+				runner.EXPECT().Output(conditionCmd("petstore-3")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Report.Steps).To(HaveLen(1))
+			Expect(ctx.Report.Steps[0].Status).To(Equal(report.StatusPassed))
+		})
+
+		It("reads the items from a list value and skips items where the when condition is false", func() {
+			step := condition("{{ .Item }}").WithWhen(`{{ ne .Item "kube-system" }}`)
+			step.ForEach = &workflow.ForEach{Value: "Namespaces"}
+			toRun := &workflow.Workflow{
+				Steps:  []*workflow.Step{step},
+				Values: render.Values{"Namespaces": "default, kube-system,\ngloo-system"},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(conditionCmd("default")).Return("", nil),
+				runner.EXPECT().Output(conditionCmd("gloo-system")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("keeps the type of items from a list value", func() {
+			step := condition("{{ .Item.name }}")
+			step.ForEach = &workflow.ForEach{Value: "Upstreams"}
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{step},
+				Values: render.Values{"Upstreams": []interface{}{
+					map[string]interface{}{"name": "petclinic"},
+					map[string]interface{}{"name": "petstore"},
+				}},
+			}
+			gomock.InOrder(
+				runner.EXPECT().Output(conditionCmd("petclinic")).Return("", nil),
+				runner.EXPECT().Output(conditionCmd("petstore")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+		})
+
+		It("stops at the first item that fails", func() {
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{bash("step-1").WithForEach("", "1", "2")},
+			}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", stepErr)
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(workflow.ItemFailedError("Item", "1", stepErr).Error()))
+		})
+
+		It("validates the items and provides the item value", func() {
+			missingList := condition("{{ .Item }}")
+			missingList.ForEach = &workflow.ForEach{Value: "Namespaces"}
+			noItems := condition("{{ .Item }}")
+			noItems.ForEach = &workflow.ForEach{}
+			toValidate := &workflow.Workflow{
+				Steps: []*workflow.Step{condition("{{ .Item }}").WithForEach("", "1"), missingList, noItems},
+			}
+			errs := toValidate.Validate(ctx).(workflow.ValidationErrors)
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Error()).To(ContainSubstring(render.MissingValueError("Namespaces").Error()))
+			Expect(errs[1].Error()).To(ContainSubstring(workflow.InvalidForEachError.Error()))
+		})
+	})
+
+	Context("matrix", func() {
+		var (
+			toRun *workflow.Workflow
+		)
+
+		BeforeEach(func() {
+			toRun = &workflow.Workflow{
+				Steps: []*workflow.Step{{Condition: &check.Condition{Type: "pod", Name: "gloo-{{ .GlooVersion }}-{{ .ClusterType }}"}}},
+				Matrix: map[string][]interface{}{
+					"GlooVersion": {"1.3.17", "1.4.0"},
+					"ClusterType": {"kind", "gke"},
+				},
+				Values: render.Values{"GlooVersion": "1.2.0", "Namespace": "gloo-system"},
+			}
+		})
+
+		It("returns every combination of the matrix values", func() {
+			Expect(toRun.GetCombinations()).To(Equal([]render.Values{
+				{"ClusterType": "kind", "GlooVersion": "1.3.17"},
+				{"ClusterType": "kind", "GlooVersion": "1.4.0"},
+				{"ClusterType": "gke", "GlooVersion": "1.3.17"},
+				{"ClusterType": "gke", "GlooVersion": "1.4.0"},
+			}))
+			Expect(workflow.FormatCombination(toRun.GetCombinations()[0])).To(Equal("ClusterType=kind, GlooVersion=1.3.17"))
+			Expect((&workflow.Workflow{}).GetCombinations()).To(BeEmpty())
+		})
+
+		It("runs a fresh copy of the workflow for each combination", func() {
+			for _, combination := range toRun.GetCombinations()[:2] {
+				combinationRun, err := toRun.ForCombination(combination)
+				Expect(err).To(BeNil())
+				Expect(combinationRun.Matrix).To(BeNil())
+				Expect(combinationRun.Values).To(HaveKeyWithValue("Namespace", "gloo-system"))
+				name := fmt.Sprintf("gloo-%s-kind", combination["GlooVersion"])
+				runner.EXPECT().Output((&check.Condition{Type: "pod", Name: name}).GetCmd(ctx)).Return("", nil)
+				Expect(combinationRun.Run(ctx)).To(BeNil())
+			}
+		})
+
+		It("keeps the type of matrix values", func() {
+			toRun := &workflow.Workflow{}
+			Expect(yaml.Unmarshal([]byte("matrix:\n  Replicas: [1, 3]\n  Enterprise: [true]\n"), toRun)).To(BeNil())
+			Expect(toRun.GetCombinations()).To(Equal([]render.Values{
+				{"Enterprise": true, "Replicas": float64(1)},
+				{"Enterprise": true, "Replicas": float64(3)},
+			}))
+		})
+
+		It("uses a separate checkpoint for each combination", func() {
+			toRun.CheckpointFile = filepath.Join("checkpoints", "workflow-1234.yaml")
+			combinations := toRun.GetCombinations()
+			first, err := toRun.ForCombination(combinations[0])
+			Expect(err).To(BeNil())
+			second, err := toRun.ForCombination(combinations[1])
+			Expect(err).To(BeNil())
+			Expect(first.CheckpointFile).To(HavePrefix(filepath.Join("checkpoints", "workflow-1234-")))
+			Expect(first.CheckpointFile).To(HaveSuffix(".yaml"))
+			Expect(first.CheckpointFile).NotTo(Equal(second.CheckpointFile))
+			again, err := toRun.ForCombination(combinations[0])
+			Expect(err).To(BeNil())
+			Expect(again.CheckpointFile).To(Equal(first.CheckpointFile))
+		})
+
+		It("provides the matrix values when validating", func() {
+			Expect(toRun.Validate(ctx)).To(BeNil())
+		})
+	})
+
+	Context("value sources", func() {
+		var (
+			dir   string
+			toRun *workflow.Workflow
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "valet-values-")
+			Expect(err).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "values.yaml"), []byte("Namespace: gloo-system\nReplicas: 2\nClusters: [kind, gke]\n"), os.ModePerm)).To(BeNil())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "values-gke.yaml"), []byte("Namespace: gke\n"), os.ModePerm)).To(BeNil())
+			ctx.FileStore = render.NewFileStore().WithLogger(log.Discard())
+			toRun = &workflow.Workflow{
+				Values: render.Values{"Namespace": "default", "Name": "gloo", "Region": "us-east1"},
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("merges the sources in order of precedence and records where each value came from", func() {
+			globalConfig := &api.ValetGlobalConfig{Values: render.Values{"Region": "us-west1", "Project": "solo"}}
+			valuesFiles := []string{filepath.Join(dir, "values.yaml"), filepath.Join(dir, "values-gke.yaml")}
+			sources, err := toRun.GetValueSources(ctx.FileStore, globalConfig, valuesFiles, render.Values{"Name": "gloo-ee"})
+			Expect(err).To(BeNil())
+			Expect(sources.Merge()).To(Equal(render.Values{
+				"Project":   "solo",
+				"Region":    "us-east1",
+				"Namespace": "gke",
+				"Replicas":  int64(2),
+				"Clusters":  []interface{}{"kind", "gke"},
+				"Name":      "gloo-ee",
+			}))
+			Expect(sources.Origins()).To(Equal(map[string]string{
+				"Project":   workflow.GlobalConfigSource,
+				"Region":    workflow.WorkflowSource,
+				"Namespace": workflow.ValuesFileSource(valuesFiles[1]),
+				"Replicas":  workflow.ValuesFileSource(valuesFiles[0]),
+				"Clusters":  workflow.ValuesFileSource(valuesFiles[0]),
+				"Name":      workflow.CliSource,
+			}))
+		})
+
+		It("returns an error for a missing values file", func() {
+			_, err := toRun.GetValueSources(ctx.FileStore, nil, []string{filepath.Join(dir, "missing.yaml")}, nil)
+			Expect(err).NotTo(BeNil())
+		})
+
+		It("finds a step by id or position", func() {
+			step := (&workflow.Step{Id: "check"}).WithValue("Name", "step")
+			toRun.Steps = []*workflow.Step{bash("echo"), step}
+			Expect(toRun.GetStep("check")).To(Equal(step))
+			Expect(toRun.GetStep("steps[1]")).To(Equal(step))
+			_, err := toRun.GetStep("missing")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("parallel", func() {
+		It("runs every branch, keeps the output of each branch together and captures values from each", func() {
+			out := &bytes.Buffer{}
+			ctx.Logger = log.New(log.Options{Out: out, Err: out})
+			first := bash("step-1").WithId("first")
+			first.Capture = map[string]string{"FIRST": "output"}
+			second := bash("step-2").WithId("second")
+			second.Capture = map[string]string{"SECOND": "output"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{workflow.RunInParallel(first, second), bash("step-3")}}
+			secondDone := make(chan struct{})
+			runner.EXPECT().Output(bashCmd("step-1")).DoAndReturn(func(*cmd.Command) (string, error) {
+				<-secondDone
+				return "one", nil
+			})
+			runner.EXPECT().Output(bashCmd("step-2")).DoAndReturn(func(*cmd.Command) (string, error) {
+				defer close(secondDone)
+				return "two", nil
+			})
+			runner.EXPECT().Output(bashCmd("step-3")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.SharedState).To(HaveKeyWithValue("FIRST", render.RawPrefix+"one"))
+			Expect(ctx.SharedState).To(HaveKeyWithValue("SECOND", render.RawPrefix+"two"))
+			// The second branch finishes first, so all of its output comes first
+			output := out.String()
+			Expect(output).To(ContainSubstring("Running command: bash -c 'step-1' step=first\n"))
+			Expect(strings.Index(output, "two step=second\n")).To(BeNumerically("<", strings.Index(output, "Running command: bash -c 'step-1'")))
+		})
+
+		It("waits for every branch and aggregates the errors in waitAll mode", func() {
+			step := workflow.RunInParallel(bash("step-1"), bash("step-2"), bash("step-3"))
+			step.Parallel.FailureMode = workflow.WaitAllMode
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			// eris records the stack of a wrapped error in its root, so concurrent branches can't share one error
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", errors.Errorf("step 1 failed"))
+			runner.EXPECT().Output(bashCmd("step-2")).Return("", nil)
+			runner.EXPECT().Output(bashCmd("step-3")).Return("", errors.Errorf("step 3 failed"))
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			parallelErrs, ok := errors.Cause(err).(workflow.ParallelErrors)
+			Expect(ok).To(BeTrue())
+			Expect(parallelErrs).To(HaveLen(2))
+			Expect(err.Error()).To(ContainSubstring("Parallel step steps[0].parallel[0] failed"))
+			Expect(err.Error()).To(ContainSubstring("Parallel step steps[0].parallel[2] failed"))
+		})
+
+		It("cancels the other branches and waits for them in failFast mode", func() {
+			ctx.Report = report.New("workflow.yaml")
+			kubeClient := mock_kube.NewMockClient(ctrl)
+			ctx.KubeClient = kubeClient
+			waiting := &workflow.Step{WaitForResources: &check.WaitForResources{
+				Resources: []string{"deployment/petclinic"},
+				Namespace: "default",
+				Timeout:   "1m",
+				Interval:  "1ms",
+			}}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{workflow.RunInParallel(bash("step-1"), waiting)}}
+			started := make(chan struct{})
+			var once sync.Once
+			checker := mock_kube.NewMockResourceChecker(ctrl)
+			kubeClient.EXPECT().NewResourceChecker().Return(checker, nil)
+			checker.EXPECT().GetReadiness(gomock.Any()).DoAndReturn(func([]kube.ResourceRef) ([]kube.Readiness, error) {
+				once.Do(func() { close(started) })
+				return []kube.Readiness{{Resource: kube.ResourceRef{Kind: "Deployment", Name: "petclinic"}}}, nil
+			}).AnyTimes()
+			runner.EXPECT().Output(bashCmd("step-1")).DoAndReturn(func(*cmd.Command) (string, error) {
+				<-started
+				return "", stepErr
+			})
+			start := time.Now()
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring(workflow.BranchFailedError("steps[0].parallel[0]", stepErr).Error()))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			// Both branches finished (and were reported) before the parallel step returned
+			var statuses []string
+			for _, step := range ctx.Report.Steps {
+				if step.Phase == "steps[0].parallel" {
+					statuses = append(statuses, step.Status)
+				}
+			}
+			Expect(statuses).To(ConsistOf(report.StatusFailed, report.StatusFailed))
+		})
+
+		It("holds the output of the commands of each branch until it finishes", func() {
+			out := &bytes.Buffer{}
+			ctx := workflow.DefaultContextWithLogger(context.TODO(), log.New(log.Options{Out: out, Err: out}))
+			step := workflow.RunInParallel(bash("sleep 0.2; echo first >&2; exit 1"), bash("echo second >&2; exit 1"))
+			step.Parallel.FailureMode = workflow.WaitAllMode
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			Expect(toRun.Run(ctx)).NotTo(BeNil())
+			output := out.String()
+			Expect(output).To(ContainSubstring("Error running command: exit status 1 step=steps[0].parallel[0]\n"))
+			Expect(output).To(ContainSubstring("Error running command: exit status 1 step=steps[0].parallel[1]\n"))
+			Expect(strings.Index(output, "second")).To(BeNumerically("<", strings.Index(output, "step=steps[0].parallel[0]")))
+		})
+
+		It("prints the output of every branch in failFast mode, including branches that finish after a failure", func() {
+			out := &bytes.Buffer{}
+			ctx.Logger = log.New(log.Options{Out: out, Err: out})
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{workflow.RunInParallel(bash("step-1"), bash("step-2"))}}
+			failed := make(chan struct{})
+			runner.EXPECT().Output(bashCmd("step-1")).DoAndReturn(func(*cmd.Command) (string, error) {
+				defer close(failed)
+				return "", stepErr
+			})
+			runner.EXPECT().Output(bashCmd("step-2")).DoAndReturn(func(*cmd.Command) (string, error) {
+				<-failed
+				return "two", nil
+			})
+			Expect(toRun.Run(ctx)).NotTo(BeNil())
+			Expect(out.String()).To(ContainSubstring("Running command: bash -c 'step-2' step=steps[0].parallel[1]\n"))
+		})
+
+		It("records the branches of each parallel step separately in the report", func() {
+			ctx.Report = report.New("workflow.yaml")
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{
+				workflow.RunInParallel(bash("step-1")),
+				workflow.RunInParallel(bash("step-2")).WithId("second"),
+			}}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", nil)
+			runner.EXPECT().Output(bashCmd("step-2")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			var names []string
+			for _, step := range ctx.Report.Steps {
+				names = append(names, step.Name())
+			}
+			Expect(names).To(ConsistOf(
+				"steps[0] (parallel)",
+				"steps[0].parallel[0] (bash)",
+				"steps[1] second (parallel)",
+				"steps[1].second.parallel[0] (bash)",
+			))
+		})
+
+		It("returns the docs of each branch", func() {
+			step := workflow.RunInParallel(bash("step-1"), &workflow.Step{WaitForPods: &check.WaitForPods{Namespace: "petclinic"}})
+			docs, err := step.Parallel.GetDocs(ctx, nil, render.Flags{})
+			Expect(err).To(BeNil())
+			Expect(docs).To(ContainSubstring("1. Run the bash step."))
+			Expect(docs).To(ContainSubstring("2. Wait until the pods in namespace 'petclinic' are ready."))
+		})
+
+		It("validates each branch, the failure mode and values captured by branches", func() {
+			capturing := bash("step-1")
+			capturing.Capture = map[string]string{"Captured": "output"}
+			missing := &workflow.Step{Condition: &check.Condition{Name: "{{ .Missing }}"}}
+			step := workflow.RunInParallel(capturing, missing)
+			step.Parallel.FailureMode = "sometimes"
+			condition := &workflow.Step{Condition: &check.Condition{Name: "{{ .Captured }}"}}
+			toValidate := &workflow.Workflow{Steps: []*workflow.Step{step, condition}}
+			errs := toValidate.Validate(ctx).(workflow.ValidationErrors)
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0].Error()).To(ContainSubstring(workflow.UnknownFailureModeError("sometimes").Error()))
+			Expect(errs[1].Error()).To(ContainSubstring(workflow.InvalidStepError("parallel[1]", render.MissingValueError("Missing")).Error()))
+		})
+	})
+
+	Context("capture", func() {
+		It("captures step outputs into values for later steps", func() {
+			step := bash("step-1")
+			step.Capture = map[string]string{"TOKEN": "output"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step, bash("step-2")}}
+			gomock.InOrder(
+				runner.EXPECT().Output(bashCmd("step-1")).Return("abc123\n", nil),
+				runner.EXPECT().Output(bashCmd("step-2")).Return("", nil),
+			)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.SharedState).To(HaveKeyWithValue("TOKEN", render.RawPrefix+"abc123"))
+			Expect(ctx.SharedState.GetValue("TOKEN", runner)).To(Equal("abc123"))
+		})
+
+		It("captures only the stdout of a bash step", func() {
+			ctx := workflow.DefaultContextWithLogger(context.TODO(), log.Discard())
+			step := bash("echo warning >&2; echo abc123")
+			step.Capture = map[string]string{"TOKEN": "output"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.SharedState).To(HaveKeyWithValue("TOKEN", render.RawPrefix+"abc123"))
+		})
+
+		It("errors when the step does not publish the output", func() {
+			step := bash("step-1")
+			step.Capture = map[string]string{"TOKEN": "body"}
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{step}}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("abc123", nil)
+			err := toRun.Run(ctx)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal(workflow.OutputNotFoundError("body", "bash").Error()))
+		})
+	})
+
+	Context("diagnostics", func() {
+		var (
+			kubeClient *mock_kube.MockClient
+			dir        string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "valet-diagnostics-")
+			Expect(err).To(BeNil())
+			kubeClient = mock_kube.NewMockClient(ctrl)
+			ctx.KubeClient = kubeClient
+			ctx.Diagnostics = diagnostics.NewBundle(dir)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		readFile := func(file string) string {
+			contents, err := ioutil.ReadFile(filepath.Join(dir, file))
+			Expect(err).To(BeNil())
+			return string(contents)
+		}
+
+		It("collects the namespaces touched by earlier steps when a step fails", func() {
+			ctx.Redactor = render.NewRedactor()
+			ctx.Redactor.Add("hunter2")
+			toRun := &workflow.Workflow{
+				Steps: []*workflow.Step{
+					{WaitForPods: &check.WaitForPods{Namespace: "petclinic"}},
+					bash("curl"),
+				},
+			}
+			pod := v1.Pod{
+				ObjectMeta: v12.ObjectMeta{Name: "petclinic-1", Namespace: "petclinic"},
+				Status: v1.PodStatus{
+					Phase: v1.PodPending,
+					ContainerStatuses: []v1.ContainerStatus{{
+						Name:         "petclinic",
+						RestartCount: 4,
+						State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					}},
+				},
+			}
+			event := v1.Event{
+				InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "petclinic-1"},
+				Type:           "Warning",
+				Reason:         "BackOff",
+				Message:        "Back-off restarting failed container",
+			}
+			describe := ctx.Kubectl().With("describe", "pods,deployments,statefulsets,daemonsets,jobs,services").Namespace("petclinic").SwallowErrorLog(true).Cmd()
+			gomock.InOrder(
+				kubeClient.EXPECT().WaitForPods(gomock.Any()).Return(nil),
+				runner.EXPECT().Output(bashCmd("curl")).Return("", errors.Errorf("connection refused with token hunter2")),
+				runner.EXPECT().Output(describe).Return("Name: petclinic-1", nil),
+				kubeClient.EXPECT().GetNamespaceDiagnostics("petclinic", int64(workflow.DiagnosticsLogLines)).Return(&kube.NamespaceDiagnostics{
+					Namespace: "petclinic",
+					Pods: []kube.PodDiagnostics{{
+						Pod:  pod,
+						Logs: map[string]string{"petclinic": "started", "petclinic" + kube.PreviousLogsSuffix: "panic: bad password hunter2"},
+					}},
+					Events: []v1.Event{event},
+				}, nil),
+			)
+			Expect(toRun.Run(ctx)).To(HaveOccurred())
+			Expect(readFile("steps-1/error.txt")).To(Equal("connection refused with token " + cmd.Redacted + "\n"))
+			Expect(readFile("steps-1/namespaces/petclinic/describe.txt")).To(Equal("Name: petclinic-1"))
+			Expect(readFile("steps-1/namespaces/petclinic/pods.txt")).To(MatchRegexp(`petclinic-1\s+0/1\s+CrashLoopBackOff\s+4`))
+			Expect(readFile("steps-1/namespaces/petclinic/events.txt")).To(MatchRegexp(`Warning\s+BackOff\s+pod/petclinic-1\s+Back-off restarting failed container`))
+			Expect(readFile("steps-1/namespaces/petclinic/logs/petclinic-1/petclinic.log")).To(Equal("started"))
+			Expect(readFile("steps-1/namespaces/petclinic/logs/petclinic-1/petclinic.previous.log")).To(Equal("panic: bad password " + cmd.Redacted))
+			Expect(readFile("steps-1/namespaces/petclinic/pods.yaml")).To(ContainSubstring("name: petclinic-1"))
+		})
+
+		It("records the namespaces of a template without rendering it again", func() {
+			fileStore := mock_render.NewMockFileStore(ctrl)
+			ctx.FileStore = fileStore
+			ctx.NativeApply = true
+			toRun := &workflow.Workflow{
+				Values: render.Values{"Namespace": "petclinic"},
+				Steps:  []*workflow.Step{{ApplyTemplate: &kubectl.ApplyTemplate{Path: "template.yaml"}}},
+			}
+			// The template is only loaded and rendered to apply it
+			manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n  namespace: {{ .Namespace }}\n"
+			fileStore.EXPECT().Load("template.yaml").Return(manifest, nil).Times(1)
+			kubeClient.EXPECT().ApplyManifests(gomock.Any(), false).Return(nil, nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			Expect(ctx.Diagnostics.GetNamespaces()).To(Equal([]string{"petclinic"}))
+		})
+
+		It("doesn't collect diagnostics when the workflow passes", func() {
+			toRun := &workflow.Workflow{Steps: []*workflow.Step{bash("step-1")}}
+			runner.EXPECT().Output(bashCmd("step-1")).Return("", nil)
+			Expect(toRun.Run(ctx)).To(BeNil())
+			files, err := ioutil.ReadDir(dir)
+			Expect(err).To(BeNil())
+			Expect(files).To(BeEmpty())
+		})
+	})
+})
@@ -2,6 +2,7 @@ package gloo
 
 import (
 	"fmt"
+	"github.com/solo-io/valet/pkg/render"
 	"github.com/solo-io/valet/pkg/step/check"
 	"github.com/solo-io/valet/pkg/step/helm"
 	"github.com/solo-io/valet/pkg/step/kubectl"
@@ -58,7 +59,7 @@ func InstallGlooEnterprise() *workflow.Step {
 			ReleaseUri:  "https://storage.googleapis.com/gloo-ee-helm/charts/gloo-ee-1.3.0.tgz",
 			Namespace:   "gloo-system",
 			WaitForPods: true,
-			Set: map[string]string{
+			Set: render.Values{
 				"license_key": "env:LICENSE_KEY",
 			},
 		},